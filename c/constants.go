@@ -5,9 +5,53 @@ const (
 	MySQLErrDuplicateEntryCode = 1062
 	TimeFormat                 = "2006-01-02T15:04:05Z"
 
-	DriverMysql = "mysql"
+	DriverMysql    = "mysql"
+	DriverSqlite   = "sqlite"
+	DriverPostgres = "postgres"
 
 	BlockConsumerType = "block"
 	LogConsumerType   = "log"
 	TxConsumerType    = "tx"
+
+	WithdrawalConsumerType = "withdrawal"
+	BalanceConsumerType    = "balance"
+	ClickHouseConsumerType = "clickhouse"
+
+	BalanceModeDerived = "derived"
+	BalanceModeRPC     = "rpc"
+
+	MempoolModeSubscribe = "subscribe"
+	MempoolModePoll      = "poll"
+
+	ClickHouseModeMirror = "mirror"
+	ClickHouseModeOnly   = "only"
+
+	TxStatusPending = "pending"
+	TxStatusMined   = "mined"
+	TxStatusDropped = "dropped"
+)
+
+const (
+	// JobReasonHead is a job following the chain head
+	JobReasonHead = "head"
+	// JobReasonBackfill is a job indexing blocks far behind the head
+	JobReasonBackfill = "backfill"
+	// JobReasonReindex is a job indexing blocks already indexed again
+	JobReasonReindex = "reindex"
+	// JobReasonGap is a job filling blocks missing below the watermark
+	JobReasonGap = "gap"
+	// JobReasonReorg is a job indexing blocks replaced by a reorganization
+	JobReasonReorg = "reorg"
+
+	JobPriorityBackfill = 0
+	JobPriorityHead     = 1
+)
+
+const (
+	// WriteModeInsert keeps the rows already stored
+	WriteModeInsert = "insert"
+	// WriteModeUpsert overwrites the rows already stored
+	WriteModeUpsert = "upsert"
+	// WriteModeReplace deletes the rows of a block before writing them again
+	WriteModeReplace = "replace"
 )
@@ -5,4 +5,9 @@ const (
 	Log               = "log"
 	Tx                = "tx"
 	LatestBlockNumber = "latest_block_number"
+	Abi               = "abi"
+	Withdrawal        = "withdrawal"
+	Balance           = "balance"
+	PendingTx         = "pending_tx"
+	Lease             = "lease"
 )
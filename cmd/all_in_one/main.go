@@ -0,0 +1,116 @@
+package main
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/internal/services/api_service/app"
+	"Ethereum_Service/internal/services/indexer_service"
+	"Ethereum_Service/internal/services/mempool"
+	"Ethereum_Service/internal/services/producer"
+	"flag"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var (
+	// flagconf is the config flag.
+	flagconf string
+)
+
+func init() {
+	flag.StringVar(&flagconf, "conf", "../../", "config path, eg: -conf config.yaml")
+}
+
+type services struct {
+	producers []*producer.Producer
+	indexers  []*indexer_service.Service
+	watchers  []*mempool.Watcher
+}
+
+// shutdown stops the producers before the indexers so the blocks already
+// enqueued are drained, the api server is stopped last.
+func (s *services) shutdown(server *app.Application) {
+	var wg sync.WaitGroup
+	for _, p := range s.producers {
+		wg.Add(1)
+		go func(p *producer.Producer) {
+			defer wg.Done()
+			p.Shutdown()
+		}(p)
+	}
+	wg.Wait()
+
+	for _, watcher := range s.watchers {
+		watcher.Shutdown()
+	}
+	for _, indexer := range s.indexers {
+		wg.Add(1)
+		go func(indexer *indexer_service.Service) {
+			defer wg.Done()
+			indexer.Shutdown()
+		}(indexer)
+	}
+	wg.Wait()
+
+	server.Shutdown()
+}
+
+func handleSignals(server *app.Application, s *services, done chan<- struct{}) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	server.GetLogger().Infof("signal %s received", <-sigs)
+	s.shutdown(server)
+	close(done)
+}
+
+// main runs the producer, the indexer workers and the api service of every
+// chain in one process, the blocks go through an in-process queue.
+func main() {
+	flag.Parse()
+	config.LoadConf(flagconf, config.GetConfig())
+	config.GetConfig().Queue.Backend = mq.BackendMemory
+
+	// tracing, the database and the routes are set up before the workers start
+	server := app.Default()
+	server.Init()
+	health := server.GetHealth()
+
+	s := &services{}
+	for _, chain := range config.GetConfig().GetChains() {
+		indexer, err := indexer_service.NewService(chain)
+		if err != nil {
+			panic(err)
+		}
+		workerNumber := chain.WorkerNumber
+		if workerNumber == 0 {
+			workerNumber = config.GetConfig().WorkerNumber
+		}
+		go indexer.Start(workerNumber)
+		indexer.RegisterHealth(health)
+		s.indexers = append(s.indexers, indexer)
+
+		p, err := producer.NewProducer(chain)
+		if err != nil {
+			panic(err)
+		}
+		go p.Start()
+		p.RegisterHealth(health)
+		s.producers = append(s.producers, p)
+
+		if config.GetConfig().Mempool.Enable {
+			watcher, err := mempool.NewWatcher(chain, config.GetConfig().Mempool)
+			if err != nil {
+				panic(err)
+			}
+			watcher.Start()
+			s.watchers = append(s.watchers, watcher)
+		}
+	}
+
+	done := make(chan struct{})
+	go handleSignals(server, s, done)
+	server.Run()
+	<-done
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
 	"Ethereum_Service/internal/services/indexer_service"
+	"Ethereum_Service/internal/services/mempool"
+	"Ethereum_Service/internal/tracing"
 	"flag"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -18,23 +22,69 @@ func init() {
 	flag.StringVar(&flagconf, "conf", "../../", "config path, eg: -conf config.yaml")
 }
 
-func handleSignals(service *indexer_service.Service) {
+func handleSignals(services []*indexer_service.Service, watchers []*mempool.Watcher) {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	<-sigs
-	service.Shutdown()
+	for _, watcher := range watchers {
+		watcher.Shutdown()
+	}
+	// every chain drains within its own deadline
+	var wg sync.WaitGroup
+	for _, service := range services {
+		wg.Add(1)
+		go func(service *indexer_service.Service) {
+			defer wg.Done()
+			service.Shutdown()
+		}(service)
+	}
+	wg.Wait()
 }
 
 func main() {
 	flag.Parse()
 	config.LoadConf(flagconf, config.GetConfig())
 
-	service, err := indexer_service.NewService(config.GetConfig().RCPEndpoint)
-
+	shutdownTracing, err := tracing.Init("eth_block_indexer", config.GetConfig().Tracing)
 	if err != nil {
 		panic(err)
 	}
-	go service.Start(config.GetConfig().WorkerNumber)
+	defer shutdownTracing()
+
+	health := monitor.NewHealth("eth_block_indexer")
+
+	var services []*indexer_service.Service
+	var watchers []*mempool.Watcher
+	for _, chain := range config.GetConfig().GetChains() {
+		service, err := indexer_service.NewService(chain)
+		if err != nil {
+			panic(err)
+		}
+		workerNumber := chain.WorkerNumber
+		if workerNumber == 0 {
+			workerNumber = config.GetConfig().WorkerNumber
+		}
+		go service.Start(workerNumber)
+		service.RegisterHealth(health)
+		services = append(services, service)
+
+		if config.GetConfig().Mempool.Enable {
+			watcher, err := mempool.NewWatcher(chain, config.GetConfig().Mempool)
+			if err != nil {
+				panic(err)
+			}
+			watcher.Start()
+			watchers = append(watchers, watcher)
+		}
+	}
+
+	// metrics and health checks are served next to the workers, the api service
+	// serves them itself
+	if addr := config.GetConfig().MonitorAddr; addr != "" {
+		monitorServer := monitor.NewServer(addr, health)
+		monitorServer.Start()
+		defer monitorServer.Shutdown()
+	}
 
-	handleSignals(service)
+	handleSignals(services, watchers)
 }
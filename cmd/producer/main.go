@@ -2,10 +2,13 @@ package main
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
 	"Ethereum_Service/internal/services/producer"
+	"Ethereum_Service/internal/tracing"
 	"flag"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -18,22 +21,52 @@ func init() {
 	flag.StringVar(&flagconf, "conf", "../../", "config path, eg: -conf config.yaml")
 }
 
-func handleSignals(service *producer.Producer) {
+func handleSignals(services []*producer.Producer) {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	<-sigs
+
+	var wg sync.WaitGroup
+	for _, service := range services {
+		wg.Add(1)
+		go func(service *producer.Producer) {
+			defer wg.Done()
+			service.Shutdown()
+		}(service)
+	}
+	wg.Wait()
 }
 
 func main() {
 	flag.Parse()
 	config.LoadConf(flagconf, config.GetConfig())
 
-	service, err := producer.NewProducer(config.GetConfig().RCPEndpoint)
-
+	shutdownTracing, err := tracing.Init("producer", config.GetConfig().Tracing)
 	if err != nil {
 		panic(err)
 	}
-	go service.Start()
+	defer shutdownTracing()
+
+	health := monitor.NewHealth("producer")
+
+	var services []*producer.Producer
+	for _, chain := range config.GetConfig().GetChains() {
+		service, err := producer.NewProducer(chain)
+		if err != nil {
+			panic(err)
+		}
+		go service.Start()
+		service.RegisterHealth(health)
+		services = append(services, service)
+	}
+
+	// metrics and health checks are served next to the workers, the api service
+	// serves them itself
+	if addr := config.GetConfig().MonitorAddr; addr != "" {
+		monitorServer := monitor.NewServer(addr, health)
+		monitorServer.Start()
+		defer monitorServer.Shutdown()
+	}
 
-	handleSignals(service)
+	handleSignals(services)
 }
@@ -1,10 +1,22 @@
 package config
 
 import (
+	"Ethereum_Service/c"
+	"fmt"
 	"sync"
 	"time"
 )
 
+// DefaultChainName is the name of the chain built from RCP_ENDPOINT when CHAINS is not set.
+const DefaultChainName = "default"
+
+// DefaultJobRangeSize is the number of blocks of a job when JOB_RANGE_SIZE is not set.
+const DefaultJobRangeSize = 100
+
+// DefaultMaxReindexBlocks is the number of blocks one reindex request may
+// cover when MAX_REINDEX_BLOCKS is not set.
+const DefaultMaxReindexBlocks = 100000
+
 var globalConfig *Config
 var configOnce sync.Once
 
@@ -33,12 +45,39 @@ type Config struct {
 
 	Redis RedisOption `mapstructure:"REDIS"`
 
+	Chains []ChainOption `mapstructure:"CHAINS"`
+
+	Balance BalanceOption `mapstructure:"BALANCE"`
+	Mempool MempoolOption `mapstructure:"MEMPOOL"`
+	Tracing TracingOption `mapstructure:"TRACING"`
+	Queue   QueueOption   `mapstructure:"QUEUE"`
+
+	ClickHouse ClickHouseOption `mapstructure:"CLICKHOUSE"`
+
+	Concurrency ConcurrencyOption `mapstructure:"CONCURRENCY"`
+
 	WorkerNumber      int    `mapstructure:"WORKER_NUMBER"`
 	StoreBufferSize   int    `mapstructure:"STORE_BUFFER_SIZE"`
 	MigrationFilePath string `mapstructure:"MIGRATION_FILE_PATH"`
 	MaxRetryTime      int    `mapstructure:"MAX_RETRY_TIME"`
+	JobRangeSize      int    `mapstructure:"JOB_RANGE_SIZE"`
+	MaxReindexBlocks  int    `mapstructure:"MAX_REINDEX_BLOCKS"`
 	RCPEndpoint       string `mapstructure:"RCP_ENDPOINT"`
 	MQEndpoint        string `mapstructure:"MQ_ENDPOINT"`
+	AdminToken        string `mapstructure:"ADMIN_TOKEN"`
+	MonitorAddr       string `mapstructure:"MONITOR_ADDR"`
+
+	// ChainId and Profile are the CHAIN_ID and PROFILE of the single chain
+	// of RCP_ENDPOINT, unused with CHAINS
+	ChainId int64  `mapstructure:"CHAIN_ID"`
+	Profile string `mapstructure:"PROFILE"`
+
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// BackfillShare is the share of the workers of a chain consuming the
+	// backfill lane, the others consume the head lane
+	BackfillShare float64 `mapstructure:"BACKFILL_SHARE"`
+
+	LeaderElection LeaderElectionOption `mapstructure:"LEADER_ELECTION"`
 }
 
 // Service defines service configuration struct.
@@ -83,6 +122,210 @@ type DatabaseOption struct {
 	Timeout      time.Duration `mapstructure:"TIMEOUT"`
 	ReadTimeout  time.Duration `mapstructure:"READ_TIMEOUT"`
 	WriteTimeout time.Duration `mapstructure:"WRITE_TIMEOUT"`
+	SSLMode      string        `mapstructure:"SSL_MODE"`
+	JournalMode  string        `mapstructure:"JOURNAL_MODE"`
+}
+
+// BalanceOption controls native balance tracking of the indexer, MODE is
+// empty (disabled), derived or rpc.
+type BalanceOption struct {
+	Mode          string `mapstructure:"MODE"`
+	TraceInternal bool   `mapstructure:"TRACE_INTERNAL"`
+}
+
+// ClickHouseOption streams the indexed blocks to ClickHouse for analytics,
+// MODE is empty (disabled), mirror (database and ClickHouse) or only (ClickHouse
+// instead of the database). The rows are inserted once BATCH_SIZE rows are
+// buffered or every FLUSH_INTERVAL.
+type ClickHouseOption struct {
+	Mode          string        `mapstructure:"MODE"`
+	Addrs         []string      `mapstructure:"ADDRS"`
+	Database      string        `mapstructure:"DATABASE"`
+	Username      string        `mapstructure:"USERNAME"`
+	Password      string        `mapstructure:"PASSWORD"`
+	BatchSize     int           `mapstructure:"BATCH_SIZE"`
+	FlushInterval time.Duration `mapstructure:"FLUSH_INTERVAL"`
+}
+
+// MempoolOption controls the pending transaction watcher of the indexer, MODE
+// is subscribe (newPendingTransactions over the WS_ENDPOINT of the chain) or
+// poll (txpool_content). WS_ENDPOINT here is only used without CHAINS.
+type MempoolOption struct {
+	Enable       bool          `mapstructure:"ENABLE"`
+	Mode         string        `mapstructure:"MODE"`
+	WsEndpoint   string        `mapstructure:"WS_ENDPOINT"`
+	PollInterval time.Duration `mapstructure:"POLL_INTERVAL"`
+	DropTimeout  time.Duration `mapstructure:"DROP_TIMEOUT"`
+}
+
+// TracingOption controls the OpenTelemetry exporter, EXPORTER is empty
+// (disabled), otlp (OTLP over HTTP to ENDPOINT), stdout or file (FILE).
+type TracingOption struct {
+	Exporter    string  `mapstructure:"EXPORTER"`
+	Endpoint    string  `mapstructure:"ENDPOINT"`
+	Insecure    bool    `mapstructure:"INSECURE"`
+	File        string  `mapstructure:"FILE"`
+	SampleRatio float64 `mapstructure:"SAMPLE_RATIO"`
+}
+
+// QueueOption selects the message queue between the producer and the indexer,
+// BACKEND is rabbitmq (default, MQ_ENDPOINT), kafka (KAFKA_BROKERS), nats
+// (NATS_URL with JetStream), redis (Redis Streams on REDIS) or memory, which
+// only connects services running in the same process.
+type QueueOption struct {
+	Backend      string   `mapstructure:"BACKEND"`
+	KafkaBrokers []string `mapstructure:"KAFKA_BROKERS"`
+	NatsURL      string   `mapstructure:"NATS_URL"`
+}
+
+// ConcurrencyOption controls the workers of the indexer. PREFETCH is the
+// number of unacknowledged jobs per worker. With ADAPTIVE the backfill workers
+// of a chain move between MIN_WORKERS and MAX_WORKERS every INTERVAL: one more
+// while the RPC calls are healthy, half as many on throttling (429), timeouts,
+// an error rate above MAX_ERROR_RATE or an average latency above
+// TARGET_LATENCY.
+type ConcurrencyOption struct {
+	Prefetch      int           `mapstructure:"PREFETCH"`
+	Adaptive      bool          `mapstructure:"ADAPTIVE"`
+	MinWorkers    int           `mapstructure:"MIN_WORKERS"`
+	MaxWorkers    int           `mapstructure:"MAX_WORKERS"`
+	Interval      time.Duration `mapstructure:"INTERVAL"`
+	TargetLatency time.Duration `mapstructure:"TARGET_LATENCY"`
+	MaxErrorRate  float64       `mapstructure:"MAX_ERROR_RATE"`
+}
+
+// LeaderElectionOption lets several producers of a chain run side by side,
+// only the holder of the lease publishes jobs and moves the watermark. The
+// lease expires LEASE_TTL after its last renewal.
+type LeaderElectionOption struct {
+	Enable   bool          `mapstructure:"ENABLE"`
+	LeaseTTL time.Duration `mapstructure:"LEASE_TTL"`
+}
+
+// ChainOption is a chain indexed by the deployment, NAME is the API path prefix
+// and the queue namespace, CHAIN_ID is stored with every row of the chain.
+// PROFILE is ethereum (default), bsc, polygon or optimism.
+type ChainOption struct {
+	Name         string   `mapstructure:"NAME"`
+	ChainId      int64    `mapstructure:"CHAIN_ID"`
+	Profile      string   `mapstructure:"PROFILE"`
+	RCPEndpoints []string `mapstructure:"RCP_ENDPOINTS"`
+	WsEndpoint   string   `mapstructure:"WS_ENDPOINT"`
+	WorkerNumber int      `mapstructure:"WORKER_NUMBER"`
+}
+
+// GetChains returns the configured chains, without CHAINS the single chain of
+// RCP_ENDPOINT is returned with the top-level CHAIN_ID and PROFILE. Its chain
+// id defaults to 0, which rows indexed before multi-chain support were
+// migrated to.
+func (c *Config) GetChains() []ChainOption {
+	if len(c.Chains) != 0 {
+		return c.Chains
+	}
+	return []ChainOption{{
+		Name:         DefaultChainName,
+		ChainId:      c.ChainId,
+		Profile:      c.Profile,
+		RCPEndpoints: []string{c.RCPEndpoint},
+		WsEndpoint:   c.Mempool.WsEndpoint,
+		WorkerNumber: c.WorkerNumber,
+	}}
+}
+
+// GetJobRangeSize returns the number of blocks of a job.
+func (c *Config) GetJobRangeSize() int64 {
+	if c.JobRangeSize <= 0 {
+		return DefaultJobRangeSize
+	}
+	return int64(c.JobRangeSize)
+}
+
+// GetMaxReindexBlocks returns the number of blocks one reindex request may cover.
+func (c *Config) GetMaxReindexBlocks() int64 {
+	if c.MaxReindexBlocks <= 0 {
+		return DefaultMaxReindexBlocks
+	}
+	return int64(c.MaxReindexBlocks)
+}
+
+// reservedChainNames collide with the api routes next to the chain path
+// prefixes.
+var reservedChainNames = map[string]bool{
+	"transaction": true,
+	"blocks":      true,
+	"address":     true,
+	"abi":         true,
+	"reindex":     true,
+}
+
+// Validate rejects CHAINS which would mix the rows or the queues of chains,
+// CHAIN_ID 0 is the chain id of the single chain setup and NAME prefixes the
+// queues and api routes of the chain.
+func (c *Config) Validate() error {
+	ids := make(map[int64]string)
+	names := make(map[string]bool)
+	for _, chain := range c.Chains {
+		if chain.Name == "" {
+			return fmt.Errorf("Validate : NAME of chain %d is missing", chain.ChainId)
+		}
+		if reservedChainNames[chain.Name] {
+			return fmt.Errorf("Validate : chain name %s is reserved", chain.Name)
+		}
+		if names[chain.Name] {
+			return fmt.Errorf("Validate : chain name %s is used twice", chain.Name)
+		}
+		names[chain.Name] = true
+		if chain.ChainId == 0 {
+			return fmt.Errorf("Validate : CHAIN_ID of chain %s is missing", chain.Name)
+		}
+		if name, ok := ids[chain.ChainId]; ok {
+			return fmt.Errorf("Validate : chains %s and %s have the same CHAIN_ID %d", name, chain.Name, chain.ChainId)
+		}
+		ids[chain.ChainId] = chain.Name
+	}
+	return nil
+}
+
+// GetChain returns the configured chain with the name.
+func (c *Config) GetChain(name string) (ChainOption, bool) {
+	for _, chain := range c.GetChains() {
+		if chain.Name == name {
+			return chain, true
+		}
+	}
+	return ChainOption{}, false
+}
+
+// BlockNumberQueue is the backfill lane of block jobs to index, the single
+// chain setup keeps the queue names used before multi-chain support.
+func (o ChainOption) BlockNumberQueue() string {
+	return o.queueName("blockNumber_queue")
+}
+
+// BlockNumberHeadQueue is the lane of block jobs following the chain head.
+func (o ChainOption) BlockNumberHeadQueue() string {
+	return o.queueName("blockNumber_head_queue")
+}
+
+// JobQueue returns the lane of the jobs of reason, head jobs do not wait
+// behind the backfill.
+func (o ChainOption) JobQueue(reason string) string {
+	if reason == c.JobReasonHead {
+		return o.BlockNumberHeadQueue()
+	}
+	return o.BlockNumberQueue()
+}
+
+// BlockNumberDoneQueue is the queue of indexed block numbers.
+func (o ChainOption) BlockNumberDoneQueue() string {
+	return o.queueName("blockNumber_done_queue")
+}
+
+func (o ChainOption) queueName(name string) string {
+	if o.Name == DefaultChainName {
+		return name
+	}
+	return o.Name + "." + name
 }
 
 type RedisOption struct {
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChains(t *testing.T) {
+	conf := &Config{RCPEndpoint: "http://node:8545", WorkerNumber: 4}
+	chains := conf.GetChains()
+	assert.Len(t, chains, 1)
+	assert.Equal(t, DefaultChainName, chains[0].Name)
+	assert.Equal(t, int64(0), chains[0].ChainId)
+	assert.Equal(t, []string{"http://node:8545"}, chains[0].RCPEndpoints)
+	// the single chain keeps the queue names used before multi-chain support
+	assert.Equal(t, "blockNumber_queue", chains[0].BlockNumberQueue())
+
+	// the single chain takes the top-level chain id and profile
+	conf.ChainId, conf.Profile = 97, "bsc"
+	chains = conf.GetChains()
+	assert.Equal(t, int64(97), chains[0].ChainId)
+	assert.Equal(t, "bsc", chains[0].Profile)
+
+	conf.Chains = []ChainOption{{Name: "mainnet", ChainId: 1}, {Name: "bsc", ChainId: 56}}
+	assert.Equal(t, conf.Chains, conf.GetChains())
+	chain, ok := conf.GetChain("bsc")
+	assert.True(t, ok)
+	assert.Equal(t, int64(56), chain.ChainId)
+	assert.Equal(t, "bsc.blockNumber_head_queue", chain.BlockNumberHeadQueue())
+	_, ok = conf.GetChain(DefaultChainName)
+	assert.False(t, ok)
+}
+
+func TestValidate(t *testing.T) {
+	conf := &Config{}
+	assert.NoError(t, conf.Validate())
+
+	conf.Chains = []ChainOption{{Name: "mainnet", ChainId: 1}, {Name: "bsc", ChainId: 56}}
+	assert.NoError(t, conf.Validate())
+
+	// chain id 0 would write into the rows of the single chain setup
+	conf.Chains = []ChainOption{{Name: "mainnet", ChainId: 1}, {Name: "bsc"}}
+	assert.Error(t, conf.Validate())
+
+	conf.Chains = []ChainOption{{Name: "mainnet", ChainId: 1}, {Name: "mainnet-archive", ChainId: 1}}
+	assert.Error(t, conf.Validate())
+
+	// the chains of one name would share their queues
+	conf.Chains = []ChainOption{{Name: "mainnet", ChainId: 1}, {Name: "mainnet", ChainId: 5}}
+	assert.Error(t, conf.Validate())
+	conf.Chains = []ChainOption{{ChainId: 1}}
+	assert.Error(t, conf.Validate())
+	conf.Chains = []ChainOption{{Name: "blocks", ChainId: 1}}
+	assert.Error(t, conf.Validate())
+}
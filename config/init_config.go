@@ -27,6 +27,7 @@ func LoadConf(paths string, globalConfig interface{}, opts ...Option) {
 	v := viper.GetViper()
 	loadLocal(v, paths, globalConfig)
 	loadEnv(v, globalConfig)
+	validate(globalConfig)
 }
 
 // loadLocal load local file
@@ -75,3 +76,19 @@ func loadEnv(vp *viper.Viper, globalConfig interface{}) {
 		logger.GetLoggerWithKeys(defaultLoggingKeys).Panic(fmt.Sprintf("fatal error apollo unmarshall err: %s ", err))
 	}
 }
+
+// validate panics when the loaded config implements Validate and is invalid.
+func validate(globalConfig interface{}) {
+	validator, ok := globalConfig.(interface{ Validate() error })
+	if !ok {
+		return
+	}
+	if err := validator.Validate(); err != nil {
+		defaultLoggingKeys := map[string]interface{}{
+			"log_key": "local_config",
+			"service": viper.GetString("SERVICE.NAME"),
+			"env":     viper.GetString("ENV"),
+		}
+		logger.GetLoggerWithKeys(defaultLoggingKeys).Panic(fmt.Sprintf("invalid config err: %s ", err))
+	}
+}
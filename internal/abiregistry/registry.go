@@ -0,0 +1,272 @@
+package abiregistry
+
+import (
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"gorm.io/gorm"
+)
+
+const (
+	// abiCacheTTL bounds how long an ABI registered or replaced on another
+	// replica stays unseen
+	abiCacheTTL = time.Minute
+	// maxCachedAbis bounds the cache, the expired entries are dropped once
+	// it is full
+	maxCachedAbis = 10000
+)
+
+var (
+	ErrInvalidAddress = errors.New("invalid contract address")
+	ErrInvalidAbi     = errors.New("invalid abi")
+)
+
+// Registry resolves contract ABIs uploaded per address and falls back to the
+// builtin ABIs when decoding transaction input and logs.
+type Registry struct {
+	mu          sync.RWMutex
+	dataHandler data.DataHandler
+	contracts   map[string]cachedAbi
+	builtin     []*abi.ABI
+	now         func() time.Time
+}
+
+// cachedAbi is the ABI of an address until expires, abi is nil for an
+// address without ABI.
+type cachedAbi struct {
+	abi     *abi.ABI
+	expires time.Time
+}
+
+func NewRegistry(dataHandler data.DataHandler) *Registry {
+	names := make([]string, 0, len(builtinAbis))
+	for name := range builtinAbis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	builtin := make([]*abi.ABI, 0, len(names))
+	for _, name := range names {
+		parsed, err := abi.JSON(strings.NewReader(builtinAbis[name]))
+		if err != nil {
+			panic(fmt.Sprintf("NewRegistry: builtin abi %s: %s", name, err))
+		}
+		builtin = append(builtin, &parsed)
+	}
+
+	return &Registry{
+		dataHandler: dataHandler,
+		contracts:   make(map[string]cachedAbi),
+		builtin:     builtin,
+		now:         time.Now,
+	}
+}
+
+// Register validates and stores the ABI of a contract.
+func (r *Registry) Register(ctx context.Context, address, name, abiJSON string) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("Register: %w", ErrInvalidAddress)
+	}
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return fmt.Errorf("Register: %w: %s", ErrInvalidAbi, err)
+	}
+
+	address = common.HexToAddress(address).Hex()
+	err = r.dataHandler.SaveAbiRow(ctx, &model.AbiRow{
+		Address: address,
+		Name:    name,
+		Abi:     abiJSON,
+	})
+	if err != nil {
+		return fmt.Errorf("Register: %w", err)
+	}
+
+	r.store(address, &parsed)
+	return nil
+}
+
+// Get returns the stored ABI row of a contract.
+func (r *Registry) Get(ctx context.Context, address string) (model.AbiRow, error) {
+	if !common.IsHexAddress(address) {
+		return model.AbiRow{}, fmt.Errorf("Get: %w", ErrInvalidAddress)
+	}
+	abiRow := model.AbiRow{Address: common.HexToAddress(address).Hex()}
+	if err := r.dataHandler.GetAbiRow(ctx, &abiRow); err != nil {
+		return model.AbiRow{}, fmt.Errorf("Get: %w", err)
+	}
+	return abiRow, nil
+}
+
+// DecodeInput decodes transaction calldata, it returns nil if no known ABI matches.
+func (r *Registry) DecodeInput(ctx context.Context, to string, input []byte) *model.DecodedCall {
+	if len(input) < 4 {
+		return nil
+	}
+	for _, contractAbi := range r.candidates(ctx, to) {
+		method, err := contractAbi.MethodById(input[:4])
+		if err != nil {
+			continue
+		}
+		args := make(map[string]interface{})
+		if err := method.Inputs.UnpackIntoMap(args, input[4:]); err != nil {
+			continue
+		}
+		return &model.DecodedCall{
+			Method:    method.RawName,
+			Signature: method.Sig,
+			Args:      formatArgs(args),
+		}
+	}
+	return nil
+}
+
+// DecodeLog decodes an event log, it returns nil if no known ABI matches.
+func (r *Registry) DecodeLog(ctx context.Context, address string, topics []common.Hash, logData []byte) *model.DecodedEvent {
+	if len(topics) == 0 {
+		return nil
+	}
+	for _, contractAbi := range r.candidates(ctx, address) {
+		event, err := contractAbi.EventByID(topics[0])
+		if err != nil {
+			continue
+		}
+
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if len(indexed) != len(topics)-1 {
+			continue
+		}
+
+		args := make(map[string]interface{})
+		if err := event.Inputs.NonIndexed().UnpackIntoMap(args, logData); err != nil {
+			continue
+		}
+		if err := abi.ParseTopicsIntoMap(args, indexed, topics[1:]); err != nil {
+			continue
+		}
+		return &model.DecodedEvent{
+			Event:     event.RawName,
+			Signature: event.Sig,
+			Args:      formatArgs(args),
+		}
+	}
+	return nil
+}
+
+// candidates returns the uploaded ABI of the address first, then the builtin ABIs.
+func (r *Registry) candidates(ctx context.Context, address string) []*abi.ABI {
+	result := make([]*abi.ABI, 0, len(r.builtin)+1)
+	if contractAbi := r.lookup(ctx, address); contractAbi != nil {
+		result = append(result, contractAbi)
+	}
+	return append(result, r.builtin...)
+}
+
+// lookup returns the uploaded ABI of the address. ABIs and addresses without
+// ABI are cached for abiCacheTTL, database errors are not cached.
+func (r *Registry) lookup(ctx context.Context, address string) *abi.ABI {
+	if !common.IsHexAddress(address) {
+		return nil
+	}
+	address = common.HexToAddress(address).Hex()
+
+	r.mu.RLock()
+	cached, ok := r.contracts[address]
+	r.mu.RUnlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.abi
+	}
+
+	abiRow := model.AbiRow{Address: address}
+	err := r.dataHandler.GetAbiRow(ctx, &abiRow)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	var contractAbi *abi.ABI
+	if err == nil && abiRow.Abi != "" {
+		parsed, err := abi.JSON(strings.NewReader(abiRow.Abi))
+		if err == nil {
+			contractAbi = &parsed
+		}
+	}
+	r.store(address, contractAbi)
+	return contractAbi
+}
+
+func (r *Registry) store(address string, contractAbi *abi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if len(r.contracts) >= maxCachedAbis {
+		for cachedAddress, cached := range r.contracts {
+			if !now.Before(cached.expires) {
+				delete(r.contracts, cachedAddress)
+			}
+		}
+		if len(r.contracts) >= maxCachedAbis {
+			r.contracts = make(map[string]cachedAbi)
+		}
+	}
+	r.contracts[address] = cachedAbi{abi: contractAbi, expires: now.Add(abiCacheTTL)}
+}
+
+func formatArgs(args map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		result[name] = formatArg(value)
+	}
+	return result
+}
+
+// formatArg converts decoded values into JSON friendly values, numbers are
+// returned as decimal strings and bytes as 0x prefixed hex.
+func formatArg(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			bs := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(bs), rv)
+			return hexutil.Encode(bs)
+		}
+		fallthrough
+	case reflect.Slice:
+		result := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			result[i] = formatArg(rv.Index(i).Interface())
+		}
+		return result
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", value)
+	}
+	return value
+}
@@ -0,0 +1,102 @@
+package abiregistry
+
+import (
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const counterAbi = `[{"type":"function","name":"increment","inputs":[{"name":"by","type":"uint256"}],"outputs":[]}]`
+
+// abiStore keeps the ABI rows in memory and counts the reads, err fails them.
+type abiStore struct {
+	data.DataHandler
+	rows  map[string]model.AbiRow
+	reads int
+	err   error
+}
+
+func (s *abiStore) SaveAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.rows[abiRow.Address] = *abiRow
+	return nil
+}
+
+func (s *abiStore) GetAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	s.reads++
+	if s.err != nil {
+		return s.err
+	}
+	row, ok := s.rows[abiRow.Address]
+	if !ok {
+		return fmt.Errorf("GetAbiRow : %w", gorm.ErrRecordNotFound)
+	}
+	*abiRow = row
+	return nil
+}
+
+func TestRegistryLookup(t *testing.T) {
+	ctx := context.Background()
+	store := &abiStore{rows: make(map[string]model.AbiRow)}
+	registry := NewRegistry(store)
+	now := time.Now()
+	registry.now = func() time.Time { return now }
+
+	contract := common.HexToAddress("0x1111111111111111111111111111111111111111").Hex()
+	input := append(common.FromHex("0x7cf5dab0"), common.BigToHash(big.NewInt(5)).Bytes()...)
+
+	// an address without ABI is cached
+	assert.Nil(t, registry.DecodeInput(ctx, contract, input))
+	assert.Nil(t, registry.DecodeInput(ctx, contract, input))
+	assert.Equal(t, 1, store.reads)
+
+	// an ABI registered on another replica is seen once the miss expired
+	store.rows[contract] = model.AbiRow{Address: contract, Abi: counterAbi}
+	now = now.Add(abiCacheTTL)
+	decoded := registry.DecodeInput(ctx, contract, input)
+	if assert.NotNil(t, decoded) {
+		assert.Equal(t, "increment", decoded.Method)
+		assert.Equal(t, "5", decoded.Args["by"])
+	}
+	assert.Equal(t, 2, store.reads)
+
+	// database errors are not cached
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222").Hex()
+	store.err = errors.New("connection refused")
+	assert.Nil(t, registry.DecodeInput(ctx, other, input))
+	store.err = nil
+	store.rows[other] = model.AbiRow{Address: other, Abi: counterAbi}
+	assert.NotNil(t, registry.DecodeInput(ctx, other, input))
+}
+
+func TestRegistryRegister(t *testing.T) {
+	ctx := context.Background()
+	store := &abiStore{rows: make(map[string]model.AbiRow)}
+	registry := NewRegistry(store)
+	contract := "0x1111111111111111111111111111111111111111"
+
+	assert.ErrorIs(t, registry.Register(ctx, "0x01", "counter", counterAbi), ErrInvalidAddress)
+	assert.ErrorIs(t, registry.Register(ctx, contract, "counter", "{"), ErrInvalidAbi)
+
+	store.err = errors.New("connection refused")
+	err := registry.Register(ctx, contract, "counter", counterAbi)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidAbi)
+
+	store.err = nil
+	assert.NoError(t, registry.Register(ctx, contract, "counter", counterAbi))
+	abiRow, err := registry.Get(ctx, contract)
+	assert.NoError(t, err)
+	assert.Equal(t, "counter", abiRow.Name)
+}
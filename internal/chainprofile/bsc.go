@@ -0,0 +1,47 @@
+package chainprofile
+
+import (
+	"Ethereum_Service/pkg/utils/logger"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// bscProfile recovers the validator of Parlia blocks from the seal in the extra data.
+type bscProfile struct {
+	ethereumProfile
+	chainId int64
+}
+
+func (bscProfile) Name() string {
+	return BSC
+}
+
+// BlockMiner returns the validator which sealed the block. Parlia validators
+// set themselves as coinbase, a different signer means the seal hash missed
+// fields of a later fork (such as the requests hash since Pascal) which the
+// header cannot carry, the coinbase is used then.
+func (p bscProfile) BlockMiner(header *types.Header) (common.Address, error) {
+	signer := sealerOrCoinbase(BSC, header, func() []interface{} {
+		// Parlia prefixes the signed fields with the chain id
+		fields := append([]interface{}{big.NewInt(p.chainId)}, sealFields(header)...)
+		// since Cancun the signature covers the new header fields as well, an empty
+		// parent beacon root marks such headers
+		if header.ParentBeaconRoot != nil && *header.ParentBeaconRoot == (common.Hash{}) {
+			fields = append(fields,
+				header.BaseFee,
+				header.WithdrawalsHash,
+				header.BlobGasUsed,
+				header.ExcessBlobGas,
+				header.ParentBeaconRoot,
+			)
+		}
+		return fields
+	})
+	if signer != header.Coinbase {
+		logger.GetLogger().Sugar().Warnf("%s block %v : sealer %s is not the coinbase, using the coinbase as miner", BSC, header.Number, signer.Hex())
+		return header.Coinbase, nil
+	}
+	return signer, nil
+}
@@ -0,0 +1,119 @@
+package chainprofile
+
+import (
+	"Ethereum_Service/pkg/model"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DepositTxType is the type of OP-stack deposit transactions.
+const DepositTxType = 0x7e
+
+// DepositTx is an OP-stack deposit transaction, it is decoded from the RPC
+// response since go-ethereum doesn't know the type.
+type DepositTx struct {
+	Hash       common.Hash     `json:"hash"`
+	From       common.Address  `json:"from"`
+	To         *common.Address `json:"to"`
+	Nonce      hexutil.Uint64  `json:"nonce"`
+	Value      *hexutil.Big    `json:"value"`
+	Mint       *hexutil.Big    `json:"mint"`
+	Input      hexutil.Bytes   `json:"input"`
+	SourceHash common.Hash     `json:"sourceHash"`
+}
+
+// optimismProfile handles the deposit transactions and the L1 data fee of OP-stack chains.
+type optimismProfile struct {
+	ethereumProfile
+}
+
+func (optimismProfile) Name() string {
+	return Optimism
+}
+
+func (optimismProfile) HasDepositTxs() bool {
+	return true
+}
+
+type l1FeeFields struct {
+	L1Fee      *hexutil.Big `json:"l1Fee"`
+	L1GasPrice *hexutil.Big `json:"l1GasPrice"`
+	L1GasUsed  *hexutil.Big `json:"l1GasUsed"`
+}
+
+// FillReceiptFields copies the L1 data fee the sequencer charged for the
+// transaction, deposit transactions don't have one.
+func (optimismProfile) FillReceiptFields(txRow *model.TransactionRow, rawReceipt json.RawMessage) error {
+	var fields l1FeeFields
+	if err := json.Unmarshal(rawReceipt, &fields); err != nil {
+		return fmt.Errorf("FillReceiptFields : %w", err)
+	}
+	if fields.L1Fee != nil {
+		txRow.L1Fee = fields.L1Fee.String()
+	}
+	if fields.L1GasPrice != nil {
+		txRow.L1GasPrice = fields.L1GasPrice.String()
+	}
+	if fields.L1GasUsed != nil {
+		txRow.L1GasUsed = fields.L1GasUsed.String()
+	}
+	return nil
+}
+
+type rpcBlockBody struct {
+	Transactions []json.RawMessage   `json:"transactions"`
+	Withdrawals  []*types.Withdrawal `json:"withdrawals"`
+}
+
+// DecodeBlock decodes an eth_getBlockBy* response with full transactions, the
+// deposit transactions are returned apart from the block.
+func DecodeBlock(raw json.RawMessage) (*types.Block, []DepositTx, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil, ethereum.NotFound
+	}
+
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, nil, fmt.Errorf("DecodeBlock : %w", err)
+	}
+	var body rpcBlockBody
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, nil, fmt.Errorf("DecodeBlock : %w", err)
+	}
+
+	txs := make([]*types.Transaction, 0, len(body.Transactions))
+	var deposits []DepositTx
+	for _, rawTx := range body.Transactions {
+		var typed struct {
+			Type hexutil.Uint64 `json:"type"`
+		}
+		if err := json.Unmarshal(rawTx, &typed); err != nil {
+			return nil, nil, fmt.Errorf("DecodeBlock : %w", err)
+		}
+		if typed.Type == DepositTxType {
+			var deposit DepositTx
+			if err := json.Unmarshal(rawTx, &deposit); err != nil {
+				return nil, nil, fmt.Errorf("DecodeBlock : %w", err)
+			}
+			deposits = append(deposits, deposit)
+			continue
+		}
+
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalJSON(rawTx); err != nil {
+			return nil, nil, fmt.Errorf("DecodeBlock : %w", err)
+		}
+		txs = append(txs, tx)
+	}
+
+	block := types.NewBlockWithHeader(&header).WithBody(txs, nil)
+	if body.Withdrawals != nil {
+		block = block.WithWithdrawals(body.Withdrawals)
+	}
+	return block, deposits, nil
+}
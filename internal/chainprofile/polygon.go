@@ -0,0 +1,36 @@
+package chainprofile
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// polygonProfile recovers the block producer of Bor blocks, whose coinbase is empty,
+// and accepts the unsigned state sync transactions.
+type polygonProfile struct {
+	ethereumProfile
+}
+
+func (polygonProfile) Name() string {
+	return Polygon
+}
+
+func (polygonProfile) BlockMiner(header *types.Header) (common.Address, error) {
+	return sealerOrCoinbase(Polygon, header, func() []interface{} {
+		fields := sealFields(header)
+		if header.BaseFee != nil {
+			fields = append(fields, header.BaseFee)
+		}
+		return fields
+	}), nil
+}
+
+// TxSender returns the zero address for state sync transactions, which are
+// included by the validators without a signature.
+func (p polygonProfile) TxSender(tx *types.Transaction) (common.Address, error) {
+	v, r, s := tx.RawSignatureValues()
+	if v.Sign() == 0 && r.Sign() == 0 && s.Sign() == 0 {
+		return common.Address{}, nil
+	}
+	return p.ethereumProfile.TxSender(tx)
+}
@@ -0,0 +1,77 @@
+package chainprofile
+
+import (
+	"Ethereum_Service/pkg/model"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	Ethereum = "ethereum"
+	BSC      = "bsc"
+	Polygon  = "polygon"
+	Optimism = "optimism"
+)
+
+// Profile holds the chain specific parts of converting blocks and transactions
+// into rows, chains which only differ from Ethereum in a few places embed the
+// ethereum profile.
+type Profile interface {
+	Name() string
+	// BlockMiner returns the address which produced the block.
+	BlockMiner(header *types.Header) (common.Address, error)
+	// TxSender returns the sender of a transaction.
+	TxSender(tx *types.Transaction) (common.Address, error)
+	// FillReceiptFields copies chain specific receipt fields into the transaction row.
+	FillReceiptFields(txRow *model.TransactionRow, rawReceipt json.RawMessage) error
+	// HasDepositTxs reports whether blocks carry OP-stack deposit transactions,
+	// which go-ethereum can't decode.
+	HasDepositTxs() bool
+}
+
+// New returns the profile with the name, an empty name is the ethereum profile.
+func New(name string, chainId int64) (Profile, error) {
+	switch name {
+	case "", Ethereum:
+		return ethereumProfile{}, nil
+	case BSC:
+		if chainId == 0 {
+			return nil, fmt.Errorf("New : the %s profile needs the chain id", name)
+		}
+		return bscProfile{chainId: chainId}, nil
+	case Polygon:
+		return polygonProfile{}, nil
+	case Optimism:
+		return optimismProfile{}, nil
+	}
+	return nil, fmt.Errorf("New : unknown chain profile %s", name)
+}
+
+type ethereumProfile struct{}
+
+func (ethereumProfile) Name() string {
+	return Ethereum
+}
+
+func (ethereumProfile) BlockMiner(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+func (ethereumProfile) TxSender(tx *types.Transaction) (common.Address, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("TxSender : %w", err)
+	}
+	return from, nil
+}
+
+func (ethereumProfile) FillReceiptFields(txRow *model.TransactionRow, rawReceipt json.RawMessage) error {
+	return nil
+}
+
+func (ethereumProfile) HasDepositTxs() bool {
+	return false
+}
@@ -0,0 +1,156 @@
+package chainprofile
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+// seal signs the header the way the consensus engine does and appends the
+// signature to the extra data.
+func seal(t *testing.T, header *types.Header, fields func(*types.Header) []interface{}) common.Address {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	return sealWith(t, key, header, fields)
+}
+
+func sealWith(t *testing.T, key *ecdsa.PrivateKey, header *types.Header, fields func(*types.Header) []interface{}) common.Address {
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+	hash := crypto.Keccak256(mustEncode(t, fields(header)))
+	signature, err := crypto.Sign(hash, key)
+	assert.NoError(t, err)
+	copy(header.Extra[len(header.Extra)-extraSeal:], signature)
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func mustEncode(t *testing.T, v interface{}) []byte {
+	bs, err := rlp.EncodeToBytes(v)
+	assert.NoError(t, err)
+	return bs
+}
+
+func TestBSCBlockMiner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+	bscFields := func(h *types.Header) []interface{} {
+		return append([]interface{}{big.NewInt(97)}, sealFields(h)...)
+	}
+	header := &types.Header{
+		Number:     big.NewInt(100),
+		Difficulty: big.NewInt(2),
+		Coinbase:   validator,
+		Extra:      make([]byte, 32),
+	}
+	sealWith(t, key, header, bscFields)
+
+	profile, err := New(BSC, 97)
+	assert.NoError(t, err)
+	miner, err := profile.BlockMiner(header)
+	assert.NoError(t, err)
+	assert.Equal(t, validator, miner)
+
+	// a signer other than the coinbase comes from a seal hash missing fields,
+	// the coinbase is kept
+	other := &types.Header{
+		Number:     big.NewInt(101),
+		Difficulty: big.NewInt(2),
+		Coinbase:   common.HexToAddress("0x0000000000000000000000000000000000001000"),
+		Extra:      make([]byte, 32),
+	}
+	sealWith(t, key, other, bscFields)
+	miner, err = profile.BlockMiner(other)
+	assert.NoError(t, err)
+	assert.Equal(t, other.Coinbase, miner)
+
+	// the chain id is part of the signed data
+	profile, err = New(BSC, 56)
+	assert.NoError(t, err)
+	signer, err := recoverSealer(header, append([]interface{}{big.NewInt(56)}, sealFields(header)...))
+	assert.NoError(t, err)
+	assert.NotEqual(t, validator, signer)
+	miner, err = profile.BlockMiner(header)
+	assert.NoError(t, err)
+	assert.Equal(t, header.Coinbase, miner)
+
+	// an invalid signature falls back to the coinbase
+	copy(header.Extra[len(header.Extra)-extraSeal:], make([]byte, extraSeal))
+	miner, err = profile.BlockMiner(header)
+	assert.NoError(t, err)
+	assert.Equal(t, header.Coinbase, miner)
+
+	_, err = New(BSC, 0)
+	assert.Error(t, err)
+}
+
+func TestPolygonBlockMiner(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(100),
+		Difficulty: big.NewInt(2),
+		Extra:      make([]byte, 32),
+		BaseFee:    big.NewInt(30),
+	}
+	signer := seal(t, header, func(h *types.Header) []interface{} {
+		return append(sealFields(h), h.BaseFee)
+	})
+
+	profile, err := New(Polygon, 137)
+	assert.NoError(t, err)
+	miner, err := profile.BlockMiner(header)
+	assert.NoError(t, err)
+	assert.Equal(t, signer, miner)
+
+	// blocks without a seal keep their coinbase
+	coinbase := common.HexToAddress("0x0000000000000000000000000000000000001000")
+	miner, err = profile.BlockMiner(&types.Header{Number: big.NewInt(101), Coinbase: coinbase, Extra: make([]byte, 32)})
+	assert.NoError(t, err)
+	assert.Equal(t, coinbase, miner)
+}
+
+func TestDecodeBlock(t *testing.T) {
+	raw := []byte(`{
+		"parentHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"miner": "0x4200000000000000000000000000000000000011",
+		"stateRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"transactionsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"receiptsRoot": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"logsBloom": "0x` + common.Bytes2Hex(make([]byte, types.BloomByteLength)) + `",
+		"difficulty": "0x0",
+		"number": "0x10",
+		"gasLimit": "0x1c9c380",
+		"gasUsed": "0x0",
+		"timestamp": "0x6553f100",
+		"extraData": "0x",
+		"mixHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"nonce": "0x0000000000000000",
+		"transactions": [{
+			"type": "0x7e",
+			"hash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+			"from": "0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001",
+			"to": "0x4200000000000000000000000000000000000015",
+			"nonce": "0x5",
+			"value": "0x0",
+			"mint": "0x0",
+			"input": "0x015d8eb9",
+			"sourceHash": "0x0000000000000000000000000000000000000000000000000000000000000002"
+		}]
+	}`)
+
+	block, deposits, err := DecodeBlock(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(16), block.NumberU64())
+	assert.Empty(t, block.Transactions())
+	assert.Len(t, deposits, 1)
+	assert.Equal(t, common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001"), deposits[0].From)
+	assert.Equal(t, uint64(5), uint64(deposits[0].Nonce))
+
+	_, _, err = DecodeBlock([]byte("null"))
+	assert.Error(t, err)
+}
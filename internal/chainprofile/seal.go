@@ -0,0 +1,78 @@
+package chainprofile
+
+import (
+	"Ethereum_Service/pkg/utils/logger"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// extraSeal is the length of the signer signature at the end of the extra data of PoA headers.
+const extraSeal = crypto.SignatureLength
+
+var (
+	ErrMissingSignature = errors.New("extra data too short to contain a signature")
+)
+
+// sealerOrCoinbase recovers the signer of a PoA header, the coinbase is used
+// when the header carries no valid seal so that the block is still indexed.
+// fields returns the header fields the signature covers.
+func sealerOrCoinbase(profile string, header *types.Header, fields func() []interface{}) common.Address {
+	if len(header.Extra) < extraSeal {
+		logger.GetLogger().Sugar().Warnf("%s block %v : %s, using the coinbase as miner", profile, header.Number, ErrMissingSignature)
+		return header.Coinbase
+	}
+	signer, err := recoverSealer(header, fields())
+	if err != nil {
+		logger.GetLogger().Sugar().Warnf("%s block %v : %s, using the coinbase as miner", profile, header.Number, err.Error())
+		return header.Coinbase
+	}
+	return signer
+}
+
+// recoverSealer recovers the signer of a PoA header from the signature at the
+// end of its extra data, fields are the header fields the signature covers.
+func recoverSealer(header *types.Header, fields []interface{}) (common.Address, error) {
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	sealHash := crypto.NewKeccakState()
+	if err := rlp.Encode(sealHash, fields); err != nil {
+		return common.Address{}, fmt.Errorf("recoverSealer : %w", err)
+	}
+	var hash common.Hash
+	sealHash.Read(hash[:])
+
+	pubkey, err := crypto.Ecrecover(hash.Bytes(), signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recoverSealer : %w", err)
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
+
+// sealFields are the header fields signed by Clique style consensus engines,
+// with the signature stripped from the extra data.
+func sealFields(header *types.Header) []interface{} {
+	return []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-extraSeal],
+		header.MixDigest,
+		header.Nonce,
+	}
+}
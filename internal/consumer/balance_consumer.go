@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"time"
+)
+
+type balanceConsumer struct {
+	balanceChan     chan Traced[model.BalanceRow]
+	storeBufferSize int
+	storeInterval   time.Duration
+	mysqlHandler    data.DataHandler
+	metrics         flushMetrics
+	done            chan struct{}
+}
+
+func (c *balanceConsumer) Run() {
+	defer close(c.done)
+	balanceBuf := make([]*model.BalanceRow, 0)
+	t := time.NewTicker(c.storeInterval)
+	for {
+		select {
+		case balance, ok := <-c.balanceChan:
+			if !ok {
+				// save
+				err := c.metrics.flush(len(balanceBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveBalanceRows(ctx, balanceBuf)
+				})
+				if err != nil {
+					logger.Errorf("BalanceConsumer Error : %v ", err)
+				}
+				balanceBuf = make([]*model.BalanceRow, 0)
+				return
+			}
+			balanceBuf = append(balanceBuf, &balance.Row)
+			c.metrics.link(balance.Span)
+			c.metrics.buffer.Set(float64(len(balanceBuf)))
+			if len(balanceBuf) == c.storeBufferSize {
+				// save
+				err := c.metrics.flush(len(balanceBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveBalanceRows(ctx, balanceBuf)
+				})
+				if err != nil {
+					logger.Errorf("BalanceConsumer Error : %v ", err)
+				}
+				balanceBuf = make([]*model.BalanceRow, 0)
+			}
+		case <-t.C:
+			if len(balanceBuf) == 0 {
+				continue
+			}
+			// save
+			err := c.metrics.flush(len(balanceBuf), func(ctx context.Context) error {
+				return c.mysqlHandler.SaveBalanceRows(ctx, balanceBuf)
+			})
+			if err != nil {
+				logger.Errorf("BalanceConsumer Error : %v ", err)
+			}
+			balanceBuf = make([]*model.BalanceRow, 0)
+		}
+	}
+}
+
+func (c *balanceConsumer) GetChan() interface{} {
+	return c.balanceChan
+}
+
+// Shutdown closes the input channel and waits for the buffered rows to be saved.
+func (c *balanceConsumer) Shutdown() {
+	close(c.balanceChan)
+	<-c.done
+}
@@ -9,13 +9,16 @@ import (
 )
 
 type blockConsumer struct {
-	blockChan       chan model.BlockRow
+	blockChan       chan Traced[model.BlockRow]
 	storeBufferSize int
 	storeInterval   time.Duration
 	mysqlHandler    data.DataHandler
+	metrics         flushMetrics
+	done            chan struct{}
 }
 
 func (c *blockConsumer) Run() {
+	defer close(c.done)
 	blockBuf := make([]*model.BlockRow, 0)
 	t := time.NewTicker(c.storeInterval)
 	for {
@@ -23,19 +26,25 @@ func (c *blockConsumer) Run() {
 		case block, ok := <-c.blockChan:
 			if !ok {
 				// save
-				err := c.mysqlHandler.SaveBlockRows(context.Background(), blockBuf)
+				err := c.metrics.flush(len(blockBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveBlockRows(ctx, blockBuf)
+				})
 				if err != nil {
-					logger.Errorf("BlockConsumer Error : %w ", err)
+					logger.Errorf("BlockConsumer Error : %v ", err)
 				}
 				blockBuf = make([]*model.BlockRow, 0)
 				return
 			}
-			blockBuf = append(blockBuf, &block)
+			blockBuf = append(blockBuf, &block.Row)
+			c.metrics.link(block.Span)
+			c.metrics.buffer.Set(float64(len(blockBuf)))
 			if len(blockBuf) == c.storeBufferSize {
 				// save
-				err := c.mysqlHandler.SaveBlockRows(context.Background(), blockBuf)
+				err := c.metrics.flush(len(blockBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveBlockRows(ctx, blockBuf)
+				})
 				if err != nil {
-					logger.Errorf("BlockConsumer Error : %w ", err)
+					logger.Errorf("BlockConsumer Error : %v ", err)
 				}
 				blockBuf = make([]*model.BlockRow, 0)
 			}
@@ -44,9 +53,11 @@ func (c *blockConsumer) Run() {
 				continue
 			}
 			// save
-			err := c.mysqlHandler.SaveBlockRows(context.Background(), blockBuf)
+			err := c.metrics.flush(len(blockBuf), func(ctx context.Context) error {
+				return c.mysqlHandler.SaveBlockRows(ctx, blockBuf)
+			})
 			if err != nil {
-				logger.Errorf("BlockConsumer Error : %w ", err)
+				logger.Errorf("BlockConsumer Error : %v ", err)
 			}
 			blockBuf = make([]*model.BlockRow, 0)
 		}
@@ -57,6 +68,8 @@ func (c *blockConsumer) GetChan() interface{} {
 	return c.blockChan
 }
 
+// Shutdown closes the input channel and waits for the buffered rows to be saved.
 func (c *blockConsumer) Shutdown() {
 	close(c.blockChan)
+	<-c.done
 }
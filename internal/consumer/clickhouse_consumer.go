@@ -0,0 +1,120 @@
+package consumer
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"time"
+)
+
+const (
+	// ClickHouse prefers few large inserts, each insert creates a part
+	defaultClickHouseBatchSize     = 100000
+	defaultClickHouseFlushInterval = 5 * time.Second
+
+	// a failed flush is retried after clickHouseRetryBase, growing up to
+	// clickHouseRetryMax between attempts
+	clickHouseRetryBase = time.Second
+	clickHouseRetryMax  = time.Minute
+)
+
+// clickHouseSink writes blocks to ClickHouse, implemented by data.ClickHouseSink.
+type clickHouseSink interface {
+	SaveBlocks(ctx context.Context, blocks []model.BlockWrite) error
+	Close() error
+}
+
+// clickHouseConsumer buffers whole blocks as every row needs the time of its
+// block, the buffer is flushed once it holds storeBufferSize rows. A failed
+// flush keeps the buffer and is retried with backoff, the scan handlers block
+// on the channel meanwhile.
+type clickHouseConsumer struct {
+	blockChan       chan Traced[model.BlockWrite]
+	storeBufferSize int
+	storeInterval   time.Duration
+	retryBase       time.Duration
+	sink            clickHouseSink
+	metrics         flushMetrics
+	done            chan struct{}
+}
+
+func (c *clickHouseConsumer) Run() {
+	defer close(c.done)
+	blockBuf := make([]model.BlockWrite, 0)
+	rows := 0
+	save := func(closing bool) {
+		c.retry(closing, func() error {
+			return c.metrics.flush(rows, func(ctx context.Context) error {
+				return c.sink.SaveBlocks(ctx, blockBuf)
+			})
+		})
+		blockBuf = make([]model.BlockWrite, 0)
+		rows = 0
+	}
+	t := time.NewTicker(c.storeInterval)
+	for {
+		select {
+		case block, ok := <-c.blockChan:
+			if !ok {
+				if len(blockBuf) > 0 {
+					save(true)
+				}
+				return
+			}
+			blockBuf = append(blockBuf, block.Row)
+			c.metrics.link(block.Span)
+			rows += blockRows(block.Row.Block)
+			c.metrics.buffer.Set(float64(rows))
+			if rows >= c.storeBufferSize {
+				save(false)
+			}
+		case <-t.C:
+			if len(blockBuf) == 0 {
+				continue
+			}
+			save(false)
+		}
+	}
+}
+
+// retry runs save until it succeeds. On shutdown it gives up after
+// MAX_RETRY_TIME attempts and the buffered blocks are lost.
+func (c *clickHouseConsumer) retry(closing bool, save func() error) {
+	backoff := common.NewFibonacci(c.retryBase)
+	for attempt := 1; ; attempt++ {
+		err := save()
+		if err == nil {
+			return
+		}
+		if closing && attempt >= config.GetConfig().MaxRetryTime {
+			logger.Errorf("ClickHouseConsumer Error : %v, dropping the buffered blocks", err)
+			return
+		}
+		wait := backoff.Next()
+		if wait > clickHouseRetryMax {
+			wait = clickHouseRetryMax
+		}
+		logger.Errorf("ClickHouseConsumer Error : %v, retrying in %s", err, wait)
+		<-time.NewTimer(wait).C
+	}
+}
+
+func (c *clickHouseConsumer) GetChan() interface{} {
+	return c.blockChan
+}
+
+// Shutdown closes the input channel and waits for the buffered blocks to be saved.
+func (c *clickHouseConsumer) Shutdown() {
+	close(c.blockChan)
+	<-c.done
+	if err := c.sink.Close(); err != nil {
+		logger.Errorf("ClickHouseConsumer Error : %v ", err)
+	}
+}
+
+// blockRows is the number of rows of a block, token transfers aside.
+func blockRows(blockData *model.BlockData) int {
+	return 1 + len(blockData.Txs) + len(blockData.Logs) + len(blockData.Withdrawals)
+}
@@ -0,0 +1,57 @@
+package consumer
+
+import (
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakySink fails its first writes, failures of them.
+type flakySink struct {
+	failures int
+	attempts int
+	saved    []model.BlockWrite
+}
+
+func (s *flakySink) SaveBlocks(ctx context.Context, blocks []model.BlockWrite) error {
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("connection refused")
+	}
+	s.saved = append(s.saved, blocks...)
+	return nil
+}
+
+func (s *flakySink) Close() error {
+	return nil
+}
+
+func TestClickHouseConsumerRetry(t *testing.T) {
+	sink := &flakySink{failures: 2}
+	consumer := &clickHouseConsumer{
+		blockChan:       make(chan Traced[model.BlockWrite]),
+		storeBufferSize: 2,
+		storeInterval:   time.Hour,
+		retryBase:       time.Millisecond,
+		sink:            sink,
+		metrics:         newFlushMetrics("mainnet", "clickhouse"),
+		done:            make(chan struct{}),
+	}
+	go consumer.Run()
+
+	// a block of one row and a block of a transaction fill the buffer
+	consumer.blockChan <- Traced[model.BlockWrite]{Row: model.BlockWrite{Block: &model.BlockData{Block: model.BlockRow{Number: 1}}}}
+	consumer.blockChan <- Traced[model.BlockWrite]{Row: model.BlockWrite{Block: &model.BlockData{
+		Block: model.BlockRow{Number: 2},
+		Txs:   []model.TransactionRow{{Hash: "0x01"}},
+	}}}
+	consumer.Shutdown()
+
+	// the failed flushes keep the buffer
+	assert.Equal(t, 3, sink.attempts)
+	assert.Len(t, sink.saved, 2)
+}
@@ -16,36 +16,87 @@ type Consumer interface {
 
 type ConsumerConf struct {
 	Type            string
+	ChainId         int64
+	Chain           string
 	StoreBufferSize int
 	StoreInterval   time.Duration
 }
 
 func NewConsumer(conf *ConsumerConf) Consumer {
-	mysqlHandler, err := data.NewMysqlHandler(&config.GetConfig().Databases)
+	// the ClickHouse consumer does not write to the database
+	if conf.Type == c.ClickHouseConsumerType {
+		sink, err := data.NewClickHouseSink(&config.GetConfig().ClickHouse, conf.ChainId)
+		if err != nil {
+			panic(err)
+		}
+		storeBufferSize := conf.StoreBufferSize
+		if storeBufferSize <= 0 {
+			storeBufferSize = defaultClickHouseBatchSize
+		}
+		storeInterval := conf.StoreInterval
+		if storeInterval <= 0 {
+			storeInterval = defaultClickHouseFlushInterval
+		}
+		return &clickHouseConsumer{
+			blockChan:       make(chan Traced[model.BlockWrite]),
+			storeBufferSize: storeBufferSize,
+			storeInterval:   storeInterval,
+			retryBase:       clickHouseRetryBase,
+			sink:            sink,
+			metrics:         newFlushMetrics(conf.Chain, conf.Type),
+			done:            make(chan struct{}),
+		}
+	}
+
+	mysqlHandler, err := data.NewDatabaseHandler(&config.GetConfig().Databases, conf.ChainId)
 	if err != nil {
 		panic(err)
 	}
 	switch conf.Type {
 	case c.BlockConsumerType:
 		return &blockConsumer{
-			blockChan:       make(chan model.BlockRow),
+			blockChan:       make(chan Traced[model.BlockRow]),
 			storeBufferSize: conf.StoreBufferSize,
 			storeInterval:   conf.StoreInterval,
 			mysqlHandler:    mysqlHandler,
+			metrics:         newFlushMetrics(conf.Chain, conf.Type),
+			done:            make(chan struct{}),
 		}
 	case c.TxConsumerType:
 		return &txConsumer{
-			txChan:          make(chan model.TransactionRow),
+			txChan:          make(chan Traced[model.TransactionRow]),
 			storeBufferSize: conf.StoreBufferSize,
 			storeInterval:   conf.StoreInterval,
 			mysqlHandler:    mysqlHandler,
+			metrics:         newFlushMetrics(conf.Chain, conf.Type),
+			done:            make(chan struct{}),
 		}
 	case c.LogConsumerType:
 		return &logConsumer{
-			logChan:         make(chan model.LogRow),
+			logChan:         make(chan Traced[model.LogRow]),
+			storeBufferSize: conf.StoreBufferSize,
+			storeInterval:   conf.StoreInterval,
+			mysqlHandler:    mysqlHandler,
+			metrics:         newFlushMetrics(conf.Chain, conf.Type),
+			done:            make(chan struct{}),
+		}
+	case c.WithdrawalConsumerType:
+		return &withdrawalConsumer{
+			withdrawalChan:  make(chan Traced[model.WithdrawalRow]),
+			storeBufferSize: conf.StoreBufferSize,
+			storeInterval:   conf.StoreInterval,
+			mysqlHandler:    mysqlHandler,
+			metrics:         newFlushMetrics(conf.Chain, conf.Type),
+			done:            make(chan struct{}),
+		}
+	case c.BalanceConsumerType:
+		return &balanceConsumer{
+			balanceChan:     make(chan Traced[model.BalanceRow]),
 			storeBufferSize: conf.StoreBufferSize,
 			storeInterval:   conf.StoreInterval,
 			mysqlHandler:    mysqlHandler,
+			metrics:         newFlushMetrics(conf.Chain, conf.Type),
+			done:            make(chan struct{}),
 		}
 	}
 	return nil
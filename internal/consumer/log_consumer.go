@@ -9,32 +9,41 @@ import (
 )
 
 type logConsumer struct {
-	logChan         chan model.LogRow
+	logChan         chan Traced[model.LogRow]
 	storeBufferSize int
 	storeInterval   time.Duration
 	mysqlHandler    data.DataHandler
+	metrics         flushMetrics
+	done            chan struct{}
 }
 
 func (c *logConsumer) Run() {
+	defer close(c.done)
 	logBuf := make([]*model.LogRow, 0)
 	t := time.NewTicker(c.storeInterval)
 	for {
 		select {
 		case log, ok := <-c.logChan:
 			if !ok {
-				err := c.mysqlHandler.SaveLogRow(context.Background(), logBuf)
+				err := c.metrics.flush(len(logBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveLogRow(ctx, logBuf)
+				})
 				if err != nil {
-					logger.Errorf("LogConsumer Error : %w ", err)
+					logger.Errorf("LogConsumer Error : %v ", err)
 				}
 				logBuf = make([]*model.LogRow, 0)
 				return
 			}
-			logBuf = append(logBuf, &log)
+			logBuf = append(logBuf, &log.Row)
+			c.metrics.link(log.Span)
+			c.metrics.buffer.Set(float64(len(logBuf)))
 			if len(logBuf) == c.storeBufferSize {
 				// save
-				err := c.mysqlHandler.SaveLogRow(context.Background(), logBuf)
+				err := c.metrics.flush(len(logBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveLogRow(ctx, logBuf)
+				})
 				if err != nil {
-					logger.Errorf("LogConsumer Error : %w ", err)
+					logger.Errorf("LogConsumer Error : %v ", err)
 				}
 				logBuf = make([]*model.LogRow, 0)
 			}
@@ -43,9 +52,11 @@ func (c *logConsumer) Run() {
 				continue
 			}
 			// save
-			err := c.mysqlHandler.SaveLogRow(context.Background(), logBuf)
+			err := c.metrics.flush(len(logBuf), func(ctx context.Context) error {
+				return c.mysqlHandler.SaveLogRow(ctx, logBuf)
+			})
 			if err != nil {
-				logger.Errorf("LogConsumer Error : %w ", err)
+				logger.Errorf("LogConsumer Error : %v ", err)
 			}
 			logBuf = make([]*model.LogRow, 0)
 		}
@@ -56,6 +67,8 @@ func (c *logConsumer) GetChan() interface{} {
 	return c.logChan
 }
 
+// Shutdown closes the input channel and waits for the buffered rows to be saved.
 func (c *logConsumer) Shutdown() {
 	close(c.logChan)
+	<-c.done
 }
@@ -0,0 +1,96 @@
+package consumer
+
+import (
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/tracing"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Traced is a row sent to a consumer with the span context of the scan which
+// produced it, the span of the flush saving the row links to it.
+type Traced[T any] struct {
+	Row  T
+	Span trace.SpanContext
+}
+
+// flushMetrics reports the buffer fill and the flushes of one consumer.
+type flushMetrics struct {
+	chain        string
+	consumerType string
+	// links are the distinct span contexts of the buffered rows
+	links *spanLinks
+
+	buffer   prometheus.Gauge
+	duration prometheus.Observer
+	errors   prometheus.Counter
+}
+
+func newFlushMetrics(chain, consumerType string) flushMetrics {
+	return flushMetrics{
+		chain:        chain,
+		consumerType: consumerType,
+		links:        &spanLinks{seen: make(map[trace.SpanID]struct{})},
+
+		buffer:   monitor.ConsumerBuffer.WithLabelValues(chain, consumerType),
+		duration: monitor.ConsumerFlushDuration.WithLabelValues(chain, consumerType),
+		errors:   monitor.ConsumerFlushErrors.WithLabelValues(chain, consumerType),
+	}
+}
+
+// link adds the span context of a buffered row to the links of the next flush.
+func (m flushMetrics) link(spanContext trace.SpanContext) {
+	m.links.add(spanContext)
+}
+
+// flush runs save for the buffered rows in a span of its own, linked to the
+// spans which produced the rows, and records its latency. The buffer is empty
+// afterwards.
+func (m flushMetrics) flush(rows int, save func(ctx context.Context) error) error {
+	ctx, span := tracing.Start(context.Background(), m.consumerType+" flush",
+		trace.WithLinks(m.links.take()...),
+		trace.WithAttributes(
+			attribute.String("chain", m.chain),
+			attribute.String("consumer", m.consumerType),
+			attribute.Int("db.rows", rows),
+		))
+	start := time.Now()
+	err := save(ctx)
+	m.duration.Observe(time.Since(start).Seconds())
+	tracing.End(span, err)
+	if err != nil {
+		m.errors.Inc()
+	}
+	m.buffer.Set(0)
+	return err
+}
+
+// spanLinks collects the span contexts of the buffered rows once each, the
+// rows of a block share the span of its scan.
+type spanLinks struct {
+	links []trace.Link
+	seen  map[trace.SpanID]struct{}
+}
+
+func (l *spanLinks) add(spanContext trace.SpanContext) {
+	if !spanContext.IsValid() {
+		return
+	}
+	if _, ok := l.seen[spanContext.SpanID()]; ok {
+		return
+	}
+	l.seen[spanContext.SpanID()] = struct{}{}
+	l.links = append(l.links, trace.Link{SpanContext: spanContext})
+}
+
+// take returns the collected links and starts over.
+func (l *spanLinks) take() []trace.Link {
+	links := l.links
+	l.links = nil
+	l.seen = make(map[trace.SpanID]struct{})
+	return links
+}
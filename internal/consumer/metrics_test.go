@@ -0,0 +1,47 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestFlushLinksRowSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	scan := func(spanID byte) trace.SpanContext {
+		return trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{spanID},
+			SpanID:     trace.SpanID{spanID},
+			TraceFlags: trace.FlagsSampled,
+		})
+	}
+	metrics := newFlushMetrics("mainnet", "block")
+	// the rows of a block share the span of its scan, rows without one are not linked
+	metrics.link(scan(1))
+	metrics.link(scan(1))
+	metrics.link(scan(2))
+	metrics.link(trace.SpanContext{})
+
+	assert.NoError(t, metrics.flush(4, func(ctx context.Context) error { return nil }))
+	assert.NoError(t, metrics.flush(0, func(ctx context.Context) error { return nil }))
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	links := spans[0].Links()
+	assert.Len(t, links, 2)
+	assert.Equal(t, scan(1).SpanID(), links[0].SpanContext.SpanID())
+	assert.Equal(t, scan(2).SpanID(), links[1].SpanContext.SpanID())
+	// the flush does not join the trace of one of the rows
+	assert.NotEqual(t, scan(1).TraceID(), spans[0].SpanContext().TraceID())
+	assert.Empty(t, spans[1].Links())
+}
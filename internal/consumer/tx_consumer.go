@@ -9,13 +9,16 @@ import (
 )
 
 type txConsumer struct {
-	txChan          chan model.TransactionRow
+	txChan          chan Traced[model.TransactionRow]
 	storeBufferSize int
 	storeInterval   time.Duration
 	mysqlHandler    data.DataHandler
+	metrics         flushMetrics
+	done            chan struct{}
 }
 
 func (c *txConsumer) Run() {
+	defer close(c.done)
 	txBuf := make([]*model.TransactionRow, 0)
 	t := time.NewTicker(c.storeInterval)
 	for {
@@ -23,18 +26,24 @@ func (c *txConsumer) Run() {
 		case tx, ok := <-c.txChan:
 			if !ok {
 				// save
-				err := c.mysqlHandler.SaveTransactionRow(context.Background(), txBuf)
+				err := c.metrics.flush(len(txBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveTransactionRow(ctx, txBuf)
+				})
 				if err != nil {
-					logger.Errorf("TxConsumer Error : %w ", err)
+					logger.Errorf("TxConsumer Error : %v ", err)
 				}
 				txBuf = make([]*model.TransactionRow, 0)
 				return
 			}
-			txBuf = append(txBuf, &tx)
+			txBuf = append(txBuf, &tx.Row)
+			c.metrics.link(tx.Span)
+			c.metrics.buffer.Set(float64(len(txBuf)))
 			if len(txBuf) == c.storeBufferSize {
-				err := c.mysqlHandler.SaveTransactionRow(context.Background(), txBuf)
+				err := c.metrics.flush(len(txBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveTransactionRow(ctx, txBuf)
+				})
 				if err != nil {
-					logger.Errorf("TxConsumer Error : %w ", err)
+					logger.Errorf("TxConsumer Error : %v ", err)
 				}
 				txBuf = make([]*model.TransactionRow, 0)
 			}
@@ -42,9 +51,11 @@ func (c *txConsumer) Run() {
 			if len(txBuf) == 0 {
 				continue
 			}
-			err := c.mysqlHandler.SaveTransactionRow(context.Background(), txBuf)
+			err := c.metrics.flush(len(txBuf), func(ctx context.Context) error {
+				return c.mysqlHandler.SaveTransactionRow(ctx, txBuf)
+			})
 			if err != nil {
-				logger.Errorf("TxConsumer Error : %w ", err)
+				logger.Errorf("TxConsumer Error : %v ", err)
 			}
 			txBuf = make([]*model.TransactionRow, 0)
 		}
@@ -55,6 +66,8 @@ func (c *txConsumer) GetChan() interface{} {
 	return c.txChan
 }
 
+// Shutdown closes the input channel and waits for the buffered rows to be saved.
 func (c *txConsumer) Shutdown() {
 	close(c.txChan)
+	<-c.done
 }
@@ -0,0 +1,75 @@
+package consumer
+
+import (
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"time"
+)
+
+type withdrawalConsumer struct {
+	withdrawalChan  chan Traced[model.WithdrawalRow]
+	storeBufferSize int
+	storeInterval   time.Duration
+	mysqlHandler    data.DataHandler
+	metrics         flushMetrics
+	done            chan struct{}
+}
+
+func (c *withdrawalConsumer) Run() {
+	defer close(c.done)
+	withdrawalBuf := make([]*model.WithdrawalRow, 0)
+	t := time.NewTicker(c.storeInterval)
+	for {
+		select {
+		case withdrawal, ok := <-c.withdrawalChan:
+			if !ok {
+				// save
+				err := c.metrics.flush(len(withdrawalBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveWithdrawalRows(ctx, withdrawalBuf)
+				})
+				if err != nil {
+					logger.Errorf("WithdrawalConsumer Error : %v ", err)
+				}
+				withdrawalBuf = make([]*model.WithdrawalRow, 0)
+				return
+			}
+			withdrawalBuf = append(withdrawalBuf, &withdrawal.Row)
+			c.metrics.link(withdrawal.Span)
+			c.metrics.buffer.Set(float64(len(withdrawalBuf)))
+			if len(withdrawalBuf) == c.storeBufferSize {
+				// save
+				err := c.metrics.flush(len(withdrawalBuf), func(ctx context.Context) error {
+					return c.mysqlHandler.SaveWithdrawalRows(ctx, withdrawalBuf)
+				})
+				if err != nil {
+					logger.Errorf("WithdrawalConsumer Error : %v ", err)
+				}
+				withdrawalBuf = make([]*model.WithdrawalRow, 0)
+			}
+		case <-t.C:
+			if len(withdrawalBuf) == 0 {
+				continue
+			}
+			// save
+			err := c.metrics.flush(len(withdrawalBuf), func(ctx context.Context) error {
+				return c.mysqlHandler.SaveWithdrawalRows(ctx, withdrawalBuf)
+			})
+			if err != nil {
+				logger.Errorf("WithdrawalConsumer Error : %v ", err)
+			}
+			withdrawalBuf = make([]*model.WithdrawalRow, 0)
+		}
+	}
+}
+
+func (c *withdrawalConsumer) GetChan() interface{} {
+	return c.withdrawalChan
+}
+
+// Shutdown closes the input channel and waits for the buffered rows to be saved.
+func (c *withdrawalConsumer) Shutdown() {
+	close(c.withdrawalChan)
+	<-c.done
+}
@@ -0,0 +1,46 @@
+package convert
+
+import (
+	"Ethereum_Service/internal/chainprofile"
+	"Ethereum_Service/pkg/model"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockToRow converts a block into the row stored by the indexer and returned
+// by the API when the block is read from the node.
+func BlockToRow(block *types.Block, profile chainprofile.Profile) (model.BlockRow, error) {
+	miner, err := profile.BlockMiner(block.Header())
+	if err != nil {
+		return model.BlockRow{}, fmt.Errorf("BlockToRow : %w", err)
+	}
+	blockRow := model.BlockRow{
+		Hash:       block.Hash().Hex(),
+		Number:     (*block.Number()).Int64(),
+		GasLimit:   block.GasLimit(),
+		GasUsed:    block.GasUsed(),
+		Difficulty: (*block.Difficulty()).Int64(),
+		Time:       block.Time(),
+		Nonce:      block.Nonce(),
+		Root:       block.Root().Hex(),
+		ParentHash: block.ParentHash().Hex(),
+		TxHash:     block.TxHash().Hex(),
+		UncleHash:  block.UncleHash().Hex(),
+		Extra:      block.Extra(),
+		Miner:      miner.Hex(),
+
+		BlobGasUsed:   block.BlobGasUsed(),
+		ExcessBlobGas: block.ExcessBlobGas(),
+	}
+	if block.BaseFee() != nil {
+		blockRow.BaseFee = block.BaseFee().String()
+	}
+	if block.Header().WithdrawalsHash != nil {
+		blockRow.WithdrawalsRoot = block.Header().WithdrawalsHash.Hex()
+	}
+	if block.BeaconRoot() != nil {
+		blockRow.ParentBeaconRoot = block.BeaconRoot().Hex()
+	}
+	return blockRow, nil
+}
@@ -0,0 +1,37 @@
+package convert
+
+import (
+	"Ethereum_Service/internal/chainprofile"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockToRow(t *testing.T) {
+	beaconRoot := common.HexToHash("0x01")
+	header := &types.Header{
+		Number:           big.NewInt(100),
+		Difficulty:       big.NewInt(0),
+		GasLimit:         30000000,
+		Time:             1700000000,
+		Coinbase:         common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		BaseFee:          big.NewInt(7),
+		WithdrawalsHash:  &types.EmptyWithdrawalsHash,
+		ParentBeaconRoot: &beaconRoot,
+	}
+	block := types.NewBlockWithHeader(header)
+	profile, err := chainprofile.New(chainprofile.Ethereum, 1)
+	assert.NoError(t, err)
+
+	blockRow, err := BlockToRow(block, profile)
+	assert.NoError(t, err)
+	assert.Equal(t, block.Hash().Hex(), blockRow.Hash)
+	assert.Equal(t, int64(100), blockRow.Number)
+	assert.Equal(t, header.Coinbase.Hex(), blockRow.Miner)
+	assert.Equal(t, "7", blockRow.BaseFee)
+	assert.Equal(t, types.EmptyWithdrawalsHash.Hex(), blockRow.WithdrawalsRoot)
+	assert.Equal(t, beaconRoot.Hex(), blockRow.ParentBeaconRoot)
+}
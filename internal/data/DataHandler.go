@@ -3,12 +3,23 @@ package data
 import (
 	"Ethereum_Service/pkg/model"
 	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
 )
 
 type DataHandler interface {
 	SaveBlockRows(ctx context.Context, blockRow []*model.BlockRow) error
 	SaveTransactionRow(ctx context.Context, txRow []*model.TransactionRow) error
 	SaveLogRow(ctx context.Context, logRow []*model.LogRow) error
+	// SaveBlockData writes a block with its transactions, logs, withdrawals
+	// and balances in one transaction, mode is one of the c.WriteMode values.
+	// The redis cache drops the cached rows of the block instead.
+	SaveBlockData(ctx context.Context, mode string, blockData *model.BlockData) error
+	// SaveBlockBalances writes the balances of block number without the
+	// block, mode is one of the c.WriteMode values.
+	SaveBlockBalances(ctx context.Context, mode string, number int64, balances []model.BalanceRow) error
 
 	GetTransactionRow(ctx context.Context, tx *model.TransactionRow) error
 	GetLogRowByTxHash(ctx context.Context, txHash string) ([]model.LogRow, error)
@@ -18,6 +29,43 @@ type DataHandler interface {
 
 	GetBlockRowByBlockNumbers(ctx context.Context, numbers []int64) ([]model.BlockRow, error)
 
+	SaveWithdrawalRows(ctx context.Context, withdrawalRows []*model.WithdrawalRow) error
+	GetWithdrawalRowsByBlockNumber(ctx context.Context, blockNumber int64) ([]model.WithdrawalRow, error)
+	GetWithdrawalRowsByAddress(ctx context.Context, address string, limit int) ([]model.WithdrawalRow, error)
+
+	SaveBalanceRows(ctx context.Context, balanceRows []*model.BalanceRow) error
+	GetBalanceRow(ctx context.Context, address string, blockNumber int64) (model.BalanceRow, error)
+	GetBalanceRowsByAddress(ctx context.Context, address string, beforeBlock int64, limit int) ([]model.BalanceRow, error)
+
+	SavePendingTxRows(ctx context.Context, pendingTxRows []*model.PendingTxRow) error
+	GetPendingTxRow(ctx context.Context, pendingTxRow *model.PendingTxRow) error
+	// GetPendingTxRowsSeenBefore pages through the transactions still pending
+	// which were first seen before seenBefore, ordered by first seen and hash.
+	// after is the last row of the previous page, nil for the first page.
+	GetPendingTxRowsSeenBefore(ctx context.Context, seenBefore time.Time, after *model.PendingTxRow, limit int) ([]model.PendingTxRow, error)
+	UpdatePendingTxStatus(ctx context.Context, hashes []string, status string) error
+
 	UpdateLatestBlockNumber(ctx context.Context, blockNumber int64) error
 	GetLatestBlockNumber(ctx context.Context) (int64, error)
+
+	SaveAbiRow(ctx context.Context, abiRow *model.AbiRow) error
+	GetAbiRow(ctx context.Context, abiRow *model.AbiRow) error
+
+	// AcquireLease takes or renews the lease name for holder until ttl from
+	// now, it returns false while another holder has an unexpired lease.
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// ReleaseLease expires the lease name if holder has it.
+	ReleaseLease(ctx context.Context, name, holder string) error
+
+	// Ping checks that the store is reachable.
+	Ping(ctx context.Context) error
+}
+
+// LeaseHolder identifies this process among the holders of a lease.
+func LeaseHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%08x", host, os.Getpid(), rand.Uint32())
 }
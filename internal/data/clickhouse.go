@@ -0,0 +1,460 @@
+package data
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
+	"context"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	chBlocks         = "blocks"
+	chTransactions   = "transactions"
+	chLogs           = "logs"
+	chTokenTransfers = "token_transfers"
+	chWithdrawals    = "withdrawals"
+
+	tokenStandardERC20  = "erc20"
+	tokenStandardERC721 = "erc721"
+)
+
+// transferTopic is the topic of the Transfer event of ERC20 and ERC721.
+var transferTopic = ethCommon.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// clickHouseSchema creates the tables of the sink, the DATABASE has to exist.
+//
+//go:embed schema/clickhouse/tables.sql
+var clickHouseSchema string
+
+// ClickHouseSink writes the indexed blocks to ClickHouse for analytics. The
+// tables are ReplacingMergeTree partitioned by the month of the block and
+// ordered by the position of the row in the chain, a block written again
+// replaces its rows once the parts are merged or when queried with FINAL.
+type ClickHouseSink struct {
+	conn    driver.Conn
+	chainId int64
+}
+
+func NewClickHouseSink(opt *config.ClickHouseOption, chainId int64) (*ClickHouseSink, error) {
+	conn, err := clickhouse.Open(&clickhouse.Options{
+		Addr: opt.Addrs,
+		Auth: clickhouse.Auth{
+			Database: opt.Database,
+			Username: opt.Username,
+			Password: opt.Password,
+		},
+		Compression: &clickhouse.Compression{Method: clickhouse.CompressionLZ4},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("NewClickHouseSink : %w", err)
+	}
+	ctx := context.Background()
+	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("NewClickHouseSink : %w", err)
+	}
+	for _, statement := range strings.Split(clickHouseSchema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := conn.Exec(ctx, statement); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("NewClickHouseSink : %w", err)
+		}
+	}
+	return &ClickHouseSink{conn: conn, chainId: chainId}, nil
+}
+
+func (s *ClickHouseSink) Close() error {
+	return s.conn.Close()
+}
+
+type chBlockRow struct {
+	ChainId          int64     `ch:"chain_id"`
+	Number           int64     `ch:"number"`
+	Hash             string    `ch:"hash"`
+	ParentHash       string    `ch:"parent_hash"`
+	Time             time.Time `ch:"time"`
+	GasLimit         uint64    `ch:"gas_limit"`
+	GasUsed          uint64    `ch:"gas_used"`
+	Difficulty       int64     `ch:"difficulty"`
+	Nonce            uint64    `ch:"nonce"`
+	Miner            string    `ch:"miner"`
+	BaseFee          string    `ch:"base_fee"`
+	Root             string    `ch:"root"`
+	TxHash           string    `ch:"tx_hash"`
+	UncleHash        string    `ch:"uncle_hash"`
+	Extra            []byte    `ch:"extra"`
+	WithdrawalsRoot  string    `ch:"withdrawals_root"`
+	BlobGasUsed      *uint64   `ch:"blob_gas_used"`
+	ExcessBlobGas    *uint64   `ch:"excess_blob_gas"`
+	ParentBeaconRoot string    `ch:"parent_beacon_root"`
+	TxCount          uint32    `ch:"tx_count"`
+	LogCount         uint32    `ch:"log_count"`
+	Version          uint64    `ch:"version"`
+	IsDeleted        uint8     `ch:"is_deleted"`
+}
+
+type chTransactionRow struct {
+	ChainId     int64     `ch:"chain_id"`
+	BlockNumber int64     `ch:"block_number"`
+	TxIndex     uint32    `ch:"tx_index"`
+	BlockHash   string    `ch:"block_hash"`
+	BlockTime   time.Time `ch:"block_time"`
+	Hash        string    `ch:"hash"`
+	Nonce       uint64    `ch:"nonce"`
+	From        string    `ch:"from"`
+	To          string    `ch:"to"`
+	Value       *big.Int  `ch:"value"`
+	Data        []byte    `ch:"data"`
+	Type        uint8     `ch:"type"`
+	L1Fee       string    `ch:"l1_fee"`
+	L1GasPrice  string    `ch:"l1_gas_price"`
+	L1GasUsed   string    `ch:"l1_gas_used"`
+	Version     uint64    `ch:"version"`
+	IsDeleted   uint8     `ch:"is_deleted"`
+}
+
+type chLogRow struct {
+	ChainId     int64     `ch:"chain_id"`
+	BlockNumber int64     `ch:"block_number"`
+	LogIndex    uint32    `ch:"log_index"`
+	BlockHash   string    `ch:"block_hash"`
+	BlockTime   time.Time `ch:"block_time"`
+	TxHash      string    `ch:"tx_hash"`
+	Address     string    `ch:"address"`
+	Topics      []string  `ch:"topics"`
+	Data        []byte    `ch:"data"`
+	Version     uint64    `ch:"version"`
+	IsDeleted   uint8     `ch:"is_deleted"`
+}
+
+// chTokenTransferRow is a Transfer event of an ERC20 (Amount) or ERC721
+// (TokenId) token.
+type chTokenTransferRow struct {
+	ChainId     int64     `ch:"chain_id"`
+	BlockNumber int64     `ch:"block_number"`
+	LogIndex    uint32    `ch:"log_index"`
+	BlockHash   string    `ch:"block_hash"`
+	BlockTime   time.Time `ch:"block_time"`
+	TxHash      string    `ch:"tx_hash"`
+	Token       string    `ch:"token"`
+	Standard    string    `ch:"standard"`
+	From        string    `ch:"from"`
+	To          string    `ch:"to"`
+	Amount      *big.Int  `ch:"amount"`
+	TokenId     *big.Int  `ch:"token_id"`
+	Version     uint64    `ch:"version"`
+	IsDeleted   uint8     `ch:"is_deleted"`
+}
+
+type chWithdrawalRow struct {
+	ChainId        int64     `ch:"chain_id"`
+	BlockNumber    int64     `ch:"block_number"`
+	Index          uint64    `ch:"index"`
+	BlockHash      string    `ch:"block_hash"`
+	BlockTime      time.Time `ch:"block_time"`
+	ValidatorIndex uint64    `ch:"validator_index"`
+	Address        string    `ch:"address"`
+	Amount         uint64    `ch:"amount"`
+	Version        uint64    `ch:"version"`
+	IsDeleted      uint8     `ch:"is_deleted"`
+}
+
+// decodeTokenTransfer decodes the Transfer event of logRow, ok is false when
+// the log is not the Transfer of an ERC20 or ERC721 token.
+func decodeTokenTransfer(logRow *model.LogRow) (transfer chTokenTransferRow, ok bool) {
+	topics := common.SplitTopics(logRow.Topics)
+	if len(topics) < 3 || topics[0] != transferTopic {
+		return transfer, false
+	}
+	transfer = chTokenTransferRow{
+		TxHash:  logRow.TxHash,
+		Token:   logRow.Address,
+		From:    ethCommon.BytesToAddress(topics[1].Bytes()).Hex(),
+		To:      ethCommon.BytesToAddress(topics[2].Bytes()).Hex(),
+		Amount:  new(big.Int),
+		TokenId: new(big.Int),
+	}
+	switch {
+	case len(topics) == 3 && len(logRow.Data) == 32:
+		transfer.Standard = tokenStandardERC20
+		transfer.Amount.SetBytes(logRow.Data)
+	case len(topics) == 4 && len(logRow.Data) == 0:
+		transfer.Standard = tokenStandardERC721
+		transfer.Amount.SetInt64(1)
+		transfer.TokenId.SetBytes(topics[3].Bytes())
+	default:
+		return transfer, false
+	}
+	return transfer, true
+}
+
+// chBatch is a batch insert into one table.
+type chBatch struct {
+	table string
+	batch driver.Batch
+	// positions are the rows of each block in the batch, the rows of a
+	// replaced block left at other positions are deleted
+	positions map[int64]map[uint64]time.Time
+}
+
+// SaveBlocks inserts the rows of blocks with one batch per table. The rows of
+// a block written in replace mode that are not part of the new block are
+// deleted, the rows at the same positions are replaced by the newer version.
+func (s *ClickHouseSink) SaveBlocks(ctx context.Context, blocks []model.BlockWrite) error {
+	tables := []string{chBlocks, chTransactions, chLogs, chTokenTransfers, chWithdrawals}
+	batches := make(map[string]*chBatch, len(tables))
+	for _, table := range tables {
+		batch, err := s.conn.PrepareBatch(ctx, "INSERT INTO "+table)
+		if err != nil {
+			return fmt.Errorf("SaveBlocks : %w", err)
+		}
+		defer batch.Abort()
+		batches[table] = &chBatch{table: table, batch: batch, positions: make(map[int64]map[uint64]time.Time)}
+	}
+
+	version := uint64(time.Now().UnixNano())
+	for _, write := range blocks {
+		if err := s.appendBlock(batches, write.Block, version); err != nil {
+			return fmt.Errorf("SaveBlocks : %w", err)
+		}
+	}
+	for _, write := range blocks {
+		if write.Mode != c.WriteModeReplace {
+			continue
+		}
+		for _, table := range tables {
+			if err := s.appendTombstones(ctx, batches[table], write.Block.Block.Number, version); err != nil {
+				return fmt.Errorf("SaveBlocks : %w", err)
+			}
+		}
+	}
+
+	for _, table := range tables {
+		if err := batches[table].batch.Send(); err != nil {
+			return fmt.Errorf("SaveBlocks : %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (s *ClickHouseSink) appendBlock(batches map[string]*chBatch, blockData *model.BlockData, version uint64) error {
+	block := &blockData.Block
+	blockTime := time.Unix(int64(block.Time), 0).UTC()
+	err := batches[chBlocks].append(block.Number, uint64(block.Number), blockTime, &chBlockRow{
+		ChainId:          s.chainId,
+		Number:           block.Number,
+		Hash:             block.Hash,
+		ParentHash:       block.ParentHash,
+		Time:             blockTime,
+		GasLimit:         block.GasLimit,
+		GasUsed:          block.GasUsed,
+		Difficulty:       block.Difficulty,
+		Nonce:            block.Nonce,
+		Miner:            block.Miner,
+		BaseFee:          block.BaseFee,
+		Root:             block.Root,
+		TxHash:           block.TxHash,
+		UncleHash:        block.UncleHash,
+		Extra:            block.Extra,
+		WithdrawalsRoot:  block.WithdrawalsRoot,
+		BlobGasUsed:      block.BlobGasUsed,
+		ExcessBlobGas:    block.ExcessBlobGas,
+		ParentBeaconRoot: block.ParentBeaconRoot,
+		TxCount:          uint32(len(blockData.Txs)),
+		LogCount:         uint32(len(blockData.Logs)),
+		Version:          version,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, tx := range blockData.Txs {
+		value := tx.ValueWei
+		if value == nil {
+			value = big.NewInt(tx.Value)
+		}
+		err := batches[chTransactions].append(block.Number, uint64(i), blockTime, &chTransactionRow{
+			ChainId:     s.chainId,
+			BlockNumber: block.Number,
+			TxIndex:     uint32(i),
+			BlockHash:   block.Hash,
+			BlockTime:   blockTime,
+			Hash:        tx.Hash,
+			Nonce:       tx.Nonce,
+			From:        tx.From,
+			To:          tx.To,
+			Value:       value,
+			Data:        tx.Data,
+			Type:        tx.Type,
+			L1Fee:       tx.L1Fee,
+			L1GasPrice:  tx.L1GasPrice,
+			L1GasUsed:   tx.L1GasUsed,
+			Version:     version,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range blockData.Logs {
+		logRow := &blockData.Logs[i]
+		topics := make([]string, 0)
+		for _, topic := range common.SplitTopics(logRow.Topics) {
+			topics = append(topics, topic.Hex())
+		}
+		err := batches[chLogs].append(block.Number, uint64(logRow.Index), blockTime, &chLogRow{
+			ChainId:     s.chainId,
+			BlockNumber: block.Number,
+			LogIndex:    uint32(logRow.Index),
+			BlockHash:   block.Hash,
+			BlockTime:   blockTime,
+			TxHash:      logRow.TxHash,
+			Address:     logRow.Address,
+			Topics:      topics,
+			Data:        logRow.Data,
+			Version:     version,
+		})
+		if err != nil {
+			return err
+		}
+
+		transfer, ok := decodeTokenTransfer(logRow)
+		if !ok {
+			continue
+		}
+		transfer.ChainId = s.chainId
+		transfer.BlockNumber = block.Number
+		transfer.LogIndex = uint32(logRow.Index)
+		transfer.BlockHash = block.Hash
+		transfer.BlockTime = blockTime
+		transfer.Version = version
+		if err := batches[chTokenTransfers].append(block.Number, uint64(logRow.Index), blockTime, &transfer); err != nil {
+			return err
+		}
+	}
+
+	for _, withdrawal := range blockData.Withdrawals {
+		err := batches[chWithdrawals].append(block.Number, withdrawal.Index, blockTime, &chWithdrawalRow{
+			ChainId:        s.chainId,
+			BlockNumber:    block.Number,
+			Index:          withdrawal.Index,
+			BlockHash:      block.Hash,
+			BlockTime:      blockTime,
+			ValidatorIndex: withdrawal.ValidatorIndex,
+			Address:        withdrawal.Address,
+			Amount:         withdrawal.Amount,
+			Version:        version,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *chBatch) append(number int64, position uint64, blockTime time.Time, row interface{}) error {
+	if b.positions[number] == nil {
+		b.positions[number] = make(map[uint64]time.Time)
+	}
+	b.positions[number][position] = blockTime
+	if err := b.batch.AppendStruct(row); err != nil {
+		return fmt.Errorf("%s: %w", b.table, err)
+	}
+	return nil
+}
+
+// appendTombstones deletes the stored rows of block number that the batch
+// does not replace. A row is only replaced within its partition, so the rows
+// of a block moved to another month are deleted as well.
+func (s *ClickHouseSink) appendTombstones(ctx context.Context, b *chBatch, number int64, version uint64) error {
+	positionColumn, numberColumn, timeColumn := chPositionColumns(b.table)
+	rows, err := s.conn.Query(ctx, fmt.Sprintf(
+		"SELECT toUInt64(%s), %s FROM %s FINAL WHERE chain_id = ? AND %s = ? AND is_deleted = 0",
+		positionColumn, timeColumn, b.table, numberColumn), s.chainId, number)
+	if err != nil {
+		return fmt.Errorf("%s: %w", b.table, err)
+	}
+	defer rows.Close()
+
+	type stored struct {
+		position  uint64
+		blockTime time.Time
+	}
+	var stale []stored
+	for rows.Next() {
+		var row stored
+		if err := rows.Scan(&row.position, &row.blockTime); err != nil {
+			return fmt.Errorf("%s: %w", b.table, err)
+		}
+		blockTime, ok := b.positions[number][row.position]
+		if ok && chSamePartition(blockTime, row.blockTime) {
+			continue
+		}
+		stale = append(stale, row)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", b.table, err)
+	}
+
+	for _, row := range stale {
+		if err := b.batch.AppendStruct(s.tombstone(b.table, number, row.position, row.blockTime, version)); err != nil {
+			return fmt.Errorf("%s: %w", b.table, err)
+		}
+	}
+	return nil
+}
+
+// tombstone is the deleted row of table at a position, the sort key and the
+// partition of the row it deletes are the only columns set.
+func (s *ClickHouseSink) tombstone(table string, number int64, position uint64, blockTime time.Time, version uint64) interface{} {
+	switch table {
+	case chBlocks:
+		return &chBlockRow{ChainId: s.chainId, Number: number, Time: blockTime, Version: version, IsDeleted: 1}
+	case chTransactions:
+		return &chTransactionRow{ChainId: s.chainId, BlockNumber: number, TxIndex: uint32(position),
+			BlockTime: blockTime, Value: new(big.Int), Version: version, IsDeleted: 1}
+	case chLogs:
+		return &chLogRow{ChainId: s.chainId, BlockNumber: number, LogIndex: uint32(position),
+			BlockTime: blockTime, Topics: []string{}, Version: version, IsDeleted: 1}
+	case chTokenTransfers:
+		return &chTokenTransferRow{ChainId: s.chainId, BlockNumber: number, LogIndex: uint32(position),
+			BlockTime: blockTime, Amount: new(big.Int), TokenId: new(big.Int), Version: version, IsDeleted: 1}
+	default:
+		return &chWithdrawalRow{ChainId: s.chainId, BlockNumber: number, Index: position,
+			BlockTime: blockTime, Version: version, IsDeleted: 1}
+	}
+}
+
+// chPositionColumns are the columns of the position of a row within its
+// block, of the block number and of the partition time of table.
+func chPositionColumns(table string) (position, number, blockTime string) {
+	switch table {
+	case chBlocks:
+		return "number", "number", "time"
+	case chTransactions:
+		return "tx_index", "block_number", "block_time"
+	case chWithdrawals:
+		return "`index`", "block_number", "block_time"
+	default:
+		return "log_index", "block_number", "block_time"
+	}
+}
+
+// chSamePartition reports whether two block times are in the same monthly
+// partition.
+func chSamePartition(a, b time.Time) bool {
+	a, b = a.UTC(), b.UTC()
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
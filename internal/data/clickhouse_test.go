@@ -0,0 +1,81 @@
+package data
+
+import (
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTokenTransfer(t *testing.T) {
+	from := ethCommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := ethCommon.HexToAddress("0x2222222222222222222222222222222222222222")
+	topics := []ethCommon.Hash{transferTopic, ethCommon.BytesToHash(from.Bytes()), ethCommon.BytesToHash(to.Bytes())}
+
+	erc20 := &model.LogRow{
+		Address: "0xToken",
+		Topics:  common.JoinTopics(topics),
+		Data:    ethCommon.BigToHash(big.NewInt(1000)).Bytes(),
+	}
+	transfer, ok := decodeTokenTransfer(erc20)
+	assert.True(t, ok)
+	assert.Equal(t, tokenStandardERC20, transfer.Standard)
+	assert.Equal(t, from.Hex(), transfer.From)
+	assert.Equal(t, to.Hex(), transfer.To)
+	assert.Equal(t, int64(1000), transfer.Amount.Int64())
+
+	erc721 := &model.LogRow{
+		Address: "0xToken",
+		Topics:  common.JoinTopics(append(topics, ethCommon.BigToHash(big.NewInt(7)))),
+	}
+	transfer, ok = decodeTokenTransfer(erc721)
+	assert.True(t, ok)
+	assert.Equal(t, tokenStandardERC721, transfer.Standard)
+	assert.Equal(t, int64(7), transfer.TokenId.Int64())
+
+	// an ERC20 Approval and a log without topics are not transfers
+	approval := ethCommon.HexToHash("0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+	_, ok = decodeTokenTransfer(&model.LogRow{Topics: common.JoinTopics([]ethCommon.Hash{approval, topics[1], topics[2]})})
+	assert.False(t, ok)
+	_, ok = decodeTokenTransfer(&model.LogRow{})
+	assert.False(t, ok)
+}
+
+// recordedBatch keeps the appended rows.
+type recordedBatch struct {
+	driver.Batch
+	rows []interface{}
+}
+
+func (b *recordedBatch) AppendStruct(row interface{}) error {
+	b.rows = append(b.rows, row)
+	return nil
+}
+
+func TestClickHouseTransactionValue(t *testing.T) {
+	sink := &ClickHouseSink{chainId: 1}
+	batches := make(map[string]*chBatch)
+	for _, table := range []string{chBlocks, chTransactions, chLogs, chTokenTransfers, chWithdrawals} {
+		batches[table] = &chBatch{table: table, batch: &recordedBatch{}, positions: make(map[int64]map[uint64]time.Time)}
+	}
+	// 100 ether does not fit in an int64 of wei
+	value, _ := new(big.Int).SetString("100000000000000000000", 10)
+	blockData := &model.BlockData{
+		Block: model.BlockRow{Number: 10},
+		Txs: []model.TransactionRow{
+			{Hash: "0x01", Value: value.Int64(), ValueWei: value},
+			{Hash: "0x02", Value: 5},
+		},
+	}
+	assert.NoError(t, sink.appendBlock(batches, blockData, 1))
+
+	rows := batches[chTransactions].batch.(*recordedBatch).rows
+	assert.Len(t, rows, 2)
+	assert.Equal(t, value, rows[0].(*chTransactionRow).Value)
+	assert.Equal(t, big.NewInt(5), rows[1].(*chTransactionRow).Value)
+}
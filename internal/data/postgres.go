@@ -0,0 +1,187 @@
+package data
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	gormPostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// copyMinRows is the smallest batch loaded with COPY, smaller batches are
+// inserted as the staging table costs more than it saves.
+const copyMinRows = 16
+
+// PostgresHandler is the SqlHandler of PostgreSQL, the batches of the
+// consumers are loaded with COPY.
+type PostgresHandler struct {
+	*SqlHandler
+	db *sql.DB
+}
+
+func NewPostgresHandler(databaseOpts *config.DatabaseOption, chainId int64) (DataHandler, error) {
+	db, err := common.OpenPostgresDatabase(databaseOpts)
+	if err != nil {
+		return nil, fmt.Errorf("NewPostgresHandler: %s", err)
+	}
+	if err := common.PostgresMigration(db); err != nil {
+		return nil, fmt.Errorf("NewPostgresHandler: %s", err)
+	}
+
+	gormClient, err := gorm.Open(gormPostgres.New(gormPostgres.Config{
+		Conn: db,
+	}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		return nil, fmt.Errorf("NewPostgresHandler: %v", err)
+	}
+
+	return newTracedHandler("postgresql", &PostgresHandler{
+		SqlHandler: &SqlHandler{
+			gormClient: gormClient,
+			chainId:    chainId,
+			nowMillis:  "CAST(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP) * 1000 AS BIGINT)",
+		},
+		db: db,
+	}), nil
+}
+
+func (h *PostgresHandler) SaveBlockRows(ctx context.Context, blockRow []*model.BlockRow) error {
+	if len(blockRow) < copyMinRows {
+		return h.SqlHandler.SaveBlockRows(ctx, blockRow)
+	}
+	rows := make([][]interface{}, 0, len(blockRow))
+	for _, row := range blockRow {
+		rows = append(rows, []interface{}{
+			h.chainId, row.Hash, row.Number, row.GasLimit, row.GasUsed, row.Difficulty, row.Time, row.Nonce,
+			row.Root, row.ParentHash, row.TxHash, row.UncleHash, row.Extra, row.Miner, row.BaseFee,
+			row.WithdrawalsRoot, row.BlobGasUsed, row.ExcessBlobGas, row.ParentBeaconRoot,
+		})
+	}
+	err := h.copyRows(ctx, c.Block, []string{
+		"chain_id", "hash", "number", "gas_limit", "gas_used", "difficulty", "time", "nonce",
+		"root", "parent_hash", "tx_hash", "uncle_hash", "extra", "miner", "base_fee",
+		"withdrawals_root", "blob_gas_used", "excess_blob_gas", "parent_beacon_root",
+	}, rows)
+	if err != nil {
+		return fmt.Errorf("SaveBlockRows : %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresHandler) SaveTransactionRow(ctx context.Context, txRow []*model.TransactionRow) error {
+	if len(txRow) < copyMinRows {
+		return h.SqlHandler.SaveTransactionRow(ctx, txRow)
+	}
+	rows := make([][]interface{}, 0, len(txRow))
+	for _, row := range txRow {
+		rows = append(rows, []interface{}{
+			h.chainId, row.Hash, row.BlockNumber, row.Nonce, row.To, row.From, row.Value, row.Data, row.Type,
+			row.L1Fee, row.L1GasPrice, row.L1GasUsed,
+		})
+	}
+	err := h.copyRows(ctx, c.Tx, []string{
+		"chain_id", "hash", "block_number", "nonce", "to", "from", "value", "data", "type",
+		"l1_fee", "l1_gas_price", "l1_gas_used",
+	}, rows)
+	if err != nil {
+		return fmt.Errorf("SaveTransactionRow : %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresHandler) SaveLogRow(ctx context.Context, logRow []*model.LogRow) error {
+	if len(logRow) < copyMinRows {
+		return h.SqlHandler.SaveLogRow(ctx, logRow)
+	}
+	rows := make([][]interface{}, 0, len(logRow))
+	for _, row := range logRow {
+		rows = append(rows, []interface{}{h.chainId, row.TxHash, row.Index, row.Data, row.Address, row.Topics})
+	}
+	err := h.copyRows(ctx, c.Log, []string{"chain_id", "tx_hash", "index", "data", "address", "topics"}, rows)
+	if err != nil {
+		return fmt.Errorf("SaveLogRow : %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresHandler) SaveWithdrawalRows(ctx context.Context, withdrawalRows []*model.WithdrawalRow) error {
+	if len(withdrawalRows) < copyMinRows {
+		return h.SqlHandler.SaveWithdrawalRows(ctx, withdrawalRows)
+	}
+	rows := make([][]interface{}, 0, len(withdrawalRows))
+	for _, row := range withdrawalRows {
+		rows = append(rows, []interface{}{h.chainId, row.Index, row.BlockNumber, row.ValidatorIndex, row.Address, row.Amount})
+	}
+	err := h.copyRows(ctx, c.Withdrawal, []string{
+		"chain_id", "index", "block_number", "validator_index", "address", "amount",
+	}, rows)
+	if err != nil {
+		return fmt.Errorf("SaveWithdrawalRows : %w", err)
+	}
+	return nil
+}
+
+func (h *PostgresHandler) SaveBalanceRows(ctx context.Context, balanceRows []*model.BalanceRow) error {
+	if len(balanceRows) < copyMinRows {
+		return h.SqlHandler.SaveBalanceRows(ctx, balanceRows)
+	}
+	rows := make([][]interface{}, 0, len(balanceRows))
+	for _, row := range balanceRows {
+		rows = append(rows, []interface{}{h.chainId, row.Address, row.BlockNumber, row.Delta, row.Balance, row.Nonce})
+	}
+	err := h.copyRows(ctx, c.Balance, []string{
+		"chain_id", "address", "block_number", "delta", "balance", "nonce",
+	}, rows)
+	if err != nil {
+		return fmt.Errorf("SaveBalanceRows : %w", err)
+	}
+	return nil
+}
+
+// copyRows loads rows into a staging table with COPY and moves them to table
+// in one transaction, rows already stored are kept like the inserts of the
+// consumers.
+func (h *PostgresHandler) copyRows(ctx context.Context, table string, columns []string, rows [][]interface{}) error {
+	conn, err := h.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("copyRows : %w", err)
+	}
+	defer conn.Close()
+
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, pgx.Identifier{column}.Sanitize())
+	}
+	columnList := strings.Join(quoted, ", ")
+	staging := pgx.Identifier{"staging_" + table}.Sanitize()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		pgConn := driverConn.(*stdlib.Conn).Conn()
+		return pgx.BeginFunc(ctx, pgConn, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP",
+				staging, pgx.Identifier{table}.Sanitize()))
+			if err != nil {
+				return err
+			}
+			_, err = tx.CopyFrom(ctx, pgx.Identifier{"staging_" + table}, columns, pgx.CopyFromRows(rows))
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s ON CONFLICT DO NOTHING",
+				pgx.Identifier{table}.Sanitize(), columnList, columnList, staging))
+			return err
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("copyRows : %w", err)
+	}
+	return nil
+}
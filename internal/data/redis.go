@@ -1,36 +1,47 @@
 package data
 
 import (
+	"Ethereum_Service/c"
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
 	"Ethereum_Service/pkg/model"
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis"
 )
 
 type RedisDataHandler struct {
 	redisClient *redis.Client
+	chainId     int64
 }
 
 const (
 	TransactionRowKey = "Transaction"
 )
 
-func NewRedisDataHandler() *RedisDataHandler {
+func NewRedisDataHandler(chainId int64) DataHandler {
 	addr := fmt.Sprintf("%s:%s", config.GetConfig().Redis.Host, config.GetConfig().Redis.Port)
 
 	cli := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: config.GetConfig().Redis.Password,
 	})
-	return &RedisDataHandler{redisClient: cli}
+	return newTracedHandler("redis", &RedisDataHandler{redisClient: cli, chainId: chainId})
+}
+
+// key prefixes the cache keys with the chain id.
+func (h *RedisDataHandler) key(format string, a ...interface{}) string {
+	return fmt.Sprintf("chain:%d:", h.chainId) + fmt.Sprintf(format, a...)
 }
 
 func (h *RedisDataHandler) GetTransactionRow(ctx context.Context, tx *model.TransactionRow) error {
-	key := fmt.Sprintf("%d:TransactionRow:%s", tx.BlockNumber, tx.Hash)
+	key := h.key("%d:TransactionRow:%s", tx.BlockNumber, tx.Hash)
 	txString, err := h.redisClient.Get(key).Result()
+	monitor.ObserveRedisLookup("transaction", err)
 	if err != nil {
 		return fmt.Errorf("GetTransactionRow: %w", err)
 	}
@@ -44,7 +55,7 @@ func (h *RedisDataHandler) GetTransactionRow(ctx context.Context, tx *model.Tran
 
 func (h *RedisDataHandler) SaveTransactionRow(ctx context.Context, txRow []*model.TransactionRow) error {
 	for _, tx := range txRow {
-		key := fmt.Sprintf("%d:TransactionRow:%s", tx.BlockNumber, tx.Hash)
+		key := h.key("%d:TransactionRow:%s", tx.BlockNumber, tx.Hash)
 		bs, err := json.Marshal(*tx)
 		if err != nil {
 			return fmt.Errorf("SaveTransactionRow: %w", err)
@@ -60,8 +71,9 @@ func (h *RedisDataHandler) SaveTransactionRow(ctx context.Context, txRow []*mode
 func (h *RedisDataHandler) GetBlockRowByBlockNumbers(ctx context.Context, numbers []int64) ([]model.BlockRow, error) {
 	var result []model.BlockRow
 	for _, number := range numbers {
-		key := fmt.Sprintf("BlockRow:%d", number)
+		key := h.key("BlockRow:%d", number)
 		v, err := h.redisClient.Get(key).Result()
+		monitor.ObserveRedisLookup("block", err)
 		if err != nil {
 			return nil, fmt.Errorf("GetBlockRowByBlockNumbers: %w", err)
 		}
@@ -78,9 +90,10 @@ func (h *RedisDataHandler) GetBlockRowByBlockNumbers(ctx context.Context, number
 }
 
 func (h *RedisDataHandler) GetBlockRow(ctx context.Context, block *model.BlockRow) error {
-	key := fmt.Sprintf("BlockRow:%d", block.Number)
+	key := h.key("BlockRow:%d", block.Number)
 
 	blockString, err := h.redisClient.Get(key).Result()
+	monitor.ObserveRedisLookup("block", err)
 	if err != nil {
 		return fmt.Errorf("GetBlockRow: %w", err)
 	}
@@ -95,7 +108,7 @@ func (h *RedisDataHandler) GetBlockRow(ctx context.Context, block *model.BlockRo
 
 func (h *RedisDataHandler) SaveBlockRows(ctx context.Context, blocks []*model.BlockRow) error {
 	for _, block := range blocks {
-		key := fmt.Sprintf("BlockRow:%d", block.Number)
+		key := h.key("BlockRow:%d", block.Number)
 		bs, err := json.Marshal(*block)
 		if err != nil {
 			return fmt.Errorf("SaveBlockRows: %w", err)
@@ -110,7 +123,7 @@ func (h *RedisDataHandler) SaveBlockRows(ctx context.Context, blocks []*model.Bl
 
 func (h *RedisDataHandler) GetLogRowByTxHash(ctx context.Context, txHash string) ([]model.LogRow, error) {
 	var result []model.LogRow
-	key := fmt.Sprintf("TxLog:%s:*", txHash)
+	key := h.key("TxLog:%s:*", txHash)
 	iter := h.redisClient.Scan(0, key, 0).Iterator()
 	for iter.Next() {
 		key := iter.Val()
@@ -127,11 +140,16 @@ func (h *RedisDataHandler) GetLogRowByTxHash(ctx context.Context, txHash string)
 		}
 		result = append(result, logRow)
 	}
+	if len(result) == 0 {
+		monitor.ObserveRedisLookup("log", redis.Nil)
+	} else {
+		monitor.ObserveRedisLookup("log", nil)
+	}
 	return result, nil
 }
 func (h *RedisDataHandler) SaveLogRow(ctx context.Context, logRow []*model.LogRow) error {
 	for _, log := range logRow {
-		key := fmt.Sprintf("TxLog:%s:%s", log.TxHash, log.Index)
+		key := h.key("TxLog:%s:%d", log.TxHash, log.Index)
 		bs, err := json.Marshal(log)
 		if err != nil {
 			return fmt.Errorf("SaveLogRow: %w", err)
@@ -154,3 +172,112 @@ func (h *RedisDataHandler) GetTransactionRowByBlockNumber(ctx context.Context, b
 func (h *RedisDataHandler) UpdateLatestBlockNumber(ctx context.Context, blockNumber int64) error {
 	return nil
 }
+
+func (h *RedisDataHandler) SaveAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	return nil
+}
+
+func (h *RedisDataHandler) GetAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	return nil
+}
+
+// SaveBlockData drops the cached block, transactions and logs of a block
+// written again in upsert or replace mode, the api caches the new rows on the
+// next lookup. Inserts keep the stored rows, so the cache is left as is.
+func (h *RedisDataHandler) SaveBlockData(ctx context.Context, mode string, blockData *model.BlockData) error {
+	if mode == c.WriteModeInsert {
+		return nil
+	}
+	number := blockData.Block.Number
+	keys := []string{h.key("BlockRow:%d", number)}
+	// the transactions cached for the block and the ones it holds now, a
+	// reorganization may have dropped or moved some of them
+	txHashes := make(map[string]bool, len(blockData.Txs))
+	for _, tx := range blockData.Txs {
+		txHashes[tx.Hash] = true
+	}
+	txPrefix := h.key("%d:TransactionRow:", number)
+	iter := h.redisClient.Scan(0, txPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		keys = append(keys, iter.Val())
+		txHashes[strings.TrimPrefix(iter.Val(), txPrefix)] = true
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("SaveBlockData: %w", err)
+	}
+	logPrefix := h.key("TxLog:")
+	iter = h.redisClient.Scan(0, logPrefix+"*", 0).Iterator()
+	for iter.Next() {
+		txHash, _, _ := strings.Cut(strings.TrimPrefix(iter.Val(), logPrefix), ":")
+		if txHashes[txHash] {
+			keys = append(keys, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("SaveBlockData: %w", err)
+	}
+
+	if err := h.redisClient.Del(keys...).Err(); err != nil {
+		return fmt.Errorf("SaveBlockData: %w", err)
+	}
+	return nil
+}
+
+func (h *RedisDataHandler) SaveBlockBalances(ctx context.Context, mode string, number int64, balances []model.BalanceRow) error {
+	return nil
+}
+
+func (h *RedisDataHandler) SaveWithdrawalRows(ctx context.Context, withdrawalRows []*model.WithdrawalRow) error {
+	return nil
+}
+
+func (h *RedisDataHandler) GetWithdrawalRowsByBlockNumber(ctx context.Context, blockNumber int64) ([]model.WithdrawalRow, error) {
+	return nil, nil
+}
+
+func (h *RedisDataHandler) GetWithdrawalRowsByAddress(ctx context.Context, address string, limit int) ([]model.WithdrawalRow, error) {
+	return nil, nil
+}
+
+func (h *RedisDataHandler) SaveBalanceRows(ctx context.Context, balanceRows []*model.BalanceRow) error {
+	return nil
+}
+
+func (h *RedisDataHandler) GetBalanceRow(ctx context.Context, address string, blockNumber int64) (model.BalanceRow, error) {
+	return model.BalanceRow{}, nil
+}
+
+func (h *RedisDataHandler) GetBalanceRowsByAddress(ctx context.Context, address string, beforeBlock int64, limit int) ([]model.BalanceRow, error) {
+	return nil, nil
+}
+
+func (h *RedisDataHandler) SavePendingTxRows(ctx context.Context, pendingTxRows []*model.PendingTxRow) error {
+	return nil
+}
+
+func (h *RedisDataHandler) GetPendingTxRow(ctx context.Context, pendingTxRow *model.PendingTxRow) error {
+	return nil
+}
+
+func (h *RedisDataHandler) GetPendingTxRowsSeenBefore(ctx context.Context, seenBefore time.Time, after *model.PendingTxRow, limit int) ([]model.PendingTxRow, error) {
+	return nil, nil
+}
+
+func (h *RedisDataHandler) UpdatePendingTxStatus(ctx context.Context, hashes []string, status string) error {
+	return nil
+}
+
+func (h *RedisDataHandler) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (h *RedisDataHandler) ReleaseLease(ctx context.Context, name, holder string) error {
+	return nil
+}
+
+func (h *RedisDataHandler) Ping(ctx context.Context) error {
+	if err := h.redisClient.Ping().Err(); err != nil {
+		return fmt.Errorf("Ping: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package data
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRedisSaveBlockData runs against the Redis of DATA_TEST_REDIS_ADDR:
+//
+//	DATA_TEST_REDIS_ADDR=localhost:6379 go test ./internal/data -run TestRedis
+func TestRedisSaveBlockData(t *testing.T) {
+	addr := os.Getenv("DATA_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("DATA_TEST_REDIS_ADDR is not set")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	assert.NoError(t, err)
+	redisOption := config.GetConfig().Redis
+	defer func() { config.GetConfig().Redis = redisOption }()
+	config.GetConfig().Redis = config.RedisOption{Host: host, Port: port}
+
+	// every run caches the rows of a new chain
+	ctx := context.Background()
+	cache := NewRedisDataHandler(time.Now().UnixNano())
+	cached := func() (block, tx, kept, dropped bool) {
+		block = cache.GetBlockRow(ctx, &model.BlockRow{Number: 10}) == nil
+		tx = cache.GetTransactionRow(ctx, &model.TransactionRow{Hash: "0xa", BlockNumber: 10}) == nil
+		kept = cache.GetTransactionRow(ctx, &model.TransactionRow{Hash: "0xc", BlockNumber: 11}) == nil
+		logs, err := cache.GetLogRowByTxHash(ctx, "0xb")
+		assert.NoError(t, err)
+		return block, tx, kept, len(logs) != 0
+	}
+
+	assert.NoError(t, cache.SaveBlockRows(ctx, []*model.BlockRow{{Hash: "0x01", Number: 10}}))
+	assert.NoError(t, cache.SaveTransactionRow(ctx, []*model.TransactionRow{
+		{Hash: "0xa", BlockNumber: 10}, {Hash: "0xb", BlockNumber: 10}, {Hash: "0xc", BlockNumber: 11},
+	}))
+	assert.NoError(t, cache.SaveLogRow(ctx, []*model.LogRow{{TxHash: "0xb", Index: 0}}))
+
+	replaced := &model.BlockData{Block: model.BlockRow{Hash: "0x02", Number: 10}, Txs: []model.TransactionRow{{Hash: "0xa", BlockNumber: 10}}}
+	assert.NoError(t, cache.SaveBlockData(ctx, c.WriteModeInsert, replaced))
+	block, tx, kept, dropped := cached()
+	assert.True(t, block && tx && kept && dropped)
+
+	// the block, its transactions and the logs of the dropped transaction go,
+	// the rows of the other blocks stay
+	assert.NoError(t, cache.SaveBlockData(ctx, c.WriteModeReplace, replaced))
+	block, tx, kept, dropped = cached()
+	assert.False(t, block)
+	assert.False(t, tx)
+	assert.True(t, kept)
+	assert.False(t, dropped)
+}
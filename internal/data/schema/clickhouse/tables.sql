@@ -0,0 +1,109 @@
+CREATE TABLE IF NOT EXISTS blocks
+(
+    chain_id           Int64,
+    number             Int64,
+    hash               String,
+    parent_hash        String,
+    time               DateTime('UTC'),
+    gas_limit          UInt64,
+    gas_used           UInt64,
+    difficulty         Int64,
+    nonce              UInt64,
+    miner              LowCardinality(String),
+    base_fee           String,
+    root               String,
+    tx_hash            String,
+    uncle_hash         String,
+    extra              String,
+    withdrawals_root   String,
+    blob_gas_used      Nullable(UInt64),
+    excess_blob_gas    Nullable(UInt64),
+    parent_beacon_root String,
+    tx_count           UInt32,
+    log_count          UInt32,
+    version            UInt64,
+    is_deleted         UInt8
+)
+ENGINE = ReplacingMergeTree(version, is_deleted)
+PARTITION BY toYYYYMM(time)
+ORDER BY (chain_id, number);
+
+CREATE TABLE IF NOT EXISTS transactions
+(
+    chain_id     Int64,
+    block_number Int64,
+    tx_index     UInt32,
+    block_hash   String,
+    block_time   DateTime('UTC'),
+    hash         String,
+    nonce        UInt64,
+    `from`       String,
+    `to`         String,
+    value        UInt256,
+    data         String,
+    type         UInt8,
+    l1_fee       String,
+    l1_gas_price String,
+    l1_gas_used  String,
+    version      UInt64,
+    is_deleted   UInt8
+)
+ENGINE = ReplacingMergeTree(version, is_deleted)
+PARTITION BY toYYYYMM(block_time)
+ORDER BY (chain_id, block_number, tx_index);
+
+CREATE TABLE IF NOT EXISTS logs
+(
+    chain_id     Int64,
+    block_number Int64,
+    log_index    UInt32,
+    block_hash   String,
+    block_time   DateTime('UTC'),
+    tx_hash      String,
+    address      String,
+    topics       Array(String),
+    data         String,
+    version      UInt64,
+    is_deleted   UInt8
+)
+ENGINE = ReplacingMergeTree(version, is_deleted)
+PARTITION BY toYYYYMM(block_time)
+ORDER BY (chain_id, block_number, log_index);
+
+CREATE TABLE IF NOT EXISTS token_transfers
+(
+    chain_id     Int64,
+    block_number Int64,
+    log_index    UInt32,
+    block_hash   String,
+    block_time   DateTime('UTC'),
+    tx_hash      String,
+    token        String,
+    standard     LowCardinality(String),
+    `from`       String,
+    `to`         String,
+    amount       UInt256,
+    token_id     UInt256,
+    version      UInt64,
+    is_deleted   UInt8
+)
+ENGINE = ReplacingMergeTree(version, is_deleted)
+PARTITION BY toYYYYMM(block_time)
+ORDER BY (chain_id, block_number, log_index);
+
+CREATE TABLE IF NOT EXISTS withdrawals
+(
+    chain_id        Int64,
+    block_number    Int64,
+    `index`         UInt64,
+    block_hash      String,
+    block_time      DateTime('UTC'),
+    validator_index UInt64,
+    address         String,
+    amount          UInt64,
+    version         UInt64,
+    is_deleted      UInt8
+)
+ENGINE = ReplacingMergeTree(version, is_deleted)
+PARTITION BY toYYYYMM(block_time)
+ORDER BY (chain_id, block_number, `index`);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS `lease`;
+DROP TABLE IF EXISTS `pending_tx`;
+DROP TABLE IF EXISTS `balance`;
+DROP TABLE IF EXISTS `withdrawal`;
+DROP TABLE IF EXISTS `abi`;
+DROP TABLE IF EXISTS `latest_block_number`;
+DROP TABLE IF EXISTS `log`;
+DROP TABLE IF EXISTS `tx`;
+DROP TABLE IF EXISTS `block`;
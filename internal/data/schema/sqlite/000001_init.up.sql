@@ -0,0 +1,109 @@
+CREATE TABLE IF NOT EXISTS `block` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `hash` varchar(255) NOT NULL,
+  `number` bigint NOT NULL,
+  `gas_limit` bigint unsigned NOT NULL,
+  `gas_used` bigint unsigned NOT NULL,
+  `difficulty` bigint NOT NULL,
+  `time` bigint unsigned NOT NULL,
+  `nonce` bigint unsigned NOT NULL,
+  `root` varchar(255) NOT NULL,
+  `parent_hash` varchar(255) NOT NULL,
+  `tx_hash` varchar(255) NOT NULL,
+  `uncle_hash` varchar(255) NOT NULL,
+  `extra` blob NOT NULL,
+  `miner` varchar(42) NOT NULL DEFAULT '',
+  `base_fee` varchar(78) NOT NULL DEFAULT '',
+  `withdrawals_root` varchar(66) NOT NULL DEFAULT '',
+  `blob_gas_used` bigint unsigned NULL,
+  `excess_blob_gas` bigint unsigned NULL,
+  `parent_beacon_root` varchar(66) NOT NULL DEFAULT '',
+  PRIMARY KEY (`chain_id`, `number`)
+);
+CREATE INDEX IF NOT EXISTS `idx_block_hash` ON `block` (`chain_id`, `hash`);
+
+CREATE TABLE IF NOT EXISTS `tx` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `hash` varchar(66) NOT NULL,
+  `block_number` bigint NOT NULL,
+  `nonce` bigint unsigned NOT NULL,
+  `to` varchar(42) NOT NULL,
+  `from` varchar(42) NOT NULL,
+  `value` bigint NOT NULL,
+  `data` blob NOT NULL,
+  `type` tinyint unsigned NOT NULL DEFAULT 0,
+  `l1_fee` varchar(78) NOT NULL DEFAULT '',
+  `l1_gas_price` varchar(78) NOT NULL DEFAULT '',
+  `l1_gas_used` varchar(78) NOT NULL DEFAULT '',
+  PRIMARY KEY (`chain_id`, `hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `log` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `tx_hash` varchar(255) NOT NULL,
+  `index` int unsigned NOT NULL,
+  `data` blob NOT NULL,
+  `address` varchar(42) NOT NULL DEFAULT '',
+  `topics` varchar(300) NOT NULL DEFAULT '',
+  PRIMARY KEY (`chain_id`, `tx_hash`, `index`)
+);
+
+CREATE TABLE IF NOT EXISTS `latest_block_number` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `id` int unsigned NOT NULL DEFAULT 0,
+  `block_number` bigint NOT NULL,
+  PRIMARY KEY (`chain_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `abi` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `address` varchar(42) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `abi` text NOT NULL,
+  PRIMARY KEY (`chain_id`, `address`)
+);
+
+CREATE TABLE IF NOT EXISTS `withdrawal` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `index` bigint unsigned NOT NULL,
+  `block_number` bigint NOT NULL,
+  `validator_index` bigint unsigned NOT NULL,
+  `address` varchar(42) NOT NULL,
+  `amount` bigint unsigned NOT NULL,
+  PRIMARY KEY (`chain_id`, `index`)
+);
+CREATE INDEX IF NOT EXISTS `idx_withdrawal_block_number` ON `withdrawal` (`chain_id`, `block_number`);
+CREATE INDEX IF NOT EXISTS `idx_withdrawal_address` ON `withdrawal` (`chain_id`, `address`);
+
+CREATE TABLE IF NOT EXISTS `balance` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `address` varchar(42) NOT NULL,
+  `block_number` bigint NOT NULL,
+  `delta` varchar(80) NOT NULL DEFAULT '',
+  `balance` varchar(78) NOT NULL DEFAULT '',
+  `nonce` bigint unsigned NULL,
+  PRIMARY KEY (`chain_id`, `address`, `block_number`)
+);
+
+CREATE TABLE IF NOT EXISTS `pending_tx` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `hash` varchar(66) NOT NULL,
+  `from` varchar(42) NOT NULL,
+  `to` varchar(42) NOT NULL,
+  `value` varchar(78) NOT NULL,
+  `nonce` bigint unsigned NOT NULL,
+  `data` blob NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `first_seen` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`chain_id`, `hash`)
+);
+CREATE INDEX IF NOT EXISTS `idx_pending_tx_status_first_seen` ON `pending_tx` (`chain_id`, `status`, `first_seen`);
+
+CREATE TABLE IF NOT EXISTS `lease` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `name` varchar(64) NOT NULL,
+  `holder` varchar(255) NOT NULL DEFAULT '',
+  `expires_at` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`chain_id`, `name`)
+);
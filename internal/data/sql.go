@@ -0,0 +1,630 @@
+package data
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"gorm.io/gorm"
+)
+
+// SqlHandler reads and writes the rows of one chain, rows are saved with its
+// chain id and every query is filtered by it. The queries are shared by the
+// MySQL, PostgreSQL and SQLite databases.
+type SqlHandler struct {
+	gormClient *gorm.DB
+	chainId    int64
+	// nowMillis is the current time of the database in unix milliseconds,
+	// leases expire by the database clock and not by the clock of a replica
+	nowMillis string
+}
+
+// NewDatabaseHandler opens the database selected by DRIVER, mysql (default),
+// postgres or sqlite.
+func NewDatabaseHandler(databaseOpts *config.DatabaseOption, chainId int64) (DataHandler, error) {
+	switch databaseOpts.Driver {
+	case c.DriverSqlite:
+		return NewSqliteHandler(databaseOpts, chainId)
+	case c.DriverPostgres:
+		return NewPostgresHandler(databaseOpts, chainId)
+	}
+	return NewMysqlHandler(databaseOpts, chainId)
+}
+
+func (m *SqlHandler) UpdateLatestBlockNumber(ctx context.Context, blockNumber int64) error {
+	// the row of a new chain is created on its first update
+	err := m.gormClient.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"block_number"}),
+	}).
+		Table(c.LatestBlockNumber).
+		WithContext(ctx).
+		Create(&model.LatestBlockNumber{ChainId: m.chainId, BlockNumber: blockNumber}).Error
+
+	if err != nil {
+		return fmt.Errorf("SaveLatestBlockNumber : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	var blockNumber int64
+	err := m.gormClient.
+		Table(c.LatestBlockNumber).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Select("block_number").
+		Scan(&blockNumber).Error
+
+	if err != nil {
+		return 0, fmt.Errorf("GetLatestBlockNumber : %w", err)
+	}
+	return blockNumber, nil
+}
+
+func (m *SqlHandler) GetBlockRow(ctx context.Context, blockRow *model.BlockRow) error {
+	err := m.gormClient.
+		Table(c.Block).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where(blockRow).
+		First(blockRow).Error
+
+	if err != nil {
+		return fmt.Errorf("GetBlockRow : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) SaveBlockRows(ctx context.Context, blockRow []*model.BlockRow) error {
+	for _, row := range blockRow {
+		row.ChainId = m.chainId
+	}
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.Block).WithContext(ctx).Create(blockRow).Error
+	if err != nil {
+		return fmt.Errorf("SaveBlockRows : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) SaveTransactionRow(ctx context.Context, txRow []*model.TransactionRow) error {
+	for _, row := range txRow {
+		row.ChainId = m.chainId
+	}
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.Tx).WithContext(ctx).Create(txRow).Error
+	if err != nil {
+		return fmt.Errorf("SaveTransactionRow : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) SaveLogRow(ctx context.Context, logRow []*model.LogRow) error {
+	for _, row := range logRow {
+		row.ChainId = m.chainId
+	}
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.Log).WithContext(ctx).Create(logRow).Error
+	if err != nil {
+		return fmt.Errorf("SaveLogRow : %w", err)
+	}
+	return nil
+}
+
+// writeClause returns the conflict handling of mode for a table keyed by keys.
+// Unlike INSERT IGNORE, keeping the stored rows does not hide other errors
+// such as truncated values.
+func writeClause(mode string, keys ...string) clause.OnConflict {
+	if mode == c.WriteModeInsert {
+		return clause.OnConflict{DoNothing: true}
+	}
+	columns := make([]clause.Column, 0, len(keys))
+	for _, key := range keys {
+		columns = append(columns, clause.Column{Name: key})
+	}
+	return clause.OnConflict{Columns: columns, UpdateAll: true}
+}
+
+// SaveBlockData writes the rows of a block in one transaction. In replace
+// mode the rows stored for the block number are deleted first, the rows are
+// then upserted as a transaction moved by a reorganization keeps its hash.
+func (m *SqlHandler) SaveBlockData(ctx context.Context, mode string, blockData *model.BlockData) error {
+	rowMode := mode
+	switch mode {
+	case c.WriteModeInsert, c.WriteModeUpsert:
+	case c.WriteModeReplace:
+		rowMode = c.WriteModeUpsert
+	default:
+		return fmt.Errorf("SaveBlockData : unknown write mode %q", mode)
+	}
+
+	block := &blockData.Block
+	block.ChainId = m.chainId
+	txRows := make([]*model.TransactionRow, 0, len(blockData.Txs))
+	for i := range blockData.Txs {
+		blockData.Txs[i].ChainId = m.chainId
+		txRows = append(txRows, &blockData.Txs[i])
+	}
+	logRows := make([]*model.LogRow, 0, len(blockData.Logs))
+	for i := range blockData.Logs {
+		blockData.Logs[i].ChainId = m.chainId
+		logRows = append(logRows, &blockData.Logs[i])
+	}
+	withdrawalRows := make([]*model.WithdrawalRow, 0, len(blockData.Withdrawals))
+	for i := range blockData.Withdrawals {
+		blockData.Withdrawals[i].ChainId = m.chainId
+		withdrawalRows = append(withdrawalRows, &blockData.Withdrawals[i])
+	}
+	balanceRows := m.balanceRows(blockData.Balances)
+
+	err := m.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if mode == c.WriteModeReplace {
+			if err := m.deleteBlock(tx, block.Number); err != nil {
+				return err
+			}
+		}
+		err := tx.Clauses(writeClause(rowMode, "chain_id", "number")).
+			Table(c.Block).Create(block).Error
+		if err != nil {
+			return err
+		}
+		if len(txRows) != 0 {
+			err = tx.Clauses(writeClause(rowMode, "chain_id", "hash")).
+				Table(c.Tx).Create(txRows).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(logRows) != 0 {
+			err = tx.Clauses(writeClause(rowMode, "chain_id", "tx_hash", "index")).
+				Table(c.Log).Create(logRows).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(withdrawalRows) != 0 {
+			err = tx.Clauses(writeClause(rowMode, "chain_id", "index")).
+				Table(c.Withdrawal).Create(withdrawalRows).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(balanceRows) != 0 {
+			err = tx.Clauses(writeClause(rowMode, "chain_id", "address", "block_number")).
+				Table(c.Balance).Create(balanceRows).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("SaveBlockData : %w", err)
+	}
+	return nil
+}
+
+// SaveBlockBalances writes the balances of block number alone, for the blocks
+// whose other rows are not stored in the database. In replace mode the
+// balances stored for the block are deleted first.
+func (m *SqlHandler) SaveBlockBalances(ctx context.Context, mode string, number int64, balances []model.BalanceRow) error {
+	rowMode := mode
+	switch mode {
+	case c.WriteModeInsert, c.WriteModeUpsert:
+	case c.WriteModeReplace:
+		rowMode = c.WriteModeUpsert
+	default:
+		return fmt.Errorf("SaveBlockBalances : unknown write mode %q", mode)
+	}
+
+	balanceRows := m.balanceRows(balances)
+	err := m.gormClient.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if mode == c.WriteModeReplace {
+			err := tx.Table(c.Balance).
+				Where("chain_id = ? AND block_number = ?", m.chainId, number).
+				Delete(&model.BalanceRow{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(balanceRows) == 0 {
+			return nil
+		}
+		return tx.Clauses(writeClause(rowMode, "chain_id", "address", "block_number")).
+			Table(c.Balance).Create(balanceRows).Error
+	})
+	if err != nil {
+		return fmt.Errorf("SaveBlockBalances : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) balanceRows(balances []model.BalanceRow) []*model.BalanceRow {
+	balanceRows := make([]*model.BalanceRow, 0, len(balances))
+	for i := range balances {
+		balances[i].ChainId = m.chainId
+		balanceRows = append(balanceRows, &balances[i])
+	}
+	return balanceRows
+}
+
+// deleteBlock deletes the rows stored for block number, the logs are found
+// through the transactions of the block.
+func (m *SqlHandler) deleteBlock(tx *gorm.DB, number int64) error {
+	txHashes := tx.Table(c.Tx).Select("hash").
+		Where("chain_id = ? AND block_number = ?", m.chainId, number)
+	err := tx.Table(c.Log).
+		Where("chain_id = ? AND tx_hash IN (?)", m.chainId, txHashes).
+		Delete(&model.LogRow{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Table(c.Tx).
+		Where("chain_id = ? AND block_number = ?", m.chainId, number).
+		Delete(&model.TransactionRow{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Table(c.Withdrawal).
+		Where("chain_id = ? AND block_number = ?", m.chainId, number).
+		Delete(&model.WithdrawalRow{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Table(c.Balance).
+		Where("chain_id = ? AND block_number = ?", m.chainId, number).
+		Delete(&model.BalanceRow{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Table(c.Block).
+		Where("chain_id = ? AND number = ?", m.chainId, number).
+		Delete(&model.BlockRow{}).Error
+}
+
+func (m *SqlHandler) GetBlockRowByBlockNumbers(ctx context.Context, numbers []int64) ([]model.BlockRow, error) {
+	var blockRows []model.BlockRow
+	err := m.gormClient.
+		Table(c.Block).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("number IN ?", numbers).
+		Order("number DESC").
+		Find(&blockRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetBlockRowByBlockNumbers : %w", err)
+	}
+	return blockRows, nil
+}
+
+func (m *SqlHandler) GetLogRowByTxHash(ctx context.Context, txHash string) ([]model.LogRow, error) {
+	var logRows []model.LogRow
+	err := m.gormClient.
+		Table(c.Log).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("tx_hash = ?", txHash).
+		Find(&logRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetLogRowByTxHash : %w", err)
+	}
+	return logRows, nil
+}
+
+func (m *SqlHandler) GetTransactionRow(ctx context.Context, tx *model.TransactionRow) error {
+	err := m.gormClient.
+		Table(c.Tx).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where(tx).
+		First(tx).Error
+
+	if err != nil {
+		return fmt.Errorf("GetTransactionRow : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) GetTransactionRowByBlockNumber(ctx context.Context, blockNumber int64) ([]model.TransactionRow, error) {
+	var txRows []model.TransactionRow
+	err := m.gormClient.
+		Table(c.Tx).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("block_number = ?", blockNumber).
+		Find(&txRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetTransactionRowByBlockNumber : %w", err)
+	}
+	return txRows, nil
+}
+
+func (m *SqlHandler) SaveAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	abiRow.ChainId = m.chainId
+	err := m.gormClient.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain_id"}, {Name: "address"}},
+		UpdateAll: true,
+	}).
+		Table(c.Abi).WithContext(ctx).Create(abiRow).Error
+	if err != nil {
+		return fmt.Errorf("SaveAbiRow : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) GetAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	err := m.gormClient.
+		Table(c.Abi).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("address = ?", abiRow.Address).
+		First(abiRow).Error
+
+	if err != nil {
+		return fmt.Errorf("GetAbiRow : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) SaveWithdrawalRows(ctx context.Context, withdrawalRows []*model.WithdrawalRow) error {
+	for _, row := range withdrawalRows {
+		row.ChainId = m.chainId
+	}
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.Withdrawal).WithContext(ctx).Create(withdrawalRows).Error
+	if err != nil {
+		return fmt.Errorf("SaveWithdrawalRows : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) GetWithdrawalRowsByBlockNumber(ctx context.Context, blockNumber int64) ([]model.WithdrawalRow, error) {
+	var withdrawalRows []model.WithdrawalRow
+	err := m.gormClient.
+		Table(c.Withdrawal).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("block_number = ?", blockNumber).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "index"}}).
+		Find(&withdrawalRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetWithdrawalRowsByBlockNumber : %w", err)
+	}
+	return withdrawalRows, nil
+}
+
+func (m *SqlHandler) GetWithdrawalRowsByAddress(ctx context.Context, address string, limit int) ([]model.WithdrawalRow, error) {
+	var withdrawalRows []model.WithdrawalRow
+	err := m.gormClient.
+		Table(c.Withdrawal).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("address = ?", address).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "index"}, Desc: true}).
+		Limit(limit).
+		Find(&withdrawalRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetWithdrawalRowsByAddress : %w", err)
+	}
+	return withdrawalRows, nil
+}
+
+func (m *SqlHandler) SaveBalanceRows(ctx context.Context, balanceRows []*model.BalanceRow) error {
+	for _, row := range balanceRows {
+		row.ChainId = m.chainId
+	}
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.Balance).WithContext(ctx).Create(balanceRows).Error
+	if err != nil {
+		return fmt.Errorf("SaveBalanceRows : %w", err)
+	}
+	return nil
+}
+
+// GetBalanceRow returns the balance of the address at blockNumber, the latest
+// snapshot at or before blockNumber plus the deltas stored after it. Without
+// snapshot (derived mode) the balance is the sum of the deltas, which is only
+// complete once every block changing the balance of the address is indexed.
+func (m *SqlHandler) GetBalanceRow(ctx context.Context, address string, blockNumber int64) (model.BalanceRow, error) {
+	balanceRow := model.BalanceRow{ChainId: m.chainId, Address: address, BlockNumber: -1}
+	var snapshot model.BalanceRow
+	err := m.gormClient.
+		Table(c.Balance).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("address = ? AND block_number <= ? AND balance != ''", address, blockNumber).
+		Order("block_number DESC").
+		First(&snapshot).Error
+	balance := new(big.Int)
+	switch {
+	case err == nil:
+		if _, ok := balance.SetString(snapshot.Balance, 10); !ok {
+			return model.BalanceRow{}, fmt.Errorf("GetBalanceRow : invalid balance %s at block %d", snapshot.Balance, snapshot.BlockNumber)
+		}
+		balanceRow.BlockNumber = snapshot.BlockNumber
+		balanceRow.Nonce = snapshot.Nonce
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return model.BalanceRow{}, fmt.Errorf("GetBalanceRow : %w", err)
+	}
+
+	var deltaRows []model.BalanceRow
+	err = m.gormClient.
+		Table(c.Balance).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("address = ? AND block_number > ? AND block_number <= ? AND delta != ''", address, balanceRow.BlockNumber, blockNumber).
+		Order("block_number").
+		Find(&deltaRows).Error
+	if err != nil {
+		return model.BalanceRow{}, fmt.Errorf("GetBalanceRow : %w", err)
+	}
+	for _, deltaRow := range deltaRows {
+		delta, ok := new(big.Int).SetString(deltaRow.Delta, 10)
+		if !ok {
+			return model.BalanceRow{}, fmt.Errorf("GetBalanceRow : invalid delta %s at block %d", deltaRow.Delta, deltaRow.BlockNumber)
+		}
+		balance.Add(balance, delta)
+		balanceRow.BlockNumber = deltaRow.BlockNumber
+		if deltaRow.Nonce != nil {
+			balanceRow.Nonce = deltaRow.Nonce
+		}
+	}
+	if balanceRow.BlockNumber < 0 {
+		return model.BalanceRow{}, fmt.Errorf("GetBalanceRow : %w", gorm.ErrRecordNotFound)
+	}
+	balanceRow.Balance = balance.String()
+	return balanceRow, nil
+}
+
+func (m *SqlHandler) GetBalanceRowsByAddress(ctx context.Context, address string, beforeBlock int64, limit int) ([]model.BalanceRow, error) {
+	var balanceRows []model.BalanceRow
+	err := m.gormClient.
+		Table(c.Balance).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("address = ? AND block_number < ?", address, beforeBlock).
+		Order("block_number DESC").
+		Limit(limit).
+		Find(&balanceRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetBalanceRowsByAddress : %w", err)
+	}
+	return balanceRows, nil
+}
+
+func (m *SqlHandler) SavePendingTxRows(ctx context.Context, pendingTxRows []*model.PendingTxRow) error {
+	for _, row := range pendingTxRows {
+		row.ChainId = m.chainId
+	}
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.PendingTx).WithContext(ctx).Create(pendingTxRows).Error
+	if err != nil {
+		return fmt.Errorf("SavePendingTxRows : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) GetPendingTxRow(ctx context.Context, pendingTxRow *model.PendingTxRow) error {
+	err := m.gormClient.
+		Table(c.PendingTx).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("hash = ?", pendingTxRow.Hash).
+		First(pendingTxRow).Error
+
+	if err != nil {
+		return fmt.Errorf("GetPendingTxRow : %w", err)
+	}
+	return nil
+}
+
+// GetPendingTxRowsSeenBefore returns transactions still pending which were first seen before seenBefore.
+func (m *SqlHandler) GetPendingTxRowsSeenBefore(ctx context.Context, seenBefore time.Time, after *model.PendingTxRow, limit int) ([]model.PendingTxRow, error) {
+	var pendingTxRows []model.PendingTxRow
+	query := m.gormClient.
+		Table(c.PendingTx).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("status = ? AND first_seen < ?", c.TxStatusPending, seenBefore)
+	if after != nil {
+		query = query.Where("first_seen > ? OR (first_seen = ? AND hash > ?)", after.FirstSeen, after.FirstSeen, after.Hash)
+	}
+	err := query.
+		Order("first_seen, hash").
+		Limit(limit).
+		Find(&pendingTxRows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("GetPendingTxRowsSeenBefore : %w", err)
+	}
+	return pendingTxRows, nil
+}
+
+func (m *SqlHandler) UpdatePendingTxStatus(ctx context.Context, hashes []string, status string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	err := m.gormClient.
+		Table(c.PendingTx).
+		WithContext(ctx).
+		Where("chain_id = ?", m.chainId).
+		Where("hash IN ? AND status = ?", hashes, c.TxStatusPending).
+		Updates(map[string]interface{}{"status": status, "updated_at": time.Now()}).Error
+
+	if err != nil {
+		return fmt.Errorf("UpdatePendingTxStatus : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	// the row of a new lease is created expired, the update takes it
+	err := m.gormClient.Clauses(writeClause(c.WriteModeInsert)).
+		Table(c.Lease).
+		WithContext(ctx).
+		Create(&model.LeaseRow{ChainId: m.chainId, Name: name}).Error
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease : %w", err)
+	}
+
+	err = m.gormClient.
+		Table(c.Lease).
+		WithContext(ctx).
+		Where("chain_id = ? AND name = ?", m.chainId, name).
+		Where("holder = ? OR expires_at < "+m.nowMillis, holder).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": gorm.Expr(m.nowMillis+" + ?", ttl.Milliseconds())}).Error
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease : %w", err)
+	}
+
+	// the affected rows of MySQL don't count rows updated with their own
+	// values, the row tells who holds the lease
+	var held int64
+	err = m.gormClient.
+		Table(c.Lease).
+		WithContext(ctx).
+		Where("chain_id = ? AND name = ? AND holder = ?", m.chainId, name, holder).
+		Where("expires_at > " + m.nowMillis).
+		Count(&held).Error
+	if err != nil {
+		return false, fmt.Errorf("AcquireLease : %w", err)
+	}
+	return held == 1, nil
+}
+
+func (m *SqlHandler) ReleaseLease(ctx context.Context, name, holder string) error {
+	err := m.gormClient.
+		Table(c.Lease).
+		WithContext(ctx).
+		Where("chain_id = ? AND name = ? AND holder = ?", m.chainId, name, holder).
+		Update("expires_at", 0).Error
+	if err != nil {
+		return fmt.Errorf("ReleaseLease : %w", err)
+	}
+	return nil
+}
+
+func (m *SqlHandler) Ping(ctx context.Context) error {
+	db, err := m.gormClient.DB()
+	if err != nil {
+		return fmt.Errorf("Ping: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("Ping: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package data
+
+import (
+	"Ethereum_Service/config"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Ethereum_Service/pkg/utils/logger"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultJournalMode = "WAL"
+	// defaultSqliteConns is the connection pool of a WAL database when
+	// POOL_SIZE is not set, the readers do not wait for the writer
+	defaultSqliteConns = 4
+	sqliteBusyTimeout  = 5000
+	// sqliteBatchSize keeps the inserts of the consumers below the bound
+	// parameter limit of SQLite
+	sqliteBatchSize = 1000
+)
+
+// sqliteMigrations are the migrations of the SQLite schema, the MySQL
+// migrations do not run on SQLite.
+//
+//go:embed schema/sqlite/*.sql
+var sqliteMigrations embed.FS
+
+var (
+	sqliteMu  sync.Mutex
+	sqliteDBs = make(map[string]*gorm.DB)
+)
+
+// NewSqliteHandler opens the SQLite database at DBNAME. The handlers of every
+// chain share the connections to the file as SQLite has a single writer.
+func NewSqliteHandler(databaseOpts *config.DatabaseOption, chainId int64) (DataHandler, error) {
+	gormClient, err := openSqlite(databaseOpts)
+	if err != nil {
+		return nil, fmt.Errorf("NewSqliteHandler: %w", err)
+	}
+
+	return newTracedHandler("sqlite", &SqlHandler{
+		gormClient: gormClient,
+		chainId:    chainId,
+		nowMillis:  "CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)",
+	}), nil
+}
+
+func openSqlite(databaseOpts *config.DatabaseOption) (*gorm.DB, error) {
+	path := databaseOpts.DBName
+	sqliteMu.Lock()
+	defer sqliteMu.Unlock()
+	if db, ok := sqliteDBs[path]; ok {
+		return db, nil
+	}
+
+	journalMode := databaseOpts.JournalMode
+	if journalMode == "" {
+		journalMode = defaultJournalMode
+	}
+	db, err := gorm.Open(sqlite.Open(sqliteDSN(path, journalMode)), &gorm.Config{
+		SkipDefaultTransaction: true,
+		CreateBatchSize:        sqliteBatchSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("openSqlite: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("openSqlite: %w", err)
+	}
+	// without WAL the readers wait for the writer anyway, and every
+	// connection to an in-memory database opens a database of its own
+	conns := 1
+	if strings.EqualFold(journalMode, "WAL") && !isSqliteMemory(path) {
+		conns = defaultSqliteConns
+		if databaseOpts.PoolSize > 0 {
+			conns = databaseOpts.PoolSize
+		}
+	}
+	sqlDB.SetMaxOpenConns(conns)
+
+	if err := migrateSqlite(db); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("openSqlite: %w", err)
+	}
+	sqliteDBs[path] = db
+	return db, nil
+}
+
+// sqliteDSN sets the pragmas of every connection to the file. Transactions
+// take the write lock when they begin so that a reader upgrading to a writer
+// waits for the busy timeout instead of failing.
+func sqliteDSN(path, journalMode string) string {
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", journalMode))
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout))
+	// a WAL database stays consistent without syncing every commit
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+	if strings.Contains(path, "?") {
+		return path + "&" + params.Encode()
+	}
+	return path + "?" + params.Encode()
+}
+
+// isSqliteMemory tells whether DBNAME is an in-memory database, which lives
+// as long as its connection.
+func isSqliteMemory(path string) bool {
+	return path == ":memory:" || strings.HasPrefix(path, "file::memory:") || strings.Contains(path, "mode=memory")
+}
+
+// migrateSqlite applies the migrations newer than the version recorded in
+// schema_migrations, each in its own transaction. The table has the layout of
+// the MySQL migrations.
+func migrateSqlite(db *gorm.DB) error {
+	err := db.Exec("CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` bigint NOT NULL, `dirty` boolean NOT NULL)").Error
+	if err != nil {
+		return fmt.Errorf("migrateSqlite: %w", err)
+	}
+	var current int64
+	err = db.Table("schema_migrations").Select("COALESCE(MAX(version), 0)").Scan(&current).Error
+	if err != nil {
+		return fmt.Errorf("migrateSqlite: %w", err)
+	}
+
+	files, err := fs.Glob(sqliteMigrations, "schema/sqlite/*.up.sql")
+	if err != nil {
+		return fmt.Errorf("migrateSqlite: %w", err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		name := strings.TrimPrefix(file, "schema/sqlite/")
+		version, err := strconv.ParseInt(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrateSqlite: invalid migration %s", name)
+		}
+		if version <= current {
+			continue
+		}
+		migration, err := sqliteMigrations.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("migrateSqlite: %w", err)
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(string(migration)).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM `schema_migrations`").Error; err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO `schema_migrations` (`version`, `dirty`) VALUES (?, false)", version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migrateSqlite: %s: %w", name, err)
+		}
+		logger.Info("migrateSqlite: applied " + name)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package data
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSqliteHandler(t *testing.T) {
+	opts := &config.DatabaseOption{DBName: filepath.Join(t.TempDir(), "indexer.db")}
+	ctx := context.Background()
+	mainnet, err := NewSqliteHandler(opts, 1)
+	assert.NoError(t, err)
+	sepolia, err := NewSqliteHandler(opts, 11155111)
+	assert.NoError(t, err)
+
+	block := &model.BlockRow{Hash: "0x01", Number: 10, Extra: []byte{}}
+	assert.NoError(t, mainnet.SaveBlockRows(ctx, []*model.BlockRow{block}))
+	// saving a block twice keeps the first row
+	assert.NoError(t, mainnet.SaveBlockRows(ctx, []*model.BlockRow{{Hash: "0x02", Number: 10, Extra: []byte{}}}))
+
+	got := &model.BlockRow{Number: 10}
+	assert.NoError(t, mainnet.GetBlockRow(ctx, got))
+	assert.Equal(t, "0x01", got.Hash)
+	assert.Error(t, sepolia.GetBlockRow(ctx, &model.BlockRow{Number: 10}))
+
+	assert.NoError(t, mainnet.UpdateLatestBlockNumber(ctx, 10))
+	assert.NoError(t, mainnet.UpdateLatestBlockNumber(ctx, 11))
+	latest, err := mainnet.GetLatestBlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), latest)
+	latest, err = sepolia.GetLatestBlockNumber(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), latest)
+}
+
+func TestSqliteLease(t *testing.T) {
+	opts := &config.DatabaseOption{DBName: filepath.Join(t.TempDir(), "indexer.db")}
+	ctx := context.Background()
+	handler, err := NewSqliteHandler(opts, 1)
+	assert.NoError(t, err)
+
+	ok, err := handler.AcquireLease(ctx, "producer", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// the holder renews, the other replica waits
+	ok, err = handler.AcquireLease(ctx, "producer", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = handler.AcquireLease(ctx, "producer", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, handler.ReleaseLease(ctx, "producer", "a"))
+	ok, err = handler.AcquireLease(ctx, "producer", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// the lease expires by the clock of the database
+	ok, err = handler.AcquireLease(ctx, "sweep", "a", 20*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	time.Sleep(50 * time.Millisecond)
+	ok, err = handler.AcquireLease(ctx, "sweep", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestSqliteSaveBlockData(t *testing.T) {
+	opts := &config.DatabaseOption{DBName: filepath.Join(t.TempDir(), "indexer.db")}
+	ctx := context.Background()
+	handler, err := NewSqliteHandler(opts, 1)
+	assert.NoError(t, err)
+
+	blockData := func(hash string, txs ...string) *model.BlockData {
+		data := &model.BlockData{Block: model.BlockRow{Hash: hash, Number: 10, Extra: []byte{}}}
+		for _, tx := range txs {
+			data.Txs = append(data.Txs, model.TransactionRow{Hash: tx, BlockNumber: 10, Data: []byte{}})
+			data.Logs = append(data.Logs, model.LogRow{TxHash: tx, Data: []byte{}})
+			data.Balances = append(data.Balances, model.BalanceRow{Address: tx, BlockNumber: 10, Delta: hash})
+		}
+		return data
+	}
+	balances := func(address string) []model.BalanceRow {
+		balanceRows, err := handler.GetBalanceRowsByAddress(ctx, address, 11, 10)
+		assert.NoError(t, err)
+		return balanceRows
+	}
+	blockHash := func() string {
+		got := &model.BlockRow{Number: 10}
+		assert.NoError(t, handler.GetBlockRow(ctx, got))
+		return got.Hash
+	}
+
+	assert.NoError(t, handler.SaveBlockData(ctx, c.WriteModeInsert, blockData("0x01", "0xa", "0xb")))
+	assert.NoError(t, handler.SaveBlockData(ctx, c.WriteModeInsert, blockData("0x02", "0xa")))
+	assert.Equal(t, "0x01", blockHash())
+
+	assert.NoError(t, handler.SaveBlockData(ctx, c.WriteModeUpsert, blockData("0x02", "0xa")))
+	assert.Equal(t, "0x02", blockHash())
+	txs, err := handler.GetTransactionRowByBlockNumber(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+
+	// the transaction left out of the replaced block is deleted with its logs
+	assert.NoError(t, handler.SaveBlockData(ctx, c.WriteModeReplace, blockData("0x03", "0xa")))
+	assert.Equal(t, "0x03", blockHash())
+	txs, err = handler.GetTransactionRowByBlockNumber(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+	logs, err := handler.GetLogRowByTxHash(ctx, "0xb")
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+	// so are the balances of the addresses the block no longer touches
+	assert.Empty(t, balances("0xb"))
+	if assert.Len(t, balances("0xa"), 1) {
+		assert.Equal(t, "0x03", balances("0xa")[0].Delta)
+	}
+
+	// the balances of a block stored elsewhere are replaced alone
+	replaced := blockData("0x04", "0xb").Balances
+	assert.NoError(t, handler.SaveBlockBalances(ctx, c.WriteModeReplace, 10, replaced))
+	assert.Empty(t, balances("0xa"))
+	assert.Len(t, balances("0xb"), 1)
+	assert.Equal(t, "0x03", blockHash())
+
+	assert.Error(t, handler.SaveBlockData(ctx, "merge", blockData("0x04")))
+	assert.Error(t, handler.SaveBlockBalances(ctx, "merge", 10, nil))
+}
+
+func TestSqliteMigration(t *testing.T) {
+	opts := &config.DatabaseOption{DBName: filepath.Join(t.TempDir(), "indexer.db")}
+	ctx := context.Background()
+	handler, err := NewSqliteHandler(opts, 1)
+	assert.NoError(t, err)
+	db, err := openSqlite(opts)
+	assert.NoError(t, err)
+
+	var journalMode string
+	assert.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+	assert.Equal(t, "wal", journalMode)
+	var version int64
+	assert.NoError(t, db.Table("schema_migrations").Select("version").Scan(&version).Error)
+	assert.Equal(t, int64(1), version)
+	assert.NoError(t, migrateSqlite(db))
+
+	// a batch with more bound parameters than SQLite accepts in one statement
+	blocks := make([]*model.BlockRow, 0, 2*sqliteBatchSize)
+	for i := 0; i < 2*sqliteBatchSize; i++ {
+		blocks = append(blocks, &model.BlockRow{Hash: fmt.Sprint(i), Number: int64(i), Extra: []byte{}})
+	}
+	assert.NoError(t, handler.SaveBlockRows(ctx, blocks))
+	got, err := handler.GetBlockRowByBlockNumbers(ctx, []int64{0, 2*sqliteBatchSize - 1})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+}
+
+func TestSqliteMemory(t *testing.T) {
+	ctx := context.Background()
+	for _, path := range []string{":memory:", "file::memory:?cache=private"} {
+		path := path
+		t.Run(path, func(t *testing.T) {
+			defer func() {
+				sqliteMu.Lock()
+				delete(sqliteDBs, path)
+				sqliteMu.Unlock()
+			}()
+			opts := &config.DatabaseOption{DBName: path, PoolSize: 4}
+			handler, err := NewSqliteHandler(opts, 1)
+			assert.NoError(t, err)
+			db, err := openSqlite(opts)
+			assert.NoError(t, err)
+			sqlDB, err := db.DB()
+			assert.NoError(t, err)
+			defer sqlDB.Close()
+			assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+
+			// the readers running at once see the schema and the rows written
+			// through the single connection
+			assert.NoError(t, handler.SaveBlockRows(ctx, []*model.BlockRow{{Hash: "0x01", Number: 10, Extra: []byte{}}}))
+			var readers sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				readers.Add(1)
+				go func() {
+					defer readers.Done()
+					got := &model.BlockRow{Number: 10}
+					assert.NoError(t, handler.GetBlockRow(ctx, got))
+					assert.Equal(t, "0x01", got.Hash)
+				}()
+			}
+			readers.Wait()
+		})
+	}
+}
+
+func TestSqliteBalance(t *testing.T) {
+	opts := &config.DatabaseOption{DBName: filepath.Join(t.TempDir(), "indexer.db")}
+	ctx := context.Background()
+	handler, err := NewSqliteHandler(opts, 1)
+	assert.NoError(t, err)
+	nonce := uint64(3)
+
+	// derived mode stores the deltas only
+	assert.NoError(t, handler.SaveBalanceRows(ctx, []*model.BalanceRow{
+		{Address: "0xA", BlockNumber: 10, Delta: "1000000000000000000000"},
+		{Address: "0xA", BlockNumber: 12, Delta: "-250", Nonce: &nonce},
+		{Address: "0xA", BlockNumber: 15, Delta: "50"},
+		{Address: "0xB", BlockNumber: 12, Delta: "250"},
+	}))
+	balanceRow, err := handler.GetBalanceRow(ctx, "0xA", 14)
+	assert.NoError(t, err)
+	assert.Equal(t, "999999999999999999750", balanceRow.Balance)
+	assert.Equal(t, int64(12), balanceRow.BlockNumber)
+	assert.Equal(t, &nonce, balanceRow.Nonce)
+	balanceRow, err = handler.GetBalanceRow(ctx, "0xA", math.MaxInt64)
+	assert.NoError(t, err)
+	assert.Equal(t, "999999999999999999800", balanceRow.Balance)
+	assert.Equal(t, int64(15), balanceRow.BlockNumber)
+	_, err = handler.GetBalanceRow(ctx, "0xA", 9)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// a replayed batch keeps the stored rows
+	assert.NoError(t, handler.SaveBalanceRows(ctx, []*model.BalanceRow{
+		{Address: "0xA", BlockNumber: 15, Delta: "70"},
+	}))
+	balanceRow, err = handler.GetBalanceRow(ctx, "0xA", math.MaxInt64)
+	assert.NoError(t, err)
+	assert.Equal(t, "999999999999999999800", balanceRow.Balance)
+
+	// rpc mode stores snapshots, deltas after the latest one are added
+	assert.NoError(t, handler.SaveBalanceRows(ctx, []*model.BalanceRow{
+		{Address: "0xC", BlockNumber: 20, Balance: "700", Nonce: &nonce},
+		{Address: "0xC", BlockNumber: 22, Delta: "-100"},
+	}))
+	balanceRow, err = handler.GetBalanceRow(ctx, "0xC", 21)
+	assert.NoError(t, err)
+	assert.Equal(t, "700", balanceRow.Balance)
+	balanceRow, err = handler.GetBalanceRow(ctx, "0xC", 30)
+	assert.NoError(t, err)
+	assert.Equal(t, "600", balanceRow.Balance)
+	assert.Equal(t, int64(22), balanceRow.BlockNumber)
+}
+
+func TestSqlitePendingTxPaging(t *testing.T) {
+	opts := &config.DatabaseOption{DBName: filepath.Join(t.TempDir(), "indexer.db")}
+	ctx := context.Background()
+	handler, err := NewSqliteHandler(opts, 1)
+	assert.NoError(t, err)
+
+	seen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pendingTxRows := []*model.PendingTxRow{
+		{Hash: "0x03", Status: c.TxStatusPending, FirstSeen: seen, Data: []byte{}},
+		{Hash: "0x01", Status: c.TxStatusPending, FirstSeen: seen, Data: []byte{}},
+		{Hash: "0x02", Status: c.TxStatusPending, FirstSeen: seen.Add(-time.Minute), Data: []byte{}},
+		{Hash: "0x04", Status: c.TxStatusPending, FirstSeen: seen.Add(time.Minute), Data: []byte{}},
+		{Hash: "0x05", Status: c.TxStatusPending, FirstSeen: seen.Add(time.Hour), Data: []byte{}},
+	}
+	assert.NoError(t, handler.SavePendingTxRows(ctx, pendingTxRows))
+
+	// rows sharing first_seen are split across pages by hash
+	hashes := make([]string, 0)
+	var after *model.PendingTxRow
+	for {
+		rows, err := handler.GetPendingTxRowsSeenBefore(ctx, seen.Add(time.Hour), after, 2)
+		assert.NoError(t, err)
+		for _, row := range rows {
+			hashes = append(hashes, row.Hash)
+		}
+		if len(rows) < 2 {
+			break
+		}
+		after = &rows[len(rows)-1]
+	}
+	assert.Equal(t, []string{"0x02", "0x01", "0x03", "0x04"}, hashes)
+}
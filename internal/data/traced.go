@@ -0,0 +1,197 @@
+package data
+
+import (
+	"Ethereum_Service/internal/tracing"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedHandler records a span for every call of the wrapped DataHandler.
+type tracedHandler struct {
+	system string
+	next   DataHandler
+}
+
+func newTracedHandler(system string, next DataHandler) DataHandler {
+	return &tracedHandler{system: system, next: next}
+}
+
+func (h *tracedHandler) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, semconv.DBSystemKey.String(h.system), semconv.DBOperation(operation))
+	return tracing.Start(ctx, h.system+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+func rows(n int) attribute.KeyValue {
+	return attribute.Int("db.rows", n)
+}
+
+func (h *tracedHandler) SaveBlockRows(ctx context.Context, blockRow []*model.BlockRow) (err error) {
+	ctx, span := h.start(ctx, "SaveBlockRows", rows(len(blockRow)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveBlockRows(ctx, blockRow)
+}
+
+func (h *tracedHandler) SaveTransactionRow(ctx context.Context, txRow []*model.TransactionRow) (err error) {
+	ctx, span := h.start(ctx, "SaveTransactionRow", rows(len(txRow)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveTransactionRow(ctx, txRow)
+}
+
+func (h *tracedHandler) SaveLogRow(ctx context.Context, logRow []*model.LogRow) (err error) {
+	ctx, span := h.start(ctx, "SaveLogRow", rows(len(logRow)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveLogRow(ctx, logRow)
+}
+
+func (h *tracedHandler) SaveBlockData(ctx context.Context, mode string, blockData *model.BlockData) (err error) {
+	ctx, span := h.start(ctx, "SaveBlockData", rows(1+len(blockData.Txs)+len(blockData.Logs)+len(blockData.Withdrawals)+len(blockData.Balances)),
+		attribute.String("db.write_mode", mode))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveBlockData(ctx, mode, blockData)
+}
+
+func (h *tracedHandler) SaveBlockBalances(ctx context.Context, mode string, number int64, balances []model.BalanceRow) (err error) {
+	ctx, span := h.start(ctx, "SaveBlockBalances", rows(len(balances)),
+		attribute.String("db.write_mode", mode))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveBlockBalances(ctx, mode, number, balances)
+}
+
+func (h *tracedHandler) GetTransactionRow(ctx context.Context, tx *model.TransactionRow) (err error) {
+	ctx, span := h.start(ctx, "GetTransactionRow")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetTransactionRow(ctx, tx)
+}
+
+func (h *tracedHandler) GetLogRowByTxHash(ctx context.Context, txHash string) (_ []model.LogRow, err error) {
+	ctx, span := h.start(ctx, "GetLogRowByTxHash")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetLogRowByTxHash(ctx, txHash)
+}
+
+func (h *tracedHandler) GetBlockRow(ctx context.Context, blockRow *model.BlockRow) (err error) {
+	ctx, span := h.start(ctx, "GetBlockRow")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetBlockRow(ctx, blockRow)
+}
+
+func (h *tracedHandler) GetTransactionRowByBlockNumber(ctx context.Context, blockNumber int64) (_ []model.TransactionRow, err error) {
+	ctx, span := h.start(ctx, "GetTransactionRowByBlockNumber", attribute.Int64("block.number", blockNumber))
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetTransactionRowByBlockNumber(ctx, blockNumber)
+}
+
+func (h *tracedHandler) GetBlockRowByBlockNumbers(ctx context.Context, numbers []int64) (_ []model.BlockRow, err error) {
+	ctx, span := h.start(ctx, "GetBlockRowByBlockNumbers", rows(len(numbers)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetBlockRowByBlockNumbers(ctx, numbers)
+}
+
+func (h *tracedHandler) SaveWithdrawalRows(ctx context.Context, withdrawalRows []*model.WithdrawalRow) (err error) {
+	ctx, span := h.start(ctx, "SaveWithdrawalRows", rows(len(withdrawalRows)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveWithdrawalRows(ctx, withdrawalRows)
+}
+
+func (h *tracedHandler) GetWithdrawalRowsByBlockNumber(ctx context.Context, blockNumber int64) (_ []model.WithdrawalRow, err error) {
+	ctx, span := h.start(ctx, "GetWithdrawalRowsByBlockNumber", attribute.Int64("block.number", blockNumber))
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetWithdrawalRowsByBlockNumber(ctx, blockNumber)
+}
+
+func (h *tracedHandler) GetWithdrawalRowsByAddress(ctx context.Context, address string, limit int) (_ []model.WithdrawalRow, err error) {
+	ctx, span := h.start(ctx, "GetWithdrawalRowsByAddress")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetWithdrawalRowsByAddress(ctx, address, limit)
+}
+
+func (h *tracedHandler) SaveBalanceRows(ctx context.Context, balanceRows []*model.BalanceRow) (err error) {
+	ctx, span := h.start(ctx, "SaveBalanceRows", rows(len(balanceRows)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveBalanceRows(ctx, balanceRows)
+}
+
+func (h *tracedHandler) GetBalanceRow(ctx context.Context, address string, blockNumber int64) (_ model.BalanceRow, err error) {
+	ctx, span := h.start(ctx, "GetBalanceRow", attribute.Int64("block.number", blockNumber))
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetBalanceRow(ctx, address, blockNumber)
+}
+
+func (h *tracedHandler) GetBalanceRowsByAddress(ctx context.Context, address string, beforeBlock int64, limit int) (_ []model.BalanceRow, err error) {
+	ctx, span := h.start(ctx, "GetBalanceRowsByAddress")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetBalanceRowsByAddress(ctx, address, beforeBlock, limit)
+}
+
+func (h *tracedHandler) SavePendingTxRows(ctx context.Context, pendingTxRows []*model.PendingTxRow) (err error) {
+	ctx, span := h.start(ctx, "SavePendingTxRows", rows(len(pendingTxRows)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.SavePendingTxRows(ctx, pendingTxRows)
+}
+
+func (h *tracedHandler) GetPendingTxRow(ctx context.Context, pendingTxRow *model.PendingTxRow) (err error) {
+	ctx, span := h.start(ctx, "GetPendingTxRow")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetPendingTxRow(ctx, pendingTxRow)
+}
+
+func (h *tracedHandler) GetPendingTxRowsSeenBefore(ctx context.Context, seenBefore time.Time, after *model.PendingTxRow, limit int) (_ []model.PendingTxRow, err error) {
+	ctx, span := h.start(ctx, "GetPendingTxRowsSeenBefore")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetPendingTxRowsSeenBefore(ctx, seenBefore, after, limit)
+}
+
+func (h *tracedHandler) UpdatePendingTxStatus(ctx context.Context, hashes []string, status string) (err error) {
+	ctx, span := h.start(ctx, "UpdatePendingTxStatus", rows(len(hashes)))
+	defer func() { tracing.End(span, err) }()
+	return h.next.UpdatePendingTxStatus(ctx, hashes, status)
+}
+
+func (h *tracedHandler) UpdateLatestBlockNumber(ctx context.Context, blockNumber int64) (err error) {
+	ctx, span := h.start(ctx, "UpdateLatestBlockNumber", attribute.Int64("block.number", blockNumber))
+	defer func() { tracing.End(span, err) }()
+	return h.next.UpdateLatestBlockNumber(ctx, blockNumber)
+}
+
+func (h *tracedHandler) GetLatestBlockNumber(ctx context.Context) (_ int64, err error) {
+	ctx, span := h.start(ctx, "GetLatestBlockNumber")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetLatestBlockNumber(ctx)
+}
+
+func (h *tracedHandler) SaveAbiRow(ctx context.Context, abiRow *model.AbiRow) (err error) {
+	ctx, span := h.start(ctx, "SaveAbiRow")
+	defer func() { tracing.End(span, err) }()
+	return h.next.SaveAbiRow(ctx, abiRow)
+}
+
+func (h *tracedHandler) GetAbiRow(ctx context.Context, abiRow *model.AbiRow) (err error) {
+	ctx, span := h.start(ctx, "GetAbiRow")
+	defer func() { tracing.End(span, err) }()
+	return h.next.GetAbiRow(ctx, abiRow)
+}
+
+func (h *tracedHandler) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (_ bool, err error) {
+	ctx, span := h.start(ctx, "AcquireLease", attribute.String("lease.name", name))
+	defer func() { tracing.End(span, err) }()
+	return h.next.AcquireLease(ctx, name, holder, ttl)
+}
+
+func (h *tracedHandler) ReleaseLease(ctx context.Context, name, holder string) (err error) {
+	ctx, span := h.start(ctx, "ReleaseLease", attribute.String("lease.name", name))
+	defer func() { tracing.End(span, err) }()
+	return h.next.ReleaseLease(ctx, name, holder)
+}
+
+func (h *tracedHandler) Ping(ctx context.Context) (err error) {
+	ctx, span := h.start(ctx, "Ping")
+	defer func() { tracing.End(span, err) }()
+	return h.next.Ping(ctx)
+}
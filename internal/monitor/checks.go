@@ -0,0 +1,30 @@
+package monitor
+
+import (
+	"Ethereum_Service/internal/mq"
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// QueueCheck fails when the queue returned by queue is missing or not
+// reachable, queue is called on every check as services connect in the
+// background.
+func QueueCheck(queue func() mq.Queue) Check {
+	return func(ctx context.Context) error {
+		q := queue()
+		if q == nil {
+			return errors.New("not connected")
+		}
+		return q.Check(ctx)
+	}
+}
+
+// RPCCheck fails when the endpoint of ethClient does not return its head.
+func RPCCheck(ethClient *ethclient.Client) Check {
+	return func(ctx context.Context) error {
+		_, err := ethClient.BlockNumber(ctx)
+		return err
+	}
+}
@@ -0,0 +1,142 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Version is the build version reported by /status, set with
+// -ldflags "-X Ethereum_Service/internal/monitor.Version=<version>".
+var Version = "dev"
+
+const checkTimeout = 3 * time.Second
+
+// Check reports whether a dependency of the service is reachable.
+type Check func(ctx context.Context) error
+
+// ChainStatus is the progress of one chain in /status, fields not tracked by
+// a service are left zero.
+type ChainStatus struct {
+	Chain     string `json:"chain"`
+	Head      int64  `json:"head"`
+	Watermark int64  `json:"watermark"`
+	InFlight  int64  `json:"in_flight"`
+	Workers   int    `json:"workers"`
+}
+
+type Status struct {
+	Service       string        `json:"service"`
+	Version       string        `json:"version"`
+	UptimeSeconds int64         `json:"uptime_seconds"`
+	Chains        []ChainStatus `json:"chains"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Health serves the liveness, readiness and status endpoints of a service.
+type Health struct {
+	service string
+	started time.Time
+
+	mu       sync.RWMutex
+	checks   []namedCheck
+	statuses []func(ctx context.Context) ChainStatus
+}
+
+func NewHealth(service string) *Health {
+	return &Health{
+		service: service,
+		started: time.Now(),
+	}
+}
+
+// AddCheck adds a dependency that has to be reachable for /readyz.
+func (h *Health) AddCheck(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// AddStatus adds the progress of a chain to /status.
+func (h *Health) AddStatus(status func(ctx context.Context) ChainStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.statuses = append(h.statuses, status)
+}
+
+// ServeHealthz answers as long as the process is able to serve requests.
+func (h *Health) ServeHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ServeReadyz runs all checks concurrently and fails when any of them fails.
+func (h *Health) ServeReadyz(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	results := make(map[string]string, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			result := "ok"
+			if err := c.check(ctx); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if result != "ok" {
+				ready = false
+			}
+			results[c.name] = result
+		}(c)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	status := "ok"
+	if !ready {
+		code = http.StatusServiceUnavailable
+		status = "unavailable"
+	}
+	writeJSON(w, code, map[string]interface{}{"status": status, "checks": results})
+}
+
+func (h *Health) ServeStatus(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	statuses := h.statuses
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	resp := Status{
+		Service:       h.service,
+		Version:       Version,
+		UptimeSeconds: int64(time.Since(h.started).Seconds()),
+		Chains:        make([]ChainStatus, 0, len(statuses)),
+	}
+	for _, status := range statuses {
+		resp.Chains = append(resp.Chains, status(ctx))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
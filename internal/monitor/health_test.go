@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthReadyz(t *testing.T) {
+	health := NewHealth("indexer")
+	health.AddCheck("default/mysql", func(ctx context.Context) error { return nil })
+
+	rec := httptest.NewRecorder()
+	health.ServeReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	health.AddCheck("default/rpc", func(ctx context.Context) error { return errors.New("connection refused") })
+	rec = httptest.NewRecorder()
+	health.ServeReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "unavailable", body.Status)
+	assert.Equal(t, "ok", body.Checks["default/mysql"])
+	assert.Equal(t, "connection refused", body.Checks["default/rpc"])
+}
+
+func TestHealthStatus(t *testing.T) {
+	health := NewHealth("producer")
+	health.AddStatus(func(ctx context.Context) ChainStatus {
+		return ChainStatus{Chain: "default", Head: 120, Watermark: 100, InFlight: 20}
+	})
+
+	rec := httptest.NewRecorder()
+	health.ServeStatus(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var status Status
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, "producer", status.Service)
+	assert.Equal(t, Version, status.Version)
+	assert.Equal(t, []ChainStatus{{Chain: "default", Head: 120, Watermark: 100, InFlight: 20}}, status.Chains)
+}
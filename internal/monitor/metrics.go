@@ -0,0 +1,156 @@
+package monitor
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "eth_service"
+
+var (
+	ChainHead = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_head_block",
+		Help:      "Latest block number reported by the RPC endpoint.",
+	}, []string{"chain"})
+	Watermark = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_watermark_block",
+		Help:      "Latest block number acknowledged by the indexer and stored as watermark.",
+	}, []string{"chain"})
+	IndexerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexer_lag_blocks",
+		Help:      "Blocks between the chain head and the watermark.",
+	}, []string{"chain"})
+	EnqueuedHead = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "enqueued_head_block",
+		Help:      "Highest block number the broker confirmed, every block below it is enqueued.",
+	}, []string{"chain"})
+	IndexedHead = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexed_head_block",
+		Help:      "Highest block number indexed by this indexer.",
+	}, []string{"chain"})
+	ProducerLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "producer_leader",
+		Help:      "1 while this producer holds the lease of the chain and publishes its jobs.",
+	}, []string{"chain"})
+
+	IndexedBlocks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "indexed_blocks_total",
+		Help:      "Blocks indexed.",
+	}, []string{"chain"})
+	IndexedTxs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "indexed_txs_total",
+		Help:      "Transactions indexed.",
+	}, []string{"chain"})
+	IndexedLogs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "indexed_logs_total",
+		Help:      "Logs indexed.",
+	}, []string{"chain"})
+
+	IndexerWorkers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "indexer_workers",
+		Help:      "Workers consuming the jobs of a chain per lane (head or backfill).",
+	}, []string{"chain", "lane"})
+
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Latency of RPC calls per method and endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "endpoint"})
+	rpcErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Failed RPC calls per method and endpoint.",
+	}, []string{"method", "endpoint"})
+
+	ConsumerBuffer = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_buffer_rows",
+		Help:      "Rows buffered by a consumer and not flushed yet.",
+	}, []string{"chain", "consumer"})
+	ConsumerFlushDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "consumer_flush_duration_seconds",
+		Help:      "Latency of consumer flushes to the database.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"chain", "consumer"})
+	ConsumerFlushErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "consumer_flush_errors_total",
+		Help:      "Failed consumer flushes.",
+	}, []string{"chain", "consumer"})
+
+	QueuePublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_published_total",
+		Help:      "Messages published per queue.",
+	}, []string{"queue"})
+	QueueRepublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_republished_total",
+		Help:      "Messages published again after the broker did not confirm them.",
+	}, []string{"queue"})
+	QueueConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_consumed_total",
+		Help:      "Messages consumed per queue.",
+	}, []string{"queue"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of API requests per route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	redisLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_cache_lookups_total",
+		Help:      "Redis cache lookups per row kind and result (hit or miss).",
+	}, []string{"kind", "result"})
+)
+
+// ObserveRPC records the latency and the result of an RPC call.
+func ObserveRPC(method, endpoint string, start time.Time, err error) {
+	endpoint = EndpointLabel(endpoint)
+	rpcDuration.WithLabelValues(method, endpoint).Observe(time.Since(start).Seconds())
+	if err != nil {
+		rpcErrors.WithLabelValues(method, endpoint).Inc()
+	}
+}
+
+// EndpointLabel keeps the host of an endpoint only, endpoint URLs often carry
+// API keys in their path or query.
+func EndpointLabel(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
+
+// ObserveRedisLookup counts a cache hit, or a miss when the key doesn't exist.
+// Other errors are not counted.
+func ObserveRedisLookup(kind string, err error) {
+	switch {
+	case err == nil:
+		redisLookups.WithLabelValues(kind, "hit").Inc()
+	case errors.Is(err, redis.Nil):
+		redisLookups.WithLabelValues(kind, "miss").Inc()
+	}
+}
@@ -0,0 +1,14 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointLabel(t *testing.T) {
+	assert.Equal(t, "eth-mainnet.g.alchemy.com", EndpointLabel("https://eth-mainnet.g.alchemy.com/v2/secret-key"))
+	assert.Equal(t, "localhost:8545", EndpointLabel("http://localhost:8545"))
+	assert.Equal(t, "node:8546", EndpointLabel("wss://node:8546/?apikey=secret"))
+	assert.Equal(t, "unknown", EndpointLabel("not an url"))
+}
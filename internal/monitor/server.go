@@ -0,0 +1,43 @@
+package monitor
+
+import (
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server serves the monitoring endpoints of the binaries without an API server.
+type Server struct {
+	srv *http.Server
+}
+
+func NewServer(addr string, health *Health) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", health.ServeHealthz)
+	mux.HandleFunc("/readyz", health.ServeReadyz)
+	mux.HandleFunc("/status", health.ServeStatus)
+	return &Server{
+		srv: &http.Server{Addr: addr, Handler: mux},
+	}
+}
+
+func (s *Server) Start() {
+	go func() {
+		logger.GetLogger().Sugar().Infof("monitor server listening on %s", s.srv.Addr)
+		err := s.srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.GetLogger().Sugar().Errorf("monitor server : %s", err.Error())
+		}
+	}()
+}
+
+func (s *Server) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.srv.Shutdown(ctx)
+}
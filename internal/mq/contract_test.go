@@ -0,0 +1,135 @@
+package mq
+
+import (
+	"Ethereum_Service/config"
+	"context"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const contractTimeout = 30 * time.Second
+
+// TestQueueContract runs the same scenario against every backend, the
+// brokers are only tested when their address is set:
+//
+//	MQ_TEST_KAFKA_BROKERS=localhost:9092 MQ_TEST_NATS_URL=nats://localhost:4222 \
+//	MQ_TEST_REDIS_ADDR=localhost:6379 go test ./internal/mq -run TestQueueContract
+func TestQueueContract(t *testing.T) {
+	backends := map[string]func(t *testing.T) Queue{
+		BackendMemory: func(t *testing.T) Queue {
+			return NewMemory()
+		},
+		BackendKafka: func(t *testing.T) Queue {
+			brokers := os.Getenv("MQ_TEST_KAFKA_BROKERS")
+			if brokers == "" {
+				t.Skip("MQ_TEST_KAFKA_BROKERS is not set")
+			}
+			q, err := NewKafka(strings.Split(brokers, ","))
+			assert.NoError(t, err)
+			return q
+		},
+		BackendNATS: func(t *testing.T) Queue {
+			url := os.Getenv("MQ_TEST_NATS_URL")
+			if url == "" {
+				t.Skip("MQ_TEST_NATS_URL is not set")
+			}
+			q, err := DialNATS(url)
+			assert.NoError(t, err)
+			return q
+		},
+		BackendRedis: func(t *testing.T) Queue {
+			addr := os.Getenv("MQ_TEST_REDIS_ADDR")
+			if addr == "" {
+				t.Skip("MQ_TEST_REDIS_ADDR is not set")
+			}
+			host, port, err := net.SplitHostPort(addr)
+			assert.NoError(t, err)
+			q, err := NewRedis(config.RedisOption{Host: host, Port: port})
+			assert.NoError(t, err)
+			return q
+		},
+	}
+	for name, open := range backends {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			q := open(t)
+			defer q.Close()
+			testQueueContract(t, q)
+		})
+	}
+}
+
+// testQueueContract publishes three messages, requeues one of them once and
+// checks that acknowledged messages are not delivered again to the group.
+func testQueueContract(t *testing.T, q Queue) {
+	ctx, cancel := context.WithTimeout(context.Background(), contractTimeout)
+	defer cancel()
+	queue := "contract-" + randomID()
+	for _, body := range []string{"1", "2", "3"} {
+		assert.NoError(t, PublishConfirmed(ctx, q, queue, Message{Body: []byte(body), Headers: map[string]string{"traceparent": body}}))
+	}
+
+	var mu sync.Mutex
+	var acked []string
+	deliveries := 0
+	requeued := false
+	consume(t, q, queue, func(d Delivery, stop func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries++
+		body := string(d.Body())
+		assert.Equal(t, body, d.Headers()["traceparent"])
+		// the first delivery of 2 is rejected and delivered again
+		if body == "2" && !requeued {
+			requeued = true
+			assert.NoError(t, d.Nack(true))
+			return
+		}
+		assert.NoError(t, d.Ack())
+		acked = append(acked, body)
+		if len(acked) == 3 {
+			stop()
+		}
+	})
+	sort.Strings(acked)
+	assert.Equal(t, []string{"1", "2", "3"}, acked)
+	assert.Equal(t, 4, deliveries)
+
+	assert.NoError(t, PublishConfirmed(ctx, q, queue, Message{Body: []byte("4")}))
+	var next []string
+	consume(t, q, queue, func(d Delivery, stop func()) {
+		next = append(next, string(d.Body()))
+		assert.NoError(t, d.Ack())
+		stop()
+	})
+	assert.Equal(t, []string{"4"}, next)
+}
+
+// consume runs Consume until handle stops it, the test fails when the
+// messages do not arrive within contractTimeout.
+func consume(t *testing.T, q Queue, queue string, handle func(d Delivery, stop func())) {
+	stop := make(chan struct{})
+	var once sync.Once
+	stopOnce := func() { once.Do(func() { close(stop) }) }
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Consume(stop, queue, "contract", 1, func(d Delivery) {
+			handle(d, stopOnce)
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(contractTimeout):
+		t.Error("messages not delivered in time")
+		stopOnce()
+		<-done
+	}
+}
@@ -0,0 +1,187 @@
+package mq
+
+import (
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	kafkaBatchTimeout = 10 * time.Millisecond
+	kafkaRetryDelay   = time.Second
+)
+
+// Kafka uses one topic per queue and a consumer group per group of Consume,
+// the workers of a group beyond the partitions of the topic stay idle.
+// A rejected message is produced again to its topic before its offset is
+// committed.
+type Kafka struct {
+	brokers []string
+	writer  *kafka.Writer
+
+	mu      sync.Mutex
+	readers []*kafka.Reader
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewKafka(brokers []string) (*Kafka, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("NewKafka : no broker configured")
+	}
+	return &Kafka{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.LeastBytes{},
+			BatchTimeout:           kafkaBatchTimeout,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		closed: make(chan struct{}),
+	}, nil
+}
+
+func (k *Kafka) System() string {
+	return BackendKafka
+}
+
+// Publish writes msg in the background, the confirmation resolves once all
+// in-sync replicas stored it.
+func (k *Kafka) Publish(ctx context.Context, queue string, msg Message) (Confirmation, error) {
+	select {
+	case <-k.closed:
+		return nil, ErrClosed
+	default:
+	}
+	cf := newConfirmation()
+	record := kafka.Message{Topic: queue, Value: msg.Body, Headers: kafkaHeaders(msg.Headers)}
+	go func() {
+		cf.resolve(k.writer.WriteMessages(ctx, record))
+	}()
+	return cf, nil
+}
+
+func (k *Kafka) Consume(stop <-chan struct{}, queue, group string, prefetch int, handle func(Delivery)) {
+	if prefetch <= 0 {
+		prefetch = 100
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:       k.brokers,
+		GroupID:       group,
+		Topic:         queue,
+		QueueCapacity: prefetch,
+		StartOffset:   kafka.FirstOffset,
+	})
+	// the reader commits the offsets of messages acknowledged after stop, it
+	// is closed with the queue
+	k.mu.Lock()
+	k.readers = append(k.readers, reader)
+	k.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+		case <-k.closed:
+		}
+		cancel()
+	}()
+
+	for {
+		record, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				// fetched messages without a committed offset are delivered
+				// again to the group
+				return
+			}
+			logger.GetLogger().Sugar().Errorf("Failed to fetch from %s: %v", queue, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(kafkaRetryDelay):
+			}
+			continue
+		}
+		handle(&kafkaDelivery{kafka: k, reader: reader, record: record})
+	}
+}
+
+// Check fails when none of the brokers accepts a connection.
+func (k *Kafka) Check(ctx context.Context) error {
+	var err error
+	for _, broker := range k.brokers {
+		var conn *kafka.Conn
+		conn, err = kafka.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+	}
+	return fmt.Errorf("Check : %w", err)
+}
+
+func (k *Kafka) Close() {
+	k.closeOnce.Do(func() {
+		close(k.closed)
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		for _, reader := range k.readers {
+			reader.Close()
+		}
+		k.writer.Close()
+	})
+}
+
+type kafkaDelivery struct {
+	kafka  *Kafka
+	reader *kafka.Reader
+	record kafka.Message
+}
+
+func (d *kafkaDelivery) Body() []byte {
+	return d.record.Value
+}
+
+func (d *kafkaDelivery) Headers() map[string]string {
+	headers := make(map[string]string, len(d.record.Headers))
+	for _, header := range d.record.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return headers
+}
+
+func (d *kafkaDelivery) Ack() error {
+	return d.reader.CommitMessages(context.Background(), d.record)
+}
+
+func (d *kafkaDelivery) Nack(requeue bool) error {
+	if requeue {
+		err := d.kafka.writer.WriteMessages(context.Background(), kafka.Message{
+			Topic:   d.record.Topic,
+			Value:   d.record.Value,
+			Headers: d.record.Headers,
+		})
+		if err != nil {
+			// without a commit the message is delivered again after a rebalance
+			return fmt.Errorf("Nack : %w", err)
+		}
+	}
+	return d.Ack()
+}
+
+func kafkaHeaders(headers map[string]string) []kafka.Header {
+	result := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		result = append(result, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return result
+}
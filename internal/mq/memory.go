@@ -0,0 +1,187 @@
+package mq
+
+import (
+	"context"
+	"sync"
+)
+
+var sharedQueues = newMemoryQueues()
+
+// SharedMemory returns a handle on the in-memory queues of the process, the
+// services running in one process exchange their messages through them.
+// Closing the handle stops its consumers only, the messages stay for the
+// services still running.
+func SharedMemory() *Memory {
+	return &Memory{queues: sharedQueues, closed: make(chan struct{})}
+}
+
+// Memory is an unbounded in-process queue. Messages are lost with the
+// process, the groups of Consume are ignored as every queue has one consumer
+// group in the pipeline.
+type Memory struct {
+	queues *memoryQueues
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewMemory() *Memory {
+	return &Memory{queues: newMemoryQueues(), closed: make(chan struct{})}
+}
+
+type memoryQueues struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+}
+
+func newMemoryQueues() *memoryQueues {
+	return &memoryQueues{queues: make(map[string]*memoryQueue)}
+}
+
+type memoryQueue struct {
+	mu    sync.Mutex
+	items []Message
+	// signal wakes up one waiting consumer when items were added
+	signal chan struct{}
+}
+
+func (m *Memory) queue(name string) *memoryQueue {
+	m.queues.mu.Lock()
+	defer m.queues.mu.Unlock()
+	q, ok := m.queues.queues[name]
+	if !ok {
+		q = &memoryQueue{signal: make(chan struct{}, 1)}
+		m.queues.queues[name] = q
+	}
+	return q
+}
+
+func (q *memoryQueue) push(msg Message, front bool) {
+	q.mu.Lock()
+	if front {
+		q.items = append([]Message{msg}, q.items...)
+	} else {
+		q.items = append(q.items, msg)
+	}
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *memoryQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// pop waits for a message, it returns false when stop or closed comes first.
+// Messages requeued after stop stay in the queue.
+func (q *memoryQueue) pop(stop, closed <-chan struct{}) (Message, bool) {
+	for {
+		select {
+		case <-stop:
+			return Message{}, false
+		case <-closed:
+			return Message{}, false
+		default:
+		}
+
+		q.mu.Lock()
+		if len(q.items) != 0 {
+			msg := q.items[0]
+			q.items = q.items[1:]
+			more := len(q.items) != 0
+			q.mu.Unlock()
+			if more {
+				q.notify()
+			}
+			return msg, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.signal:
+		case <-stop:
+			return Message{}, false
+		case <-closed:
+			return Message{}, false
+		}
+	}
+}
+
+// Len returns the number of messages waiting in queue.
+func (m *Memory) Len(queue string) int {
+	q := m.queue(queue)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func (m *Memory) System() string {
+	return BackendMemory
+}
+
+func (m *Memory) Publish(ctx context.Context, queue string, msg Message) (Confirmation, error) {
+	select {
+	case <-m.closed:
+		return nil, ErrClosed
+	default:
+	}
+	m.queue(queue).push(Message{Body: append([]byte(nil), msg.Body...), Headers: copyHeaders(msg.Headers)}, false)
+	return confirmed{}, nil
+}
+
+func (m *Memory) Consume(stop <-chan struct{}, queue, group string, prefetch int, handle func(Delivery)) {
+	q := m.queue(queue)
+	for {
+		msg, ok := q.pop(stop, m.closed)
+		if !ok {
+			return
+		}
+		handle(&memoryDelivery{queue: q, msg: msg})
+	}
+}
+
+func (m *Memory) Check(ctx context.Context) error {
+	select {
+	case <-m.closed:
+		return ErrClosed
+	default:
+		return nil
+	}
+}
+
+func (m *Memory) Close() {
+	m.closeOnce.Do(func() {
+		close(m.closed)
+	})
+}
+
+// memoryDelivery puts a rejected message back at the head of its queue.
+type memoryDelivery struct {
+	queue *memoryQueue
+	msg   Message
+	once  sync.Once
+}
+
+func (d *memoryDelivery) Body() []byte {
+	return d.msg.Body
+}
+
+func (d *memoryDelivery) Headers() map[string]string {
+	return d.msg.Headers
+}
+
+func (d *memoryDelivery) Ack() error {
+	d.once.Do(func() {})
+	return nil
+}
+
+func (d *memoryDelivery) Nack(requeue bool) error {
+	d.once.Do(func() {
+		if requeue {
+			d.queue.push(d.msg, true)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,77 @@
+package mq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRequeue(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	for _, body := range []string{"1", "2", "3"} {
+		cf, err := m.Publish(ctx, "blocks", Message{Body: []byte(body), Headers: map[string]string{"traceparent": body}})
+		assert.NoError(t, err)
+		assert.NoError(t, cf.Wait(ctx))
+	}
+
+	stop := make(chan struct{})
+	var got []string
+	requeued := false
+	m.Consume(stop, "blocks", "indexer", 1, func(d Delivery) {
+		got = append(got, string(d.Body()))
+		assert.Equal(t, string(d.Body()), d.Headers()["traceparent"])
+		// the first attempt of block 2 fails and is delivered again
+		if string(d.Body()) == "2" && !requeued {
+			requeued = true
+			d.Nack(true)
+			return
+		}
+		d.Ack()
+		if m.Len("blocks") == 0 {
+			close(stop)
+		}
+	})
+
+	assert.Equal(t, []string{"1", "2", "2", "3"}, got)
+
+	m.Close()
+	_, err := m.Publish(ctx, "blocks", Message{Body: []byte("4")})
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestSharedMemoryClose(t *testing.T) {
+	ctx := context.Background()
+	producer, indexer := SharedMemory(), SharedMemory()
+	_, err := producer.Publish(ctx, "shared", Message{Body: []byte("1")})
+	assert.NoError(t, err)
+
+	// the messages outlive the handle which published them
+	producer.Close()
+	assert.Equal(t, 1, indexer.Len("shared"))
+	_, err = indexer.Publish(ctx, "shared", Message{Body: []byte("2")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, indexer.Len("shared"))
+}
+
+func TestMemoryNackAfterStop(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	for _, body := range []string{"1", "2"} {
+		_, err := m.Publish(ctx, "blocks", Message{Body: []byte(body)})
+		assert.NoError(t, err)
+	}
+
+	// a job interrupted by the shutdown is requeued and not delivered again
+	stop := make(chan struct{})
+	var got []string
+	m.Consume(stop, "blocks", "indexer", 1, func(d Delivery) {
+		got = append(got, string(d.Body()))
+		close(stop)
+		d.Nack(true)
+	})
+
+	assert.Equal(t, []string{"1"}, got)
+	assert.Equal(t, 2, m.Len("blocks"))
+}
@@ -0,0 +1,146 @@
+package mq
+
+import (
+	"Ethereum_Service/config"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	BackendRabbitMQ = "rabbitmq"
+	BackendKafka    = "kafka"
+	BackendNATS     = "nats"
+	BackendRedis    = "redis"
+	BackendMemory   = "memory"
+
+	republishDelay = time.Second
+)
+
+var (
+	ErrClosed          = errors.New("queue closed")
+	ErrNacked          = errors.New("message nacked by the broker")
+	ErrReturned        = errors.New("message returned as unroutable")
+	ErrPublisherClosed = errors.New("publisher closed before the confirmation")
+)
+
+// Message is published to a queue, Headers carry the trace context.
+type Message struct {
+	Body    []byte
+	Headers map[string]string
+	// ContentType is the MIME type of Body, only RabbitMQ carries it
+	ContentType string
+}
+
+// Delivery is a message consumed from a queue, it is acknowledged or rejected once.
+type Delivery interface {
+	Body() []byte
+	Headers() map[string]string
+	Ack() error
+	// Nack rejects the message, it is delivered again when requeue is set.
+	Nack(requeue bool) error
+}
+
+// Confirmation tells whether the backend stored a published message.
+type Confirmation interface {
+	// Wait returns nil once the message is stored, the message has to be
+	// published again on any other error than ctx.Err().
+	Wait(ctx context.Context) error
+}
+
+// Queue carries the block numbers between the producer and the indexer.
+type Queue interface {
+	// System is the messaging system, used as tracing attribute.
+	System() string
+	// Publish sends msg to queue, waiting for the backend to be reachable
+	// until ctx is done.
+	Publish(ctx context.Context, queue string, msg Message) (Confirmation, error)
+	// Consume passes the messages of queue to handle until stop is closed or
+	// the queue is closed, the consumers of one group share the messages.
+	// Messages fetched but not handled at stop are requeued, handled messages
+	// can still be acknowledged until Close.
+	Consume(stop <-chan struct{}, queue, group string, prefetch int, handle func(Delivery))
+	// Check fails when the backend is not reachable.
+	Check(ctx context.Context) error
+	Close()
+}
+
+// New connects to the backend selected by opt.
+func New(opt config.QueueOption, mqEndpoint string) (Queue, error) {
+	switch opt.Backend {
+	case "", BackendRabbitMQ:
+		return DialRabbitMQ(mqEndpoint)
+	case BackendKafka:
+		return NewKafka(opt.KafkaBrokers)
+	case BackendNATS:
+		return DialNATS(opt.NatsURL)
+	case BackendRedis:
+		return NewRedis(config.GetConfig().Redis)
+	case BackendMemory:
+		return SharedMemory(), nil
+	}
+	return nil, fmt.Errorf("New : unknown queue backend %q", opt.Backend)
+}
+
+// PublishConfirmed publishes msg until the backend confirms it or ctx is done.
+func PublishConfirmed(ctx context.Context, q Queue, queue string, msg Message) error {
+	for {
+		cf, err := q.Publish(ctx, queue, msg)
+		if err == nil {
+			err = cf.Wait(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+		if ctx.Err() != nil || errors.Is(err, ErrClosed) {
+			return fmt.Errorf("PublishConfirmed : %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("PublishConfirmed : %w", ctx.Err())
+		case <-time.After(republishDelay):
+		}
+	}
+}
+
+// confirmed is the confirmation of a message stored before Publish returned.
+type confirmed struct {
+	err error
+}
+
+func (c confirmed) Wait(ctx context.Context) error {
+	return c.err
+}
+
+// confirmation is resolved once by the backend.
+type confirmation struct {
+	done chan struct{}
+	err  error
+}
+
+func newConfirmation() *confirmation {
+	return &confirmation{done: make(chan struct{})}
+}
+
+func (cf *confirmation) resolve(err error) {
+	cf.err = err
+	close(cf.done)
+}
+
+func (cf *confirmation) Wait(ctx context.Context) error {
+	select {
+	case <-cf.done:
+		return cf.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers))
+	for k, v := range headers {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,272 @@
+package mq
+
+import (
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	natsFetchWait  = time.Second
+	natsRetryDelay = time.Second
+	// natsProgressInterval is how often a message being handled is reported
+	// in progress, well within the default ack wait of 30s, so that a long
+	// job is not redelivered to another worker
+	natsProgressInterval = 10 * time.Second
+)
+
+// NATS uses a JetStream stream per queue with the queue name as subject and a
+// durable pull consumer per group. The streams are work queues, a message is
+// deleted once acknowledged. The client reconnects by itself.
+type NATS struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+
+	mu      sync.Mutex
+	streams map[string]bool
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func DialNATS(url string) (*NATS, error) {
+	conn, err := nats.Connect(url,
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.GetLogger().Sugar().Warnf("NATS connection lost: %v", err)
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			logger.GetLogger().Sugar().Infof("NATS connection re-established")
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("DialNATS : %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("DialNATS : %w", err)
+	}
+	return &NATS{
+		conn:    conn,
+		js:      js,
+		streams: make(map[string]bool),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// natsName turns a queue or group name into a stream or consumer name, which
+// cannot contain dots.
+func natsName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+func (n *NATS) ensureStream(queue string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.streams[queue] {
+		return nil
+	}
+	info, err := n.js.StreamInfo(natsName(queue))
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = n.js.AddStream(&nats.StreamConfig{
+			Name:      natsName(queue),
+			Subjects:  []string{queue},
+			Storage:   nats.FileStorage,
+			Retention: nats.WorkQueuePolicy,
+		})
+	} else if err == nil && info.Config.Retention != nats.WorkQueuePolicy {
+		// the retention of a stream cannot be changed, it has to be recreated
+		logger.GetLogger().Sugar().Warnf("NATS stream %s keeps acknowledged messages, recreate it as a work queue", natsName(queue))
+	}
+	if err != nil {
+		return fmt.Errorf("ensureStream : %w", err)
+	}
+	n.streams[queue] = true
+	return nil
+}
+
+func (n *NATS) System() string {
+	return BackendNATS
+}
+
+// Publish publishes msg asynchronously, the confirmation resolves with the
+// acknowledgement of the stream.
+func (n *NATS) Publish(ctx context.Context, queue string, msg Message) (Confirmation, error) {
+	if err := n.ensureStream(queue); err != nil {
+		return nil, fmt.Errorf("Publish : %w", err)
+	}
+	header := nats.Header{}
+	for k, v := range msg.Headers {
+		header.Set(k, v)
+	}
+	future, err := n.js.PublishMsgAsync(&nats.Msg{Subject: queue, Data: msg.Body, Header: header})
+	if err != nil {
+		return nil, fmt.Errorf("Publish : %w", err)
+	}
+	return natsConfirmation{future}, nil
+}
+
+func (n *NATS) Consume(stop <-chan struct{}, queue, group string, prefetch int, handle func(Delivery)) {
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+	var sub *nats.Subscription
+	for {
+		err := n.ensureStream(queue)
+		if err == nil {
+			sub, err = n.js.PullSubscribe(queue, natsName(group),
+				nats.BindStream(natsName(queue)), nats.ManualAck(), nats.AckExplicit())
+		}
+		if err == nil {
+			break
+		}
+		logger.GetLogger().Sugar().Errorf("Failed to consume %s: %v", queue, err)
+		if !n.wait(stop, natsRetryDelay) {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-n.closed:
+			return
+		default:
+		}
+		msgs, err := sub.Fetch(prefetch, nats.MaxWait(natsFetchWait))
+		if err != nil {
+			if !errors.Is(err, nats.ErrTimeout) {
+				logger.GetLogger().Sugar().Errorf("Failed to fetch from %s: %v", queue, err)
+				if !n.wait(stop, natsRetryDelay) {
+					return
+				}
+			}
+			continue
+		}
+		for i, msg := range msgs {
+			if n.stopped(stop) {
+				// the rest of the batch goes back to the stream
+				for _, rest := range msgs[i:] {
+					rest.Nak()
+				}
+				return
+			}
+			handle(n.delivery(msg))
+		}
+	}
+}
+
+func (n *NATS) stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	case <-n.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (n *NATS) wait(stop <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-n.closed:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (n *NATS) Check(ctx context.Context) error {
+	if status := n.conn.Status(); status != nats.CONNECTED {
+		return fmt.Errorf("Check : connection %s", status.String())
+	}
+	return nil
+}
+
+func (n *NATS) Close() {
+	n.closeOnce.Do(func() {
+		close(n.closed)
+		n.conn.Close()
+	})
+}
+
+type natsConfirmation struct {
+	future nats.PubAckFuture
+}
+
+func (c natsConfirmation) Wait(ctx context.Context) error {
+	select {
+	case <-c.future.Ok():
+		return nil
+	case err := <-c.future.Err():
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delivery reports msg in progress until it is acknowledged or rejected.
+func (n *NATS) delivery(msg *nats.Msg) *natsDelivery {
+	d := &natsDelivery{msg: msg, done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(natsProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-n.closed:
+				return
+			case <-ticker.C:
+				d.msg.InProgress()
+			}
+		}
+	}()
+	return d
+}
+
+type natsDelivery struct {
+	msg *nats.Msg
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func (d *natsDelivery) finish() {
+	d.doneOnce.Do(func() { close(d.done) })
+}
+
+func (d *natsDelivery) Body() []byte {
+	return d.msg.Data
+}
+
+func (d *natsDelivery) Headers() map[string]string {
+	headers := make(map[string]string, len(d.msg.Header))
+	for k := range d.msg.Header {
+		headers[k] = d.msg.Header.Get(k)
+	}
+	return headers
+}
+
+func (d *natsDelivery) Ack() error {
+	d.finish()
+	return d.msg.Ack()
+}
+
+func (d *natsDelivery) Nack(requeue bool) error {
+	d.finish()
+	if requeue {
+		return d.msg.Nak()
+	}
+	return d.msg.Term()
+}
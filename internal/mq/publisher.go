@@ -0,0 +1,185 @@
+package mq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// publishTagHeader carries the delivery tag of a message so a basic.return
+// can be matched with the confirmation that follows it.
+const publishTagHeader = "x-publish-tag"
+
+// publisher is a channel in confirm mode with the confirmations it still waits for.
+type publisher struct {
+	conn    *amqp.Connection
+	ch      *amqp.Channel
+	nextTag uint64
+
+	mu       sync.Mutex
+	declared map[string]bool
+	pending  map[uint64]*confirmation
+	closed   bool
+}
+
+func newPublisher(conn *amqp.Connection) (*publisher, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("newPublisher : %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("newPublisher : %w", err)
+	}
+	p := &publisher{
+		conn:     conn,
+		ch:       ch,
+		declared: make(map[string]bool),
+		nextTag:  1,
+		pending:  make(map[uint64]*confirmation),
+	}
+	// unbuffered, a basic.return is received before the confirmation of its message
+	go p.listen(ch.NotifyPublish(make(chan amqp.Confirmation)), ch.NotifyReturn(make(chan amqp.Return)))
+	return p, nil
+}
+
+func (p *publisher) listen(confirms chan amqp.Confirmation, returns chan amqp.Return) {
+	returned := make(map[uint64]bool)
+	for {
+		select {
+		case ret, ok := <-returns:
+			if !ok {
+				returns = nil
+				continue
+			}
+			if tag, ok := ret.Headers[publishTagHeader].(int64); ok {
+				returned[uint64(tag)] = true
+			}
+			// the queue is gone, it is declared again on the next publish
+			p.mu.Lock()
+			delete(p.declared, ret.RoutingKey)
+			p.mu.Unlock()
+		case confirm, ok := <-confirms:
+			if !ok {
+				p.fail()
+				return
+			}
+			var err error
+			if !confirm.Ack {
+				err = ErrNacked
+			} else if returned[confirm.DeliveryTag] {
+				err = ErrReturned
+			}
+			delete(returned, confirm.DeliveryTag)
+			p.resolve(confirm.DeliveryTag, err)
+		}
+	}
+}
+
+func (p *publisher) resolve(tag uint64, err error) {
+	p.mu.Lock()
+	cf, ok := p.pending[tag]
+	delete(p.pending, tag)
+	p.mu.Unlock()
+	if ok {
+		cf.resolve(err)
+	}
+}
+
+// fail rejects the messages still waiting for a confirmation once the channel is gone.
+func (p *publisher) fail() {
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = make(map[uint64]*confirmation)
+	p.closed = true
+	p.mu.Unlock()
+	for _, cf := range pending {
+		cf.resolve(ErrPublisherClosed)
+	}
+}
+
+func (p *publisher) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+func (p *publisher) publish(queue string, msg amqp.Publishing) (*confirmation, error) {
+	p.mu.Lock()
+	declared := p.declared[queue]
+	p.mu.Unlock()
+	if !declared {
+		if err := declareQueue(p.ch, queue); err != nil {
+			return nil, fmt.Errorf("publish : %w", err)
+		}
+		p.mu.Lock()
+		p.declared[queue] = true
+		p.mu.Unlock()
+	}
+
+	tag := p.nextTag
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[publishTagHeader] = int64(tag)
+	msg.Headers = headers
+
+	cf := newConfirmation()
+	p.mu.Lock()
+	p.pending[tag] = cf
+	p.mu.Unlock()
+
+	if err := p.ch.Publish("", queue, true, false, msg); err != nil {
+		p.resolve(tag, err)
+		return nil, fmt.Errorf("publish : %w", err)
+	}
+	p.nextTag++
+	return cf, nil
+}
+
+// Publish sends msg to queue as mandatory on the publisher channel, declaring
+// the queue the first time it is used on the channel. It waits for the
+// connection to be re-established until ctx is done.
+func (c *RabbitMQ) Publish(ctx context.Context, queue string, msg Message) (Confirmation, error) {
+	c.pubMu.Lock()
+	defer c.pubMu.Unlock()
+
+	conn := c.current(ctx.Done())
+	if conn == nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("Publish : %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("Publish : %w", ErrClosed)
+	}
+	if c.pub == nil || c.pub.conn != conn || c.pub.isClosed() {
+		pub, err := newPublisher(conn)
+		if err != nil {
+			return nil, fmt.Errorf("Publish : %w", err)
+		}
+		c.pub = pub
+	}
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	contentType := msg.ContentType
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	cf, err := c.pub.publish(queue, amqp.Publishing{
+		ContentType: contentType,
+		Headers:     headers,
+		Body:        msg.Body,
+	})
+	if err != nil {
+		// the channel is closed by the error, the next publish opens another one
+		c.pub.ch.Close()
+		c.pub = nil
+		return nil, fmt.Errorf("Publish : %w", err)
+	}
+	return cf, nil
+}
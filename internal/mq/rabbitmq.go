@@ -0,0 +1,266 @@
+package mq
+
+import (
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+
+	// consumeRetryDelay is waited before opening a consumer again when the
+	// connection is up but the channel could not be set up
+	consumeRetryDelay = time.Second
+)
+
+// RabbitMQ is a RabbitMQ connection that is re-established with backoff when
+// the broker closes it. Publishing goes through one long-lived channel in
+// confirm mode and consumers are opened again on the new connection.
+type RabbitMQ struct {
+	url string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	// ready is closed while conn is usable, it is replaced when conn is lost
+	ready chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	pubMu sync.Mutex
+	pub   *publisher
+}
+
+// DialRabbitMQ connects to url, the first attempt is not retried.
+func DialRabbitMQ(url string) (*RabbitMQ, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("DialRabbitMQ : %w", err)
+	}
+	c := &RabbitMQ{
+		url:    url,
+		ready:  make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+	c.setConn(conn)
+	return c, nil
+}
+
+func (c *RabbitMQ) setConn(conn *amqp.Connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closed:
+		conn.Close()
+		return
+	default:
+	}
+	c.conn = conn
+	close(c.ready)
+	go c.watch(conn.NotifyClose(make(chan *amqp.Error, 1)))
+}
+
+// watch reconnects once conn is closed by the broker or the network, a close
+// requested by Close is not reported with an error.
+func (c *RabbitMQ) watch(notify chan *amqp.Error) {
+	amqpErr, ok := <-notify
+	if !ok || amqpErr == nil {
+		return
+	}
+	logger.GetLogger().Sugar().Warnf("RabbitMQ connection lost: %v", amqpErr)
+
+	c.mu.Lock()
+	c.ready = make(chan struct{})
+	c.mu.Unlock()
+
+	delay := minReconnectDelay
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-time.After(delay):
+		}
+		conn, err := amqp.Dial(c.url)
+		if err == nil {
+			logger.GetLogger().Sugar().Infof("RabbitMQ connection re-established")
+			c.setConn(conn)
+			return
+		}
+		logger.GetLogger().Sugar().Errorf("Failed to reconnect to RabbitMQ, retry in %s: %v", delay, err)
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// current waits for a usable connection, it returns nil when done or Close
+// comes first.
+func (c *RabbitMQ) current(done <-chan struct{}) *amqp.Connection {
+	for {
+		c.mu.Lock()
+		ready, conn := c.ready, c.conn
+		c.mu.Unlock()
+		select {
+		case <-ready:
+			return conn
+		default:
+		}
+
+		select {
+		case <-ready:
+		case <-done:
+			return nil
+		case <-c.closed:
+			return nil
+		}
+	}
+}
+
+func (c *RabbitMQ) System() string {
+	return BackendRabbitMQ
+}
+
+// Check fails while the connection is being re-established.
+func (c *RabbitMQ) Check(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil || c.conn.IsClosed() {
+		return errors.New("connection closed")
+	}
+	return nil
+}
+
+// Consume passes the messages of queue to handle until stop is closed, group
+// is the consumer tag. The consumer is opened again whenever its channel or
+// the connection is lost, messages unacknowledged at that point are
+// redelivered by the broker. The channel is left open after stop so messages
+// handled already can still be acknowledged, it is closed with the connection.
+func (c *RabbitMQ) Consume(stop <-chan struct{}, queue, tag string, prefetch int, handle func(Delivery)) {
+	for {
+		conn := c.current(stop)
+		if conn == nil {
+			return
+		}
+		ch, msgs, err := openConsumer(conn, queue, tag, prefetch)
+		if err != nil {
+			logger.GetLogger().Sugar().Errorf("Failed to consume %s: %v", queue, err)
+			select {
+			case <-stop:
+				return
+			case <-c.closed:
+				return
+			case <-time.After(consumeRetryDelay):
+			}
+			continue
+		}
+		if !deliver(stop, ch, tag, msgs, handle) {
+			return
+		}
+		logger.GetLogger().Sugar().Warnf("Consumer of %s closed, re-establishing", queue)
+	}
+}
+
+// deliver passes msgs to handle, it returns false once stop is closed and
+// true when msgs was closed with its channel.
+func deliver(stop <-chan struct{}, ch *amqp.Channel, tag string, msgs <-chan amqp.Delivery, handle func(Delivery)) bool {
+	for {
+		select {
+		case <-stop:
+			if err := ch.Cancel(tag, false); err != nil {
+				logger.GetLogger().Sugar().Errorf("Failed to cancel the consumer: %v", err)
+				return false
+			}
+			for msg := range msgs {
+				msg.Nack(false, true)
+			}
+			return false
+		case msg, ok := <-msgs:
+			if !ok {
+				return true
+			}
+			handle(rabbitDelivery{msg})
+		}
+	}
+}
+
+func openConsumer(conn *amqp.Connection, queue, tag string, prefetch int) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("openConsumer : %w", err)
+	}
+	if prefetch > 0 {
+		if err := ch.Qos(prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, nil, fmt.Errorf("openConsumer : %w", err)
+		}
+	}
+	if err := declareQueue(ch, queue); err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("openConsumer : %w", err)
+	}
+	msgs, err := ch.Consume(queue, tag, false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, nil, fmt.Errorf("openConsumer : %w", err)
+	}
+	return ch, msgs, nil
+}
+
+func declareQueue(ch *amqp.Channel, queue string) error {
+	_, err := ch.QueueDeclare(
+		queue,
+		true,
+		false,
+		false,
+		false,
+		nil,
+	)
+	return err
+}
+
+// Close closes the connection and stops reconnecting.
+func (c *RabbitMQ) Close() {
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		close(c.closed)
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
+
+// rabbitDelivery acknowledges a single message on its channel.
+type rabbitDelivery struct {
+	msg amqp.Delivery
+}
+
+func (d rabbitDelivery) Body() []byte {
+	return d.msg.Body
+}
+
+func (d rabbitDelivery) Headers() map[string]string {
+	headers := make(map[string]string, len(d.msg.Headers))
+	for k, v := range d.msg.Headers {
+		if s, ok := v.(string); ok {
+			headers[k] = s
+		}
+	}
+	return headers
+}
+
+func (d rabbitDelivery) Ack() error {
+	return d.msg.Ack(false)
+}
+
+func (d rabbitDelivery) Nack(requeue bool) error {
+	return d.msg.Nack(false, requeue)
+}
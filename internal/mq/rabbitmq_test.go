@@ -0,0 +1,41 @@
+package mq
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublisherConfirmations(t *testing.T) {
+	p := &publisher{
+		declared: map[string]bool{"blocks": true},
+		pending:  make(map[uint64]*confirmation),
+	}
+	confirms := make([]*confirmation, 4)
+	for i := range confirms {
+		confirms[i] = newConfirmation()
+		p.pending[uint64(i+1)] = confirms[i]
+	}
+
+	confirmCh := make(chan amqp.Confirmation)
+	returnCh := make(chan amqp.Return)
+	go p.listen(confirmCh, returnCh)
+
+	confirmCh <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	confirmCh <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+	// an unroutable message is returned before it is acked
+	returnCh <- amqp.Return{RoutingKey: "blocks", Headers: amqp.Table{publishTagHeader: int64(3)}}
+	confirmCh <- amqp.Confirmation{DeliveryTag: 3, Ack: true}
+	close(confirmCh)
+
+	ctx := context.Background()
+	assert.NoError(t, confirms[0].Wait(ctx))
+	assert.ErrorIs(t, confirms[1].Wait(ctx), ErrNacked)
+	assert.ErrorIs(t, confirms[2].Wait(ctx), ErrReturned)
+	assert.ErrorIs(t, confirms[3].Wait(ctx), ErrPublisherClosed)
+
+	assert.True(t, p.isClosed())
+	assert.False(t, p.declared["blocks"])
+}
@@ -0,0 +1,242 @@
+package mq
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	redisBodyField   = "body"
+	redisHeaderField = "h:"
+
+	redisBlock      = time.Second
+	redisRetryDelay = time.Second
+	// redisClaimIdle is how long a message stays with a consumer that does not
+	// acknowledge it before another consumer of the group claims it
+	redisClaimIdle = 5 * time.Minute
+)
+
+// Redis uses a stream per queue and a consumer group per group of Consume.
+// Messages pending on a consumer that went away are claimed by the others
+// after redisClaimIdle. Acknowledged messages are deleted from the stream as
+// every queue has a single group.
+type Redis struct {
+	client *redis.Client
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewRedis(opt config.RedisOption) (*Redis, error) {
+	return &Redis{
+		client: redis.NewClient(&redis.Options{
+			Addr:     opt.Host + ":" + opt.Port,
+			Password: opt.Password,
+		}),
+		closed: make(chan struct{}),
+	}, nil
+}
+
+func (r *Redis) System() string {
+	return BackendRedis
+}
+
+// Publish appends msg to the stream, it is stored once XADD returns.
+func (r *Redis) Publish(ctx context.Context, queue string, msg Message) (Confirmation, error) {
+	values := map[string]interface{}{redisBodyField: msg.Body}
+	for k, v := range msg.Headers {
+		values[redisHeaderField+k] = v
+	}
+	if err := r.client.XAdd(&redis.XAddArgs{Stream: queue, Values: values}).Err(); err != nil {
+		return nil, fmt.Errorf("Publish : %w", err)
+	}
+	return confirmed{}, nil
+}
+
+func (r *Redis) Consume(stop <-chan struct{}, queue, group string, prefetch int, handle func(Delivery)) {
+	if prefetch <= 0 {
+		prefetch = 1
+	}
+	consumer := group + "-" + randomID()
+	for {
+		err := r.client.XGroupCreateMkStream(queue, group, "0").Err()
+		if err == nil || strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			break
+		}
+		logger.GetLogger().Sugar().Errorf("Failed to create the group of %s: %v", queue, err)
+		if !r.wait(stop, redisRetryDelay) {
+			return
+		}
+	}
+
+	lastClaim := time.Now()
+	for !r.stopped(stop) {
+		var msgs []redis.XMessage
+		if time.Since(lastClaim) > redisClaimIdle {
+			msgs = r.claim(queue, group, consumer, prefetch)
+			lastClaim = time.Now()
+		}
+		if len(msgs) == 0 {
+			streams, err := r.client.XReadGroup(&redis.XReadGroupArgs{
+				Group:    group,
+				Consumer: consumer,
+				Streams:  []string{queue, ">"},
+				Count:    int64(prefetch),
+				Block:    redisBlock,
+			}).Result()
+			if err != nil && err != redis.Nil {
+				logger.GetLogger().Sugar().Errorf("Failed to read from %s: %v", queue, err)
+				if !r.wait(stop, redisRetryDelay) {
+					return
+				}
+				continue
+			}
+			for _, stream := range streams {
+				msgs = append(msgs, stream.Messages...)
+			}
+		}
+
+		for i, msg := range msgs {
+			if r.stopped(stop) {
+				// the rest of the batch goes back to the stream
+				for _, rest := range msgs[i:] {
+					r.delivery(queue, group, rest).Nack(true)
+				}
+				return
+			}
+			handle(r.delivery(queue, group, msg))
+		}
+	}
+}
+
+// claim takes over the messages left unacknowledged by other consumers.
+func (r *Redis) claim(queue, group, consumer string, count int) []redis.XMessage {
+	pending, err := r.client.XPendingExt(&redis.XPendingExtArgs{
+		Stream: queue,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(count),
+	}).Result()
+	if err != nil {
+		return nil
+	}
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		if p.Idle >= redisClaimIdle {
+			ids = append(ids, p.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	msgs, err := r.client.XClaim(&redis.XClaimArgs{
+		Stream:   queue,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  redisClaimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil
+	}
+	return msgs
+}
+
+func (r *Redis) delivery(queue, group string, msg redis.XMessage) *redisDelivery {
+	return &redisDelivery{redis: r, queue: queue, group: group, msg: msg}
+}
+
+func (r *Redis) stopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	case <-r.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *Redis) wait(stop <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stop:
+		return false
+	case <-r.closed:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (r *Redis) Check(ctx context.Context) error {
+	return r.client.Ping().Err()
+}
+
+func (r *Redis) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+		r.client.Close()
+	})
+}
+
+type redisDelivery struct {
+	redis *Redis
+	queue string
+	group string
+	msg   redis.XMessage
+}
+
+func (d *redisDelivery) Body() []byte {
+	body, _ := d.msg.Values[redisBodyField].(string)
+	return []byte(body)
+}
+
+func (d *redisDelivery) Headers() map[string]string {
+	headers := make(map[string]string)
+	for k, v := range d.msg.Values {
+		if s, ok := v.(string); ok && strings.HasPrefix(k, redisHeaderField) {
+			headers[strings.TrimPrefix(k, redisHeaderField)] = s
+		}
+	}
+	return headers
+}
+
+func (d *redisDelivery) Ack() error {
+	_, err := d.redis.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.XAck(d.queue, d.group, d.msg.ID)
+		pipe.XDel(d.queue, d.msg.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Ack : %w", err)
+	}
+	return nil
+}
+
+// Nack appends the message again to requeue it, the stream has no other way
+// to put a message back.
+func (d *redisDelivery) Nack(requeue bool) error {
+	if requeue {
+		err := d.redis.client.XAdd(&redis.XAddArgs{Stream: d.queue, Values: d.msg.Values}).Err()
+		if err != nil {
+			return fmt.Errorf("Nack : %w", err)
+		}
+	}
+	return d.Ack()
+}
+
+func randomID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
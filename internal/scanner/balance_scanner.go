@@ -0,0 +1,173 @@
+package scanner
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/tracing"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// InternalTransfer is a value transfer made by a contract call inside a transaction.
+type InternalTransfer struct {
+	TxHash common.Hash
+	From   common.Address
+	To     common.Address
+	Value  *big.Int
+}
+
+type BalanceScanner interface {
+	BalanceAt(ctx context.Context, address common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, address common.Address, blockNumber *big.Int) (uint64, error)
+	InternalTransfers(ctx context.Context, blockNumber *big.Int) ([]InternalTransfer, error)
+	Shutdown()
+}
+
+type defaultBalanceScanner struct {
+	ethClient *ethclient.Client
+	pool      *EndpointPool
+	endpoint  string
+}
+
+func NewDefaultBalanceScanner(pool *EndpointPool) BalanceScanner {
+	s := &defaultBalanceScanner{
+		pool: pool,
+	}
+	s.createClient()
+	return s
+}
+
+func (s *defaultBalanceScanner) BalanceAt(ctx context.Context, address common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance *big.Int
+	var err error
+	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "eth_getBalance", s.endpoint)
+		balance, err = s.ethClient.BalanceAt(rpcCtx, address, blockNumber)
+		tracing.End(span, err)
+		s.pool.observe("eth_getBalance", s.endpoint, start, err)
+		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
+			s.createClient()
+		}
+		if err == nil {
+			break
+		}
+		<-time.NewTimer(time.Second * 1).C
+	}
+	if err != nil {
+		return nil, fmt.Errorf("BalanceAt : %w", err)
+	}
+	return balance, nil
+}
+
+func (s *defaultBalanceScanner) NonceAt(ctx context.Context, address common.Address, blockNumber *big.Int) (uint64, error) {
+	var nonce uint64
+	var err error
+	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "eth_getTransactionCount", s.endpoint)
+		nonce, err = s.ethClient.NonceAt(rpcCtx, address, blockNumber)
+		tracing.End(span, err)
+		s.pool.observe("eth_getTransactionCount", s.endpoint, start, err)
+		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
+			s.createClient()
+		}
+		if err == nil {
+			break
+		}
+		<-time.NewTimer(time.Second * 1).C
+	}
+	if err != nil {
+		return 0, fmt.Errorf("NonceAt : %w", err)
+	}
+	return nonce, nil
+}
+
+type callFrame struct {
+	Type  string         `json:"type"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+	Error string         `json:"error"`
+	Calls []callFrame    `json:"calls"`
+}
+
+type txTraceResult struct {
+	TxHash common.Hash `json:"txHash"`
+	Result callFrame   `json:"result"`
+}
+
+// InternalTransfers traces the block with the callTracer, the node must expose the debug namespace.
+func (s *defaultBalanceScanner) InternalTransfers(ctx context.Context, blockNumber *big.Int) ([]InternalTransfer, error) {
+	var traces []txTraceResult
+	var err error
+	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "debug_traceBlockByNumber", s.endpoint)
+		err = s.ethClient.Client().CallContext(rpcCtx, &traces, "debug_traceBlockByNumber",
+			hexutil.EncodeBig(blockNumber), map[string]string{"tracer": "callTracer"})
+		tracing.End(span, err)
+		s.pool.observe("debug_traceBlockByNumber", s.endpoint, start, err)
+		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
+			s.createClient()
+		}
+		if err == nil {
+			break
+		}
+		<-time.NewTimer(time.Second * 1).C
+	}
+	if err != nil {
+		return nil, fmt.Errorf("InternalTransfers : %w", err)
+	}
+
+	var transfers []InternalTransfer
+	for _, trace := range traces {
+		if trace.Result.Error != "" {
+			continue
+		}
+		// the top level frame is the transaction itself, only nested calls are internal
+		for _, call := range trace.Result.Calls {
+			transfers = collectTransfers(transfers, trace.TxHash, call)
+		}
+	}
+	return transfers, nil
+}
+
+func collectTransfers(transfers []InternalTransfer, txHash common.Hash, frame callFrame) []InternalTransfer {
+	// reverted frames don't move value, neither do their children
+	if frame.Error != "" {
+		return transfers
+	}
+	if frame.Type != "DELEGATECALL" && frame.Type != "STATICCALL" &&
+		frame.Value != nil && frame.Value.ToInt().Sign() > 0 {
+		transfers = append(transfers, InternalTransfer{
+			TxHash: txHash,
+			From:   frame.From,
+			To:     frame.To,
+			Value:  frame.Value.ToInt(),
+		})
+	}
+	for _, call := range frame.Calls {
+		transfers = collectTransfers(transfers, txHash, call)
+	}
+	return transfers
+}
+
+func (s *defaultBalanceScanner) createClient() {
+	var err error
+	s.endpoint = s.pool.Next()
+	s.ethClient, err = ethclient.Dial(s.endpoint)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (s *defaultBalanceScanner) Shutdown() {
+	s.ethClient.Close()
+}
@@ -2,13 +2,17 @@ package scanner
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/chainprofile"
+	"Ethereum_Service/internal/tracing"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
@@ -16,27 +20,47 @@ import (
 type BlockScanner interface {
 	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
 	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	// BlockWithDeposits returns the block and, on OP-stack chains, its deposit transactions.
+	BlockWithDeposits(ctx context.Context, number *big.Int) (*types.Block, []chainprofile.DepositTx, error)
 	Shutdown()
 }
 
+// DefaultBlockScanner decodes the blocks of chains with deposit transactions
+// from the raw RPC response, the blocks returned by BlockByHash and
+// BlockByNumber leave the deposit transactions out.
 type DefaultBlockScanner struct {
-	ethClient   *ethclient.Client
-	rpcEndpoint string
+	ethClient *ethclient.Client
+	pool      *EndpointPool
+	endpoint  string
+	profile   chainprofile.Profile
 }
 
-func NewDefaultBlockScanner(rpcEndpoint string) BlockScanner {
+func NewDefaultBlockScanner(pool *EndpointPool, profile chainprofile.Profile) BlockScanner {
 	s := &DefaultBlockScanner{
-		rpcEndpoint: rpcEndpoint,
+		pool:    pool,
+		profile: profile,
 	}
 	s.createClient()
 	return s
 }
 
 func (s *DefaultBlockScanner) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if s.profile.HasDepositTxs() {
+		b, _, err := s.BlockWithDeposits(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("BlockByNumber : %w", err)
+		}
+		return b, nil
+	}
+
 	var b *types.Block
 	var err error
 	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
-		b, err = s.ethClient.BlockByNumber(ctx, number)
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "eth_getBlockByNumber", s.endpoint)
+		b, err = s.ethClient.BlockByNumber(rpcCtx, number)
+		tracing.End(span, err)
+		s.pool.observe("eth_getBlockByNumber", s.endpoint, start, err)
 		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
 			s.createClient()
 		}
@@ -52,10 +76,22 @@ func (s *DefaultBlockScanner) BlockByNumber(ctx context.Context, number *big.Int
 }
 
 func (s *DefaultBlockScanner) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if s.profile.HasDepositTxs() {
+		b, _, err := s.rawBlock(ctx, "eth_getBlockByHash", hash)
+		if err != nil {
+			return nil, fmt.Errorf("BlockByHash : %w", err)
+		}
+		return b, nil
+	}
+
 	var b *types.Block
 	var err error
 	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
-		b, err = s.ethClient.BlockByHash(ctx, hash)
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "eth_getBlockByHash", s.endpoint)
+		b, err = s.ethClient.BlockByHash(rpcCtx, hash)
+		tracing.End(span, err)
+		s.pool.observe("eth_getBlockByHash", s.endpoint, start, err)
 		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
 			s.createClient()
 		}
@@ -70,9 +106,49 @@ func (s *DefaultBlockScanner) BlockByHash(ctx context.Context, hash common.Hash)
 	return b, nil
 }
 
+func (s *DefaultBlockScanner) BlockWithDeposits(ctx context.Context, number *big.Int) (*types.Block, []chainprofile.DepositTx, error) {
+	if !s.profile.HasDepositTxs() {
+		b, err := s.BlockByNumber(ctx, number)
+		if err != nil {
+			return nil, nil, fmt.Errorf("BlockWithDeposits : %w", err)
+		}
+		return b, nil, nil
+	}
+
+	b, deposits, err := s.rawBlock(ctx, "eth_getBlockByNumber", hexutil.EncodeBig(number))
+	if err != nil {
+		return nil, nil, fmt.Errorf("BlockWithDeposits : %w", err)
+	}
+	return b, deposits, nil
+}
+
+func (s *DefaultBlockScanner) rawBlock(ctx context.Context, method string, id interface{}) (*types.Block, []chainprofile.DepositTx, error) {
+	var raw json.RawMessage
+	var err error
+	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, method, s.endpoint)
+		err = s.ethClient.Client().CallContext(rpcCtx, &raw, method, id, true)
+		tracing.End(span, err)
+		s.pool.observe(method, s.endpoint, start, err)
+		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
+			s.createClient()
+		}
+		if err == nil {
+			break
+		}
+		<-time.NewTimer(time.Second * 1).C
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("rawBlock : %w", err)
+	}
+	return chainprofile.DecodeBlock(raw)
+}
+
 func (s *DefaultBlockScanner) createClient() {
 	var err error
-	s.ethClient, err = ethclient.Dial(s.rpcEndpoint)
+	s.endpoint = s.pool.Next()
+	s.ethClient, err = ethclient.Dial(s.endpoint)
 	if err != nil {
 		panic(err)
 	}
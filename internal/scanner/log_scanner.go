@@ -2,11 +2,14 @@ package scanner
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/tracing"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -14,17 +17,21 @@ import (
 
 type LogScanner interface {
 	GetLogs(ctx context.Context, txHash common.Hash) ([]*types.Log, error)
+	GetReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	// GetRawReceipt returns the receipt as sent by the node, including chain specific fields.
+	GetRawReceipt(ctx context.Context, txHash common.Hash) (json.RawMessage, error)
 	Shutdown()
 }
 
 type defaultLogScanner struct {
-	ethClient   *ethclient.Client
-	rpcEndpoint string
+	ethClient *ethclient.Client
+	pool      *EndpointPool
+	endpoint  string
 }
 
-func NewDefaultLogScanner(rpcEndpoint string) LogScanner {
+func NewDefaultLogScanner(pool *EndpointPool) LogScanner {
 	s := &defaultLogScanner{
-		rpcEndpoint: rpcEndpoint,
+		pool: pool,
 	}
 	s.createClient()
 	return s
@@ -32,17 +39,45 @@ func NewDefaultLogScanner(rpcEndpoint string) LogScanner {
 
 func (s *defaultLogScanner) createClient() {
 	var err error
-	s.ethClient, err = ethclient.Dial(s.rpcEndpoint)
+	s.endpoint = s.pool.Next()
+	s.ethClient, err = ethclient.Dial(s.endpoint)
 	if err != nil {
 		panic(err)
 	}
 }
 
 func (s *defaultLogScanner) GetLogs(ctx context.Context, txHash common.Hash) ([]*types.Log, error) {
+	receipt, err := s.GetReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("LogsByTxHash : %w", err)
+	}
+	return receipt.Logs, nil
+}
+
+func (s *defaultLogScanner) GetReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	raw, err := s.GetRawReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("GetReceipt : %w", err)
+	}
 	var receipt *types.Receipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, fmt.Errorf("GetReceipt : %w", err)
+	}
+	return receipt, nil
+}
+
+func (s *defaultLogScanner) GetRawReceipt(ctx context.Context, txHash common.Hash) (json.RawMessage, error) {
+	var raw json.RawMessage
 	var err error
 	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
-		receipt, err = s.ethClient.TransactionReceipt(ctx, txHash)
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "eth_getTransactionReceipt", s.endpoint)
+		err = s.ethClient.Client().CallContext(rpcCtx, &raw, "eth_getTransactionReceipt", txHash)
+		tracing.End(span, err)
+		s.pool.observe("eth_getTransactionReceipt", s.endpoint, start, err)
+		if err == nil && (len(raw) == 0 || string(raw) == "null") {
+			err = ethereum.NotFound
+		}
 		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
 			s.createClient()
 		}
@@ -53,9 +88,9 @@ func (s *defaultLogScanner) GetLogs(ctx context.Context, txHash common.Hash) ([]
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("LogsByTxHash : %w", err)
+		return nil, fmt.Errorf("GetRawReceipt : %w", err)
 	}
-	return receipt.Logs, nil
+	return raw, nil
 }
 
 func (s *defaultLogScanner) Shutdown() {
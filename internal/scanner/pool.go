@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"Ethereum_Service/internal/monitor"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EndpointPool hands out the RPC endpoints of a chain in turn, scanners dial
+// the next endpoint whenever they reconnect.
+type EndpointPool struct {
+	endpoints []string
+	next      uint32
+
+	mu        sync.RWMutex
+	observers []RPCObserver
+}
+
+// RPCObserver is told the latency and the result of every RPC call made
+// through the scanners of a pool.
+type RPCObserver func(method string, latency time.Duration, err error)
+
+func NewEndpointPool(endpoints []string) *EndpointPool {
+	if len(endpoints) == 0 {
+		panic("NewEndpointPool: no rpc endpoint")
+	}
+	return &EndpointPool{endpoints: endpoints}
+}
+
+func (p *EndpointPool) Next() string {
+	i := atomic.AddUint32(&p.next, 1) - 1
+	return p.endpoints[i%uint32(len(p.endpoints))]
+}
+
+// AddObserver adds o to the observers of the RPC calls of the pool.
+func (p *EndpointPool) AddObserver(o RPCObserver) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.observers = append(p.observers, o)
+}
+
+// observe records an RPC call made to endpoint in the metrics and tells the
+// observers of the pool.
+func (p *EndpointPool) observe(method, endpoint string, start time.Time, err error) {
+	monitor.ObserveRPC(method, endpoint, start, err)
+	latency := time.Since(start)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, o := range p.observers {
+		o(method, latency, err)
+	}
+}
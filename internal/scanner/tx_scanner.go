@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/tracing"
 	"context"
 	"fmt"
 	"strings"
@@ -18,13 +19,14 @@ type TxScanner interface {
 }
 
 type defaultTxScanner struct {
-	ethClient   *ethclient.Client
-	rpcEndpoint string
+	ethClient *ethclient.Client
+	pool      *EndpointPool
+	endpoint  string
 }
 
-func NewDefaultTxScanner(rpcEndpoint string) TxScanner {
+func NewDefaultTxScanner(pool *EndpointPool) TxScanner {
 	s := &defaultTxScanner{
-		rpcEndpoint: rpcEndpoint,
+		pool: pool,
 	}
 	s.createClient()
 	return s
@@ -35,7 +37,11 @@ func (s *defaultTxScanner) TxDetailByHash(ctx context.Context, txHash common.Has
 	var err error
 	var tx *types.Transaction
 	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
-		tx, isPending, err = s.ethClient.TransactionByHash(ctx, txHash)
+		start := time.Now()
+		rpcCtx, span := tracing.StartRPC(ctx, "eth_getTransactionByHash", s.endpoint)
+		tx, isPending, err = s.ethClient.TransactionByHash(rpcCtx, txHash)
+		tracing.End(span, err)
+		s.pool.observe("eth_getTransactionByHash", s.endpoint, start, err)
 		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
 			s.createClient()
 		}
@@ -53,7 +59,8 @@ func (s *defaultTxScanner) TxDetailByHash(ctx context.Context, txHash common.Has
 
 func (s *defaultTxScanner) createClient() {
 	var err error
-	s.ethClient, err = ethclient.Dial(s.rpcEndpoint)
+	s.endpoint = s.pool.Next()
+	s.ethClient, err = ethclient.Dial(s.endpoint)
 	if err != nil {
 		panic(err)
 	}
@@ -3,6 +3,7 @@ package app
 import (
 	"Ethereum_Service/c"
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
 	"context"
 	"database/sql"
 	"fmt"
@@ -25,6 +26,8 @@ type Application struct {
 	addr       string
 	db         *sql.DB
 	gormClient *gorm.DB
+	health     *monitor.Health
+	initOnce   sync.Once
 	// Init and destroy hooks
 	initHooks    []ApplicationHook
 	destroyHooks []ApplicationHook
@@ -43,6 +46,7 @@ func Default() *Application {
 		destroyHooks: make([]ApplicationHook, 0),
 		srv:          &http.Server{},
 		addr:         config.GetConfig().Service.Host + ":" + config.GetConfig().Service.Port,
+		health:       monitor.NewHealth(config.GetConfig().Service.Name),
 	}
 
 	initSlice := []ApplicationHook{initLoggerApplicationHook}
@@ -52,6 +56,7 @@ func Default() *Application {
 			panic(err)
 		}
 	}
+	o.AddInitHook(InitTracingHook)
 	o.AddInitHook(InitDatabaseHook)
 	o.AddInitHook(InitGinApplicationHook)
 
@@ -60,9 +65,14 @@ func Default() *Application {
 	return o
 }
 
+// Init runs the init hooks, Run calls it when it was not called before.
+func (app *Application) Init() {
+	app.initOnce.Do(app.callInitHooks)
+}
+
 // Run application
 func (app *Application) Run() {
-	app.callInitHooks()
+	app.Init()
 
 	errc := make(chan error)
 
@@ -181,3 +191,8 @@ func (app *Application) SetGormClient(db *gorm.DB) {
 func (app *Application) GetGormClient() *gorm.DB {
 	return app.gormClient
 }
+
+// GetHealth returns the checks served on /healthz, /readyz and /status.
+func (app *Application) GetHealth() *monitor.Health {
+	return app.health
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"Ethereum_Service/c"
 	"Ethereum_Service/config"
 	"Ethereum_Service/pkg/utils/common"
 	"Ethereum_Service/pkg/utils/logger"
@@ -12,11 +13,19 @@ import (
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	gormMysql "gorm.io/driver/mysql"
+	gormPostgres "gorm.io/driver/postgres"
 
 	"gorm.io/gorm"
 )
 
 func InitDatabaseHook(app *Application) error {
+	switch config.GetConfig().Databases.Driver {
+	case c.DriverSqlite:
+		// the sqlite file is created and migrated by the data handlers
+		return nil
+	case c.DriverPostgres:
+		return initPostgres(app)
+	}
 	db, err := common.OpenMysqlDatabase(&config.GetConfig().Databases)
 	if err != nil {
 		return fmt.Errorf("InitDatabaseHook: %s", err)
@@ -44,6 +53,25 @@ func InitGormClientHook(app *Application, db *sql.DB) error {
 	return nil
 }
 
+// initPostgres opens and migrates the PostgreSQL database.
+func initPostgres(app *Application) error {
+	db, err := common.OpenPostgresDatabase(&config.GetConfig().Databases)
+	if err != nil {
+		return fmt.Errorf("InitDatabaseHook: %s", err)
+	}
+	if err := common.PostgresMigration(db); err != nil {
+		return fmt.Errorf("InitDatabaseHook: %s", err)
+	}
+	gormClient, err := gorm.Open(gormPostgres.New(gormPostgres.Config{
+		Conn: db,
+	}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		return fmt.Errorf("InitDatabaseHook: %v", err)
+	}
+	app.gormClient = gormClient
+	return nil
+}
+
 func migration(db *sql.DB) error {
 	driver, err := mysql.WithInstance(db, &mysql.Config{})
 	if err != nil {
@@ -1,12 +1,32 @@
 package app
 
 import (
+	"Ethereum_Service/internal/monitor"
 	"Ethereum_Service/internal/services/api_service/controller"
+	"Ethereum_Service/internal/tracing"
+	"Ethereum_Service/pkg/utils/common"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var defaultController *controller.Controller
+var (
+	controllers  map[string]*controller.Controller
+	defaultChain string
+)
+
+// chainPicker selects the controller of the chain a request is made for.
+type chainPicker func(ginC *gin.Context) (*controller.Controller, bool)
 
 func InitGinApplicationHook(app *Application) error {
 	if app.IsProduction() {
@@ -15,20 +35,163 @@ func InitGinApplicationHook(app *Application) error {
 
 	gin.EnableJsonDecoderUseNumber()
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.Use(gin.Recovery(), traceRequests(), observeRequests())
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	defaultController = controller.NewController()
+	health := app.GetHealth()
+	r.GET("/healthz", gin.WrapF(health.ServeHealthz))
+	r.GET("/readyz", gin.WrapF(health.ServeReadyz))
+	r.GET("/status", gin.WrapF(health.ServeStatus))
 
-	r.GET("/transaction/:txHash", defaultController.GetTransaction)
-	r.GET("/blocks", defaultController.ListBlocks)
-	r.GET("/blocks/:id", defaultController.GetBlock)
+	// the chain names prefix the routes
+	if err := app.GetConfig().Validate(); err != nil {
+		return fmt.Errorf("InitGinApplicationHook: %w", err)
+	}
+	controllers = make(map[string]*controller.Controller)
+	for _, chain := range app.GetConfig().GetChains() {
+		if defaultChain == "" {
+			defaultChain = chain.Name
+		}
+		controllers[chain.Name] = controller.NewController(chain)
+		controllers[chain.Name].RegisterHealth(health)
+	}
+
+	v1 := r.Group("/v1",
+		controller.APIVersion(controller.APIVersionV1),
+		controller.ResponseEncoding(common.EncodingHex))
+	registerRoutes(v1, chainFromHeader)
+	for name, chainController := range controllers {
+		registerRoutes(v1.Group("/"+name), fixedChain(chainController))
+	}
+	// unversioned routes keep the legacy response shapes until clients moved to /v1
+	registerRoutes(r.Group("/",
+		deprecated("/v1"),
+		controller.APIVersion(controller.APIVersionLegacy),
+		controller.ResponseEncoding(common.EncodingRaw)), chainFromHeader)
+
+	// admin routes are only served when an admin token is configured
+	if token := app.GetConfig().AdminToken; token != "" {
+		admin := r.Group("/admin", adminAuth(token))
+		registerAdminRoutes(admin, chainFromHeader)
+		for name, chainController := range controllers {
+			registerAdminRoutes(admin.Group("/"+name), fixedChain(chainController))
+		}
+	}
 
 	app.srv.Handler = r
 
 	return nil
 }
 
+func registerRoutes(group *gin.RouterGroup, pick chainPicker) {
+	group.GET("/transaction/:txHash", onChain(pick, (*controller.Controller).GetTransaction))
+	group.GET("/blocks", onChain(pick, (*controller.Controller).ListBlocks))
+	group.GET("/blocks/:id", onChain(pick, (*controller.Controller).GetBlock))
+	group.GET("/blocks/:id/withdrawals", onChain(pick, (*controller.Controller).GetBlockWithdrawals))
+	group.GET("/address/:addr/withdrawals", onChain(pick, (*controller.Controller).GetAddressWithdrawals))
+	group.GET("/address/:addr/balance", onChain(pick, (*controller.Controller).GetBalance))
+	group.GET("/address/:addr/balance/history", onChain(pick, (*controller.Controller).GetBalanceHistory))
+}
+
+func registerAdminRoutes(group *gin.RouterGroup, pick chainPicker) {
+	group.PUT("/abi/:address", onChain(pick, (*controller.Controller).RegisterAbi))
+	group.GET("/abi/:address", onChain(pick, (*controller.Controller).GetAbi))
+	group.POST("/reindex", onChain(pick, (*controller.Controller).Reindex))
+}
+
 func DestroyGinApplicationHook(app *Application) error {
-	defaultController.Shutdown()
+	for _, chainController := range controllers {
+		chainController.Shutdown()
+	}
 	return nil
 }
+
+func onChain(pick chainPicker, handler func(*controller.Controller, *gin.Context)) gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		chainController, ok := pick(ginC)
+		if !ok {
+			ginC.JSON(404, gin.H{"error": "unknown chain"})
+			return
+		}
+		handler(chainController, ginC)
+	}
+}
+
+// chainFromHeader picks the chain named by the X-Chain header, the first
+// configured chain is used without the header.
+func chainFromHeader(ginC *gin.Context) (*controller.Controller, bool) {
+	name := ginC.GetHeader("X-Chain")
+	if name == "" {
+		name = defaultChain
+	}
+	chainController, ok := controllers[name]
+	return chainController, ok
+}
+
+func fixedChain(chainController *controller.Controller) chainPicker {
+	return func(ginC *gin.Context) (*controller.Controller, bool) {
+		return chainController, true
+	}
+}
+
+func adminAuth(token string) gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		given := ginC.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			ginC.AbortWithStatusJSON(401, gin.H{"error": "unauthorized"})
+			return
+		}
+		ginC.Next()
+	}
+}
+
+// traceRequests runs every request in a server span named by its route,
+// continuing the trace of the caller when it sent a traceparent header.
+func traceRequests() gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		route := ginC.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := otel.GetTextMapPropagator().Extract(ginC.Request.Context(), propagation.HeaderCarrier(ginC.Request.Header))
+		ctx, span := tracing.Start(ctx, ginC.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(ginC.Request.Method),
+				semconv.HTTPRoute(route),
+			))
+		defer span.End()
+
+		ginC.Request = ginC.Request.WithContext(ctx)
+		ginC.Next()
+
+		status := ginC.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// observeRequests records the latency of requests per route template, so that
+// path parameters do not end up in the labels.
+func observeRequests() gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		start := time.Now()
+		ginC.Next()
+		route := ginC.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		monitor.HTTPDuration.WithLabelValues(ginC.Request.Method, route, strconv.Itoa(ginC.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func deprecated(successor string) gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		ginC.Header("Deprecation", "true")
+		ginC.Header("Link", fmt.Sprintf("<%s%s>; rel=\"successor-version\"", successor, ginC.Request.URL.Path))
+		ginC.Next()
+	}
+}
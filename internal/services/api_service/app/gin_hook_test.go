@@ -0,0 +1,52 @@
+package app
+
+import (
+	"Ethereum_Service/internal/services/api_service/controller"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainRouting(t *testing.T) {
+	mainnet, bsc := new(controller.Controller), new(controller.Controller)
+	controllers = map[string]*controller.Controller{"mainnet": mainnet, "bsc": bsc}
+	defaultChain = "mainnet"
+	defer func() {
+		controllers = nil
+		defaultChain = ""
+	}()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var picked *controller.Controller
+	handler := func(chainController *controller.Controller, ginC *gin.Context) {
+		picked = chainController
+		ginC.Status(http.StatusOK)
+	}
+	r.GET("/blocks", onChain(chainFromHeader, handler))
+	r.GET("/bsc/blocks", onChain(fixedChain(bsc), handler))
+
+	get := func(path, chain string) int {
+		picked = nil
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if chain != "" {
+			req.Header.Set("X-Chain", chain)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// without the header the first configured chain serves the request
+	assert.Equal(t, http.StatusOK, get("/blocks", ""))
+	assert.Same(t, mainnet, picked)
+	assert.Equal(t, http.StatusOK, get("/blocks", "bsc"))
+	assert.Same(t, bsc, picked)
+	assert.Equal(t, http.StatusOK, get("/bsc/blocks", "mainnet"))
+	assert.Same(t, bsc, picked)
+	assert.Equal(t, http.StatusNotFound, get("/blocks", "polygon"))
+	assert.Nil(t, picked)
+}
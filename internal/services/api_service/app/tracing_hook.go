@@ -0,0 +1,18 @@
+package app
+
+import (
+	"Ethereum_Service/internal/tracing"
+	"fmt"
+)
+
+func InitTracingHook(app *Application) error {
+	shutdown, err := tracing.Init(app.GetConfig().Service.Name, app.GetConfig().Tracing)
+	if err != nil {
+		return fmt.Errorf("InitTracingHook: %s", err)
+	}
+	app.AddDestroyHook(func(*Application) error {
+		shutdown()
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"Ethereum_Service/internal/abiregistry"
+	"Ethereum_Service/pkg/model"
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (c *Controller) RegisterAbi(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+	address := ginC.Param("address")
+
+	var req model.AbiRequest
+	if err := ginC.ShouldBindJSON(&req); err != nil {
+		ginC.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := c.abiRegistry.Register(ctx, address, req.Name, string(req.Abi))
+	if err != nil {
+		if errors.Is(err, abiregistry.ErrInvalidAddress) || errors.Is(err, abiregistry.ErrInvalidAbi) {
+			ginC.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	abiRow, err := c.abiRegistry.Get(ctx, address)
+	if err != nil {
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	ginC.JSON(200, convertAbiRowToResp(abiRow))
+}
+
+func (c *Controller) GetAbi(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+	address := ginC.Param("address")
+
+	abiRow, err := c.abiRegistry.Get(ctx, address)
+	if err != nil {
+		if errors.Is(err, abiregistry.ErrInvalidAddress) {
+			ginC.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ginC.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	ginC.JSON(200, convertAbiRowToResp(abiRow))
+}
+
+func convertAbiRowToResp(abiRow model.AbiRow) model.AbiResponse {
+	return model.AbiResponse{
+		Address: abiRow.Address,
+		Name:    abiRow.Name,
+		Abi:     json.RawMessage(abiRow.Abi),
+	}
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"Ethereum_Service/internal/abiregistry"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// abiStore fails every lookup with err.
+type abiStore struct {
+	data.DataHandler
+	err error
+}
+
+func (s *abiStore) GetAbiRow(ctx context.Context, abiRow *model.AbiRow) error {
+	return s.err
+}
+
+func TestGetAbi(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	get := func(address string, err error) int {
+		controller := &Controller{abiRegistry: abiregistry.NewRegistry(&abiStore{err: err})}
+		r := gin.New()
+		r.GET("/abi/:address", controller.GetAbi)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/abi/"+address, nil))
+		return w.Code
+	}
+
+	contract := "0x00000000000000000000000000000000000000aa"
+	assert.Equal(t, http.StatusBadRequest, get("0x01", nil))
+	assert.Equal(t, http.StatusNotFound, get(contract, fmt.Errorf("GetAbiRow : %w", gorm.ErrRecordNotFound)))
+	// an unavailable database is not an unknown ABI
+	assert.Equal(t, http.StatusInternalServerError, get(contract, errors.New("connection refused")))
+}
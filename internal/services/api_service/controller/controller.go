@@ -2,86 +2,131 @@ package controller
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/abiregistry"
+	"Ethereum_Service/internal/chainprofile"
 	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/mq"
 	"Ethereum_Service/internal/scanner"
 	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
 	"context"
+	"errors"
 	"strconv"
+	"sync"
 
+	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gin-gonic/gin"
 )
 
 type Controller struct {
+	chain        string
+	chainOption  config.ChainOption
 	mysqlHandler data.DataHandler
 	redisHandler data.DataHandler
 	txScanner    scanner.TxScanner
 	blockScanner scanner.BlockScanner
 	logScanner   scanner.LogScanner
+	abiRegistry  *abiregistry.Registry
+	profile      chainprofile.Profile
+
+	balanceScanner scanner.BalanceScanner
 
 	ethClient *ethclient.Client
+
+	// queue publishes the jobs of the admin routes, connected on first use
+	queue   mq.Queue
+	queueMu sync.Mutex
 }
 
-func NewController() *Controller {
-	ethClient, err := ethclient.Dial(config.GetConfig().RCPEndpoint)
+// NewController creates the controller serving the data of one chain.
+func NewController(chain config.ChainOption) *Controller {
+	pool := scanner.NewEndpointPool(chain.RCPEndpoints)
+	profile, err := chainprofile.New(chain.Profile, chain.ChainId)
+	if err != nil {
+		panic(err)
+	}
+	ethClient, err := ethclient.Dial(pool.Next())
 	if err != nil {
 		panic(err)
 	}
 
-	mysqlHandler, err := data.NewMysqlHandler(&config.GetConfig().Databases)
+	mysqlHandler, err := data.NewDatabaseHandler(&config.GetConfig().Databases, chain.ChainId)
 	if err != nil {
 		panic(err)
 	}
 
-	redisHandler := data.NewRedisDataHandler()
+	// without redis the lookups of the cache go to the database
+	redisHandler := mysqlHandler
+	if config.GetConfig().Redis.Host != "" {
+		redisHandler = data.NewRedisDataHandler(chain.ChainId)
+	}
 
-	txScanner := scanner.NewDefaultTxScanner(config.GetConfig().RCPEndpoint)
-	blockScanner := scanner.NewDefaultBlockScanner(config.GetConfig().RCPEndpoint)
-	logScanner := scanner.NewDefaultLogScanner(config.GetConfig().RCPEndpoint)
+	txScanner := scanner.NewDefaultTxScanner(pool)
+	blockScanner := scanner.NewDefaultBlockScanner(pool, profile)
+	logScanner := scanner.NewDefaultLogScanner(pool)
+	balanceScanner := scanner.NewDefaultBalanceScanner(pool)
+
+	abiRegistry := abiregistry.NewRegistry(mysqlHandler)
 
 	return &Controller{
+		chain:        chain.Name,
+		chainOption:  chain,
 		ethClient:    ethClient,
 		mysqlHandler: mysqlHandler,
 		redisHandler: redisHandler,
 		txScanner:    txScanner,
 		logScanner:   logScanner,
 		blockScanner: blockScanner,
+		abiRegistry:  abiRegistry,
+		profile:      profile,
+
+		balanceScanner: balanceScanner,
 	}
 }
 
 func (c *Controller) GetTransaction(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
 	txHash := ginC.Param("txHash")
-	resp, err := c.getTransaction(txHash)
+	encoding := getEncoding(ginC)
+	resp, err := c.getTransaction(ctx, txHash, encoding)
 	if err != nil {
 		ginC.JSON(404, gin.H{"error": err.Error()})
 		return
 	}
-	logs, err := getTxLogs(c.redisHandler, txHash)
+	logs, err := c.getTxLogs(ctx, txHash)
 	if err != nil {
 		ginC.JSON(404, gin.H{"error": err.Error()})
 		return
 	}
-	resp.Logs = convertLogRowToResp(logs)
+	if len(logs) != 0 {
+		resp.Logs = convertLogRowToResp(ctx, c.abiRegistry, logs, encoding)
+	}
 	ginC.JSON(200, resp)
 }
 
-func (c *Controller) getTransaction(txHash string) (model.TxResponse, error) {
-	resp, err := getTxFromStore(c.redisHandler, txHash)
+func (c *Controller) getTransaction(ctx context.Context, txHash, encoding string) (model.TxResponse, error) {
+	resp, err := getTxFromStore(ctx, c.redisHandler, c.abiRegistry, txHash, encoding)
 	if err == nil && resp.TxHash != "" {
 		return resp, err
 	}
-	resp, err = getTxFromRPC(c.txScanner, c.logScanner, c.ethClient, c.mysqlHandler, c.redisHandler, txHash)
+	resp, err = getPendingTxFromStore(ctx, c.mysqlHandler, c.abiRegistry, txHash, encoding)
+	if err == nil && resp.TxHash != "" {
+		return resp, err
+	}
+	resp, err = getTxFromRPC(ctx, c.txScanner, c.logScanner, c.ethClient, c.mysqlHandler, c.redisHandler, c.abiRegistry, c.profile, txHash, encoding)
 	return resp, err
 
 }
 
-func (c *Controller) getTxLogs(txHash string) ([]model.LogRow, error) {
-	logRows, err := getTxLogs(c.redisHandler, txHash)
+func (c *Controller) getTxLogs(ctx context.Context, txHash string) ([]model.LogRow, error) {
+	logRows, err := getTxLogs(ctx, c.redisHandler, txHash)
 	if err == nil && len(logRows) != 0 {
 		return logRows, err
 	}
-	logRows, err = getTxLogs(c.mysqlHandler, txHash)
+	logRows, err = getTxLogs(ctx, c.mysqlHandler, txHash)
 	if err != nil {
 		return nil, err
 	}
@@ -89,71 +134,123 @@ func (c *Controller) getTxLogs(txHash string) ([]model.LogRow, error) {
 }
 
 func (c *Controller) ListBlocks(ginC *gin.Context) {
-	limit := ginC.Query("limit")
-	limitUint, err := strconv.ParseUint(limit, 10, 64)
+	ctx := ginC.Request.Context()
+	query, err := parseBlockListQuery(ginC)
 	if err != nil {
 		ginC.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	resp, err := c.listBlocks(limitUint)
+	resp, err := c.listBlocks(ctx, query)
 	if err != nil {
 		ginC.JSON(404, gin.H{"error": err.Error()})
 		return
 	}
+	if getAPIVersion(ginC) == APIVersionLegacy {
+		ginC.JSON(200, resp.Data)
+		return
+	}
 	ginC.JSON(200, resp)
 }
 func (c *Controller) GetBlock(ginC *gin.Context) {
-	blockId := ginC.Param("id")
-
-	blockNum, err := strconv.ParseInt(blockId, 10, 64)
+	ctx := ginC.Request.Context()
+	encoding := getEncoding(ginC)
+	id, err := resolveBlockId(ctx, c.ethClient, ginC.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidBlockId) {
+			ginC.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		ginC.JSON(502, gin.H{"error": err.Error()})
+		return
+	}
+	full, err := strconv.ParseBool(ginC.DefaultQuery("full", "false"))
 	if err != nil {
 		ginC.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	resp, err := c.getBlockDetail(blockNum)
+
+	resp, err := c.getBlockDetail(ctx, id, encoding)
 	if err != nil {
 		ginC.JSON(404, gin.H{"error": err.Error()})
 		return
 	}
 
-	txHashs, err := c.getBlockTx(blockNum)
+	txHashs, err := c.getBlockTx(ctx, resp.BlockNum)
 	if err != nil {
 		ginC.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
 	resp.Transactions = txHashs
+
+	if full {
+		txObjects, err := c.getBlockTxObjects(ctx, resp.BlockNum, encoding)
+		if err != nil {
+			ginC.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		resp.TransactionObjects = txObjects
+	}
 	ginC.JSON(200, resp)
 
 }
-func (c *Controller) Shutdown() {}
+func (c *Controller) Shutdown() {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	if c.queue != nil {
+		c.queue.Close()
+	}
+}
 
-func (c *Controller) getBlockDetail(blockNumber int64) (model.BlockResponseWithTx, error) {
-	resp, err := getBlockFromStore(c.redisHandler, blockNumber)
-	if err == nil && resp.BlockHash != "" {
-		return resp, err
+// RegisterHealth adds the dependencies of the controller and the progress of
+// its chain to health.
+func (c *Controller) RegisterHealth(health *monitor.Health) {
+	health.AddCheck(c.chain+"/mysql", c.mysqlHandler.Ping)
+	if c.redisHandler != c.mysqlHandler {
+		health.AddCheck(c.chain+"/redis", c.redisHandler.Ping)
 	}
+	health.AddCheck(c.chain+"/rpc", monitor.RPCCheck(c.ethClient))
+	health.AddStatus(func(ctx context.Context) monitor.ChainStatus {
+		status := monitor.ChainStatus{Chain: c.chain}
+		if head, err := c.ethClient.BlockNumber(ctx); err == nil {
+			status.Head = int64(head)
+		}
+		if watermark, err := c.mysqlHandler.GetLatestBlockNumber(ctx); err == nil {
+			status.Watermark = watermark
+		}
+		return status
+	})
+}
 
-	resp, err = getBlockFromStore(c.mysqlHandler, blockNumber)
+func (c *Controller) getBlockDetail(ctx context.Context, id blockId, encoding string) (model.BlockResponseWithTx, error) {
+	// the redis cache is keyed by block number only
+	if id.hash == "" {
+		resp, err := getBlockFromStore(ctx, c.redisHandler, id, encoding)
+		if err == nil && resp.BlockHash != "" {
+			return resp, err
+		}
+	}
+
+	resp, err := getBlockFromStore(ctx, c.mysqlHandler, id, encoding)
 	if err == nil && resp.BlockHash != "" {
 		return resp, err
 	}
-	resp, err = getBlockFromRPC(c.blockScanner, c.mysqlHandler, c.redisHandler, blockNumber)
+	resp, err = getBlockFromRPC(ctx, c.blockScanner, c.mysqlHandler, c.redisHandler, c.profile, id, encoding)
 	if err != nil {
 		return model.BlockResponseWithTx{}, err
 	}
 	return resp, nil
 }
-func (c *Controller) getBlockTx(blockNumber int64) ([]string, error) {
-	txHashs, err := getTxHashFromStore(c.redisHandler, blockNumber)
+func (c *Controller) getBlockTx(ctx context.Context, blockNumber int64) ([]string, error) {
+	txHashs, err := getTxHashFromStore(ctx, c.redisHandler, blockNumber)
 	if err == nil && len(txHashs) != 0 {
 		return txHashs, err
 	}
-	txHashs, err = getTxHashFromStore(c.mysqlHandler, blockNumber)
+	txHashs, err = getTxHashFromStore(ctx, c.mysqlHandler, blockNumber)
 	if err == nil && len(txHashs) != 0 {
 		return txHashs, err
 	}
-	txHashs, err = getTxHashFromRPC(c.blockScanner, blockNumber)
+	txHashs, err = getTxHashFromRPC(ctx, c.blockScanner, blockNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -161,53 +258,95 @@ func (c *Controller) getBlockTx(blockNumber int64) ([]string, error) {
 
 }
 
-func (c *Controller) listBlocks(limit uint64) ([]model.BlockResponse, error) {
-	latestBlockNumber, err := c.ethClient.BlockNumber(context.Background())
+func (c *Controller) getBlockTxObjects(ctx context.Context, blockNumber int64, encoding string) ([]model.TxResponse, error) {
+	txObjects, err := getTxObjectsFromStore(ctx, c.mysqlHandler, c.abiRegistry, blockNumber, encoding)
+	if err == nil && len(txObjects) != 0 {
+		return txObjects, err
+	}
+	return getTxObjectsFromRPC(ctx, c.blockScanner, c.abiRegistry, c.profile, blockNumber, encoding)
+}
+
+func (c *Controller) listBlocks(ctx context.Context, query blockListQuery) (model.BlockListResponse, error) {
+	watermark, err := c.mysqlHandler.GetLatestBlockNumber(ctx)
 	if err != nil {
-		return nil, err
+		return model.BlockListResponse{}, err
 	}
-	resp, err := listBlocksFromStore(c.redisHandler, latestBlockNumber, limit)
-	if len(resp) == int(limit) && err == nil {
+	window, ok := resolveBlockWindow(query, watermark)
+	if !ok {
+		return model.BlockListResponse{Data: []model.BlockResponse{}}, nil
+	}
+
+	resp := model.BlockListResponse{}
+	if window.next >= 0 {
+		resp.Next = strconv.FormatInt(window.next, 10)
+	}
+	if window.prev >= 0 {
+		resp.Prev = strconv.FormatInt(window.prev, 10)
+	}
+
+	numbers := window.numbers()
+	resp.Data, err = listBlocksFromStore(ctx, c.redisHandler, numbers)
+	if len(resp.Data) == len(numbers) && err == nil {
 		return resp, err
 	}
 
-	resp, err = listBlocksFromStore(c.mysqlHandler, latestBlockNumber, limit)
-	if len(resp) == int(limit) && err == nil {
+	resp.Data, err = listBlocksFromStore(ctx, c.mysqlHandler, numbers)
+	if len(resp.Data) == len(numbers) && err == nil {
 		return resp, err
 	}
-	resp, err = listBlocksFromRPC(c.mysqlHandler, c.redisHandler, c.blockScanner, latestBlockNumber, limit)
+	resp.Data, err = listBlocksFromRPC(ctx, c.mysqlHandler, c.redisHandler, c.blockScanner, c.profile, numbers)
+	if err != nil {
+		return model.BlockListResponse{}, err
+	}
 	return resp, nil
 }
 
-func convertLogRowToResp(logRows []model.LogRow) []model.LogResponse {
+func convertLogRowToResp(ctx context.Context, abiRegistry *abiregistry.Registry, logRows []model.LogRow, encoding string) []model.LogResponse {
 	resp := make([]model.LogResponse, 0, len(logRows))
 	for _, logRow := range logRows {
+		topics := common.SplitTopics(logRow.Topics)
 		resp = append(resp, model.LogResponse{
-			Index: logRow.Index,
-			Data:  string(logRow.Data),
+			Index:   logRow.Index,
+			Address: logRow.Address,
+			Topics:  hashesToHex(topics),
+			Data:    common.EncodeBytes(logRow.Data, encoding),
+			Decoded: abiRegistry.DecodeLog(ctx, logRow.Address, topics, logRow.Data),
 		})
 	}
 	return resp
 }
 
-func convertTypeLogToResp(logs []*types.Log) []model.LogResponse {
+func convertTypeLogToResp(ctx context.Context, abiRegistry *abiregistry.Registry, logs []*types.Log, encoding string) []model.LogResponse {
 	resp := make([]model.LogResponse, 0, len(logs))
 	for _, log := range logs {
 		resp = append(resp, model.LogResponse{
-			Index: log.Index,
-			Data:  string(log.Data),
+			Index:   log.Index,
+			Address: log.Address.Hex(),
+			Topics:  hashesToHex(log.Topics),
+			Data:    common.EncodeBytes(log.Data, encoding),
+			Decoded: abiRegistry.DecodeLog(ctx, log.Address.Hex(), log.Topics, log.Data),
 		})
 	}
 	return resp
 }
 
+func hashesToHex(hashes []ethCommon.Hash) []string {
+	result := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		result = append(result, hash.Hex())
+	}
+	return result
+}
+
 func convertTypeLogToRow(logs []*types.Log) []*model.LogRow {
 	resp := make([]*model.LogRow, 0, len(logs))
 	for _, log := range logs {
 		resp = append(resp, &model.LogRow{
-			TxHash: log.TxHash.Hex(),
-			Index:  log.Index,
-			Data:   log.Data,
+			TxHash:  log.TxHash.Hex(),
+			Index:   log.Index,
+			Address: log.Address.Hex(),
+			Topics:  common.JoinTopics(log.Topics),
+			Data:    log.Data,
 		})
 	}
 	return resp
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"Ethereum_Service/pkg/utils/common"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const encodingContextKey = "encoding"
+
+// ResponseEncoding sets how bytes fields of the responses are encoded, clients
+// can override the default of the route group with ?encoding=hex|base64. Raw
+// strings are not valid JSON for arbitrary bytes, only the legacy routes
+// defaulting to raw accept ?encoding=raw.
+func ResponseEncoding(defaultEncoding string) gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		encoding := ginC.DefaultQuery("encoding", defaultEncoding)
+		if !common.IsValidEncoding(encoding) || (encoding == common.EncodingRaw && defaultEncoding != common.EncodingRaw) {
+			ginC.AbortWithStatusJSON(400, gin.H{"error": fmt.Sprintf("unsupported encoding: %s", encoding)})
+			return
+		}
+		ginC.Set(encodingContextKey, encoding)
+		ginC.Next()
+	}
+}
+
+func getEncoding(ginC *gin.Context) string {
+	return ginC.GetString(encodingContextKey)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"Ethereum_Service/pkg/utils/common"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseEncoding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := func(ginC *gin.Context) {
+		ginC.String(http.StatusOK, getEncoding(ginC))
+	}
+	r.GET("/v1/tx", ResponseEncoding(common.EncodingHex), handler)
+	r.GET("/tx", ResponseEncoding(common.EncodingRaw), handler)
+	get := func(path string) (int, string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code, w.Body.String()
+	}
+
+	code, encoding := get("/v1/tx")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, common.EncodingHex, encoding)
+	code, encoding = get("/v1/tx?encoding=base64")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, common.EncodingBase64, encoding)
+	code, _ = get("/v1/tx?encoding=raw")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("/v1/tx?encoding=utf8")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, encoding = get("/tx")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, common.EncodingRaw, encoding)
+	code, encoding = get("/tx?encoding=hex")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, common.EncodingHex, encoding)
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"math"
+	"math/big"
+	"strconv"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	balanceSourceStore = "store"
+	balanceSourceRPC   = "rpc"
+)
+
+func (c *Controller) GetBalance(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+	address := ginC.Param("addr")
+	if !ethCommon.IsHexAddress(address) {
+		ginC.JSON(400, gin.H{"error": "invalid address"})
+		return
+	}
+	address = ethCommon.HexToAddress(address).Hex()
+
+	var blockNumber int64 = -1
+	if raw, ok := ginC.GetQuery("block"); ok {
+		number, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || number < 0 {
+			ginC.JSON(400, gin.H{"error": "block must be a non-negative integer"})
+			return
+		}
+		blockNumber = number
+	}
+
+	resp, err := getBalanceFromStore(ctx, c.mysqlHandler, address, blockNumber)
+	if err == nil && resp.Balance != "" {
+		ginC.JSON(200, resp)
+		return
+	}
+	resp, err = getBalanceFromRPC(ctx, c.balanceScanner, address, blockNumber)
+	if err != nil {
+		ginC.JSON(502, gin.H{"error": err.Error()})
+		return
+	}
+	ginC.JSON(200, resp)
+}
+
+func (c *Controller) GetBalanceHistory(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+	address := ginC.Param("addr")
+	if !ethCommon.IsHexAddress(address) {
+		ginC.JSON(400, gin.H{"error": "invalid address"})
+		return
+	}
+	before, err := strconv.ParseInt(ginC.DefaultQuery("before", strconv.FormatInt(math.MaxInt64, 10)), 10, 64)
+	if err != nil || before < 0 {
+		ginC.JSON(400, gin.H{"error": "before must be a non-negative integer"})
+		return
+	}
+	limit, err := strconv.Atoi(ginC.DefaultQuery("limit", strconv.Itoa(defaultListLimit)))
+	if err != nil || limit <= 0 {
+		ginC.JSON(400, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	balanceRows, err := c.mysqlHandler.GetBalanceRowsByAddress(ctx, ethCommon.HexToAddress(address).Hex(), before, limit)
+	if err != nil {
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := make([]model.BalanceChangeResponse, 0, len(balanceRows))
+	for _, balanceRow := range balanceRows {
+		resp = append(resp, model.BalanceChangeResponse{
+			BlockNum: balanceRow.BlockNumber,
+			Delta:    balanceRow.Delta,
+			Balance:  balanceRow.Balance,
+			Nonce:    balanceRow.Nonce,
+		})
+	}
+	ginC.JSON(200, resp)
+}
+
+// getBalanceFromStore returns the stored balance at blockNumber, a
+// negative blockNumber means the latest indexed block.
+func getBalanceFromStore(ctx context.Context, dataHandler data.DataHandler, address string, blockNumber int64) (model.BalanceResponse, error) {
+	if blockNumber < 0 {
+		blockNumber = math.MaxInt64
+	}
+	balanceRow, err := dataHandler.GetBalanceRow(ctx, address, blockNumber)
+	if err != nil {
+		return model.BalanceResponse{}, err
+	}
+	return model.BalanceResponse{
+		Address:  address,
+		BlockNum: balanceRow.BlockNumber,
+		Balance:  balanceRow.Balance,
+		Nonce:    balanceRow.Nonce,
+		Source:   balanceSourceStore,
+	}, nil
+}
+
+func getBalanceFromRPC(ctx context.Context, balanceScanner scanner.BalanceScanner, address string, blockNumber int64) (model.BalanceResponse, error) {
+	var number *big.Int
+	if blockNumber >= 0 {
+		number = big.NewInt(blockNumber)
+	}
+	balance, err := balanceScanner.BalanceAt(ctx, ethCommon.HexToAddress(address), number)
+	if err != nil {
+		return model.BalanceResponse{}, err
+	}
+	nonce, err := balanceScanner.NonceAt(ctx, ethCommon.HexToAddress(address), number)
+	if err != nil {
+		return model.BalanceResponse{}, err
+	}
+	return model.BalanceResponse{
+		Address:  address,
+		BlockNum: blockNumber,
+		Balance:  balance.String(),
+		Nonce:    &nonce,
+		Source:   balanceSourceRPC,
+	}, nil
+}
@@ -1,68 +1,104 @@
 package controller
 
 import (
+	"Ethereum_Service/internal/abiregistry"
+	"Ethereum_Service/internal/chainprofile"
+	"Ethereum_Service/internal/convert"
 	"Ethereum_Service/internal/data"
 	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/internal/tracing"
 	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	ErrInvalidBlockId = errors.New("block id must be a number, a block hash, latest, finalized or safe")
 )
 
-func getBlockFromStore(dataHandler data.DataHandler, blockNumber int64) (model.BlockResponseWithTx, error) {
+// blockId identifies a block either by number or by hash.
+type blockId struct {
+	number int64
+	hash   string
+}
+
+var blockTags = map[string]rpc.BlockNumber{
+	"latest":    rpc.LatestBlockNumber,
+	"finalized": rpc.FinalizedBlockNumber,
+	"safe":      rpc.SafeBlockNumber,
+}
+
+// resolveBlockId parses a block number, a 0x block hash or a block tag, tags
+// are resolved to a number through the RPC endpoint.
+func resolveBlockId(ctx context.Context, ethClient *ethclient.Client, id string) (blockId, error) {
+	if tag, ok := blockTags[strings.ToLower(id)]; ok {
+		header, err := ethClient.HeaderByNumber(ctx, big.NewInt(tag.Int64()))
+		if err != nil {
+			return blockId{}, fmt.Errorf("resolveBlockId: %w", err)
+		}
+		return blockId{number: header.Number.Int64()}, nil
+	}
+
+	if strings.HasPrefix(id, "0x") {
+		if len(id) != 2+2*ethCommon.HashLength {
+			return blockId{}, ErrInvalidBlockId
+		}
+		return blockId{hash: ethCommon.HexToHash(id).Hex()}, nil
+	}
+
+	number, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || number < 0 {
+		return blockId{}, ErrInvalidBlockId
+	}
+	return blockId{number: number}, nil
+}
+
+func getBlockFromStore(ctx context.Context, dataHandler data.DataHandler, id blockId, encoding string) (model.BlockResponseWithTx, error) {
 	blockRow := model.BlockRow{
-		Number: blockNumber,
+		Number: id.number,
+		Hash:   id.hash,
 	}
-	err := dataHandler.GetBlockRow(context.Background(), &blockRow)
+	err := dataHandler.GetBlockRow(ctx, &blockRow)
 	if err != nil {
 		return model.BlockResponseWithTx{}, err
 	}
 
-	resp := model.BlockResponseWithTx{
-		BlockNum:     blockNumber,
-		BlockHash:    blockRow.Hash,
-		ParentHash:   blockRow.ParentHash,
-		BlockTime:    blockRow.Time,
-		Transactions: []string{},
-	}
-
-	return resp, err
+	return convertBlockRowToResp(blockRow, encoding), err
 }
 
-func getBlockFromRPC(blockScanner scanner.BlockScanner, mysqlHandler, redisHandler data.DataHandler, blockNumber int64) (model.BlockResponseWithTx, error) {
-	blockNumBig := big.NewInt(blockNumber)
-	block, err := blockScanner.BlockByNumber(context.Background(), blockNumBig)
+func getBlockFromRPC(ctx context.Context, blockScanner scanner.BlockScanner, mysqlHandler, redisHandler data.DataHandler, profile chainprofile.Profile, id blockId, encoding string) (model.BlockResponseWithTx, error) {
+	var block *types.Block
+	var err error
+	if id.hash != "" {
+		block, err = blockScanner.BlockByHash(ctx, ethCommon.HexToHash(id.hash))
+	} else {
+		block, err = blockScanner.BlockByNumber(ctx, big.NewInt(id.number))
+	}
 	if err != nil {
 		return model.BlockResponseWithTx{}, err
 	}
-	resp := model.BlockResponseWithTx{
-		BlockNum:     blockNumber,
-		BlockHash:    block.Hash().Hex(),
-		ParentHash:   block.ParentHash().Hex(),
-		BlockTime:    block.Time(),
-		Transactions: []string{},
-	}
 
-	blockRow := model.BlockRow{
-		Hash:       block.Hash().Hex(),
-		Number:     (*block.Number()).Int64(),
-		GasLimit:   block.GasLimit(),
-		GasUsed:    block.GasUsed(),
-		Difficulty: (*block.Difficulty()).Int64(),
-		Time:       block.Time(),
-		Nonce:      block.Nonce(),
-		Root:       block.Root().Hex(),
-		ParentHash: block.ParentHash().Hex(),
-		TxHash:     block.TxHash().Hex(),
-		UncleHash:  block.UncleHash().Hex(),
-		Extra:      block.Extra(),
-	}
-	go mysqlHandler.SaveBlockRows(context.Background(), []*model.BlockRow{&blockRow})
-	go redisHandler.SaveBlockRows(context.Background(), []*model.BlockRow{&blockRow})
-	return resp, nil
+	blockRow, err := convert.BlockToRow(block, profile)
+	if err != nil {
+		return model.BlockResponseWithTx{}, err
+	}
+	go mysqlHandler.SaveBlockRows(tracing.Detach(ctx), []*model.BlockRow{&blockRow})
+	go redisHandler.SaveBlockRows(tracing.Detach(ctx), []*model.BlockRow{&blockRow})
+	return convertBlockRowToResp(blockRow, encoding), nil
 }
 
-func getTxHashFromStore(dataHandler data.DataHandler, blockNumber int64) ([]string, error) {
-	txRows, err := dataHandler.GetTransactionRowByBlockNumber(context.Background(), blockNumber)
+func getTxHashFromStore(ctx context.Context, dataHandler data.DataHandler, blockNumber int64) ([]string, error) {
+	txRows, err := dataHandler.GetTransactionRowByBlockNumber(ctx, blockNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -73,9 +109,9 @@ func getTxHashFromStore(dataHandler data.DataHandler, blockNumber int64) ([]stri
 	return txHashes, err
 }
 
-func getTxHashFromRPC(blockScanner scanner.BlockScanner, blockNumber int64) ([]string, error) {
+func getTxHashFromRPC(ctx context.Context, blockScanner scanner.BlockScanner, blockNumber int64) ([]string, error) {
 	blockNumBig := big.NewInt(blockNumber)
-	block, err := blockScanner.BlockByNumber(context.Background(), blockNumBig)
+	block, err := blockScanner.BlockByNumber(ctx, blockNumBig)
 	if err != nil {
 		return nil, err
 	}
@@ -87,3 +123,80 @@ func getTxHashFromRPC(blockScanner scanner.BlockScanner, blockNumber int64) ([]s
 	return txHashes, nil
 
 }
+
+func getTxObjectsFromStore(ctx context.Context, dataHandler data.DataHandler, abiRegistry *abiregistry.Registry, blockNumber int64, encoding string) ([]model.TxResponse, error) {
+	txRows, err := dataHandler.GetTransactionRowByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]model.TxResponse, 0, len(txRows))
+	for _, txRow := range txRows {
+		resp = append(resp, model.TxResponse{
+			TxHash:  txRow.Hash,
+			From:    txRow.From,
+			To:      txRow.To,
+			Nonce:   txRow.Nonce,
+			Type:    txRow.Type,
+			Value:   strconv.FormatInt(txRow.Value, 10),
+			Data:    common.EncodeBytes(txRow.Data, encoding),
+			Decoded: abiRegistry.DecodeInput(ctx, txRow.To, txRow.Data),
+
+			L1Fee:      txRow.L1Fee,
+			L1GasPrice: txRow.L1GasPrice,
+			L1GasUsed:  txRow.L1GasUsed,
+		})
+	}
+	return resp, nil
+}
+
+func getTxObjectsFromRPC(ctx context.Context, blockScanner scanner.BlockScanner, abiRegistry *abiregistry.Registry, profile chainprofile.Profile, blockNumber int64, encoding string) ([]model.TxResponse, error) {
+	block, err := blockScanner.BlockByNumber(ctx, big.NewInt(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]model.TxResponse, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		from, err := profile.TxSender(tx)
+		if err != nil {
+			return nil, fmt.Errorf("getTxObjectsFromRPC : %w", err)
+		}
+		txResp := model.TxResponse{
+			TxHash: tx.Hash().Hex(),
+			From:   from.Hex(),
+			Nonce:  tx.Nonce(),
+			Type:   tx.Type(),
+			Value:  tx.Value().String(),
+			Data:   common.EncodeBytes(tx.Data(), encoding),
+		}
+		if tx.To() != nil {
+			txResp.To = tx.To().Hex()
+		}
+		txResp.Decoded = abiRegistry.DecodeInput(ctx, txResp.To, tx.Data())
+		resp = append(resp, txResp)
+	}
+	return resp, nil
+}
+
+func convertBlockRowToResp(blockRow model.BlockRow, encoding string) model.BlockResponseWithTx {
+	return model.BlockResponseWithTx{
+		BlockNum:         blockRow.Number,
+		BlockHash:        blockRow.Hash,
+		BlockTime:        blockRow.Time,
+		ParentHash:       blockRow.ParentHash,
+		Miner:            blockRow.Miner,
+		GasLimit:         blockRow.GasLimit,
+		GasUsed:          blockRow.GasUsed,
+		Difficulty:       blockRow.Difficulty,
+		Nonce:            blockRow.Nonce,
+		StateRoot:        blockRow.Root,
+		TransactionsRoot: blockRow.TxHash,
+		UncleHash:        blockRow.UncleHash,
+		Extra:            common.EncodeBytes(blockRow.Extra, encoding),
+		BaseFee:          blockRow.BaseFee,
+		WithdrawalsRoot:  blockRow.WithdrawalsRoot,
+		BlobGasUsed:      blockRow.BlobGasUsed,
+		ExcessBlobGas:    blockRow.ExcessBlobGas,
+		ParentBeaconRoot: blockRow.ParentBeaconRoot,
+		Transactions:     []string{},
+	}
+}
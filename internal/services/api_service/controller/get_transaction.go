@@ -1,65 +1,115 @@
 package controller
 
 import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/internal/abiregistry"
+	"Ethereum_Service/internal/chainprofile"
 	"Ethereum_Service/internal/data"
 	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/internal/tracing"
 	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
 	"context"
 	"fmt"
 	"strconv"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
+	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
-func getTxFromStore(dataHandler data.DataHandler, txHash string) (model.TxResponse, error) {
+func getTxFromStore(ctx context.Context, dataHandler data.DataHandler, abiRegistry *abiregistry.Registry, txHash, encoding string) (model.TxResponse, error) {
 	txRow := model.TransactionRow{
 		Hash: txHash,
 	}
-	err := dataHandler.GetTransactionRow(context.Background(), &txRow)
+	err := dataHandler.GetTransactionRow(ctx, &txRow)
 	if err != nil {
 		return model.TxResponse{}, err
 	}
 
 	resp := model.TxResponse{
-		TxHash: txRow.Hash,
-		From:   txRow.From,
-		To:     txRow.To,
-		Value:  strconv.FormatInt(txRow.Value, 10),
-		Data:   string(txRow.Data),
+		TxHash:  txRow.Hash,
+		From:    txRow.From,
+		To:      txRow.To,
+		Value:   strconv.FormatInt(txRow.Value, 10),
+		Nonce:   txRow.Nonce,
+		Type:    txRow.Type,
+		Status:  c.TxStatusMined,
+		Data:    common.EncodeBytes(txRow.Data, encoding),
+		Decoded: abiRegistry.DecodeInput(ctx, txRow.To, txRow.Data),
+
+		L1Fee:      txRow.L1Fee,
+		L1GasPrice: txRow.L1GasPrice,
+		L1GasUsed:  txRow.L1GasUsed,
 	}
 
-	logs, err := dataHandler.GetLogRowByTxHash(context.Background(), txHash)
-	resp.Logs = convertLogRowToResp(logs)
+	logs, err := dataHandler.GetLogRowByTxHash(ctx, txHash)
+	resp.Logs = convertLogRowToResp(ctx, abiRegistry, logs, encoding)
 	return resp, err
 }
 
-func getTxLogs(dataHandler data.DataHandler, txHash string) ([]model.LogRow, error) {
-	logs, err := dataHandler.GetLogRowByTxHash(context.Background(), txHash)
+// getPendingTxFromStore returns a pending or dropped transaction seen by the
+// mempool watcher, mined ones are left to the indexed data and the RPC endpoint.
+func getPendingTxFromStore(ctx context.Context, dataHandler data.DataHandler, abiRegistry *abiregistry.Registry, txHash, encoding string) (model.TxResponse, error) {
+	pendingTxRow := model.PendingTxRow{
+		Hash: ethCommon.HexToHash(txHash).Hex(),
+	}
+	err := dataHandler.GetPendingTxRow(ctx, &pendingTxRow)
+	if err != nil {
+		return model.TxResponse{}, err
+	}
+	if pendingTxRow.Status == c.TxStatusMined {
+		return model.TxResponse{}, nil
+	}
+
+	return model.TxResponse{
+		TxHash:  pendingTxRow.Hash,
+		From:    pendingTxRow.From,
+		To:      pendingTxRow.To,
+		Value:   pendingTxRow.Value,
+		Nonce:   pendingTxRow.Nonce,
+		Status:  pendingTxRow.Status,
+		Data:    common.EncodeBytes(pendingTxRow.Data, encoding),
+		Decoded: abiRegistry.DecodeInput(ctx, pendingTxRow.To, pendingTxRow.Data),
+		Logs:    []model.LogResponse{},
+	}, nil
+}
+
+func getTxLogs(ctx context.Context, dataHandler data.DataHandler, txHash string) ([]model.LogRow, error) {
+	logs, err := dataHandler.GetLogRowByTxHash(ctx, txHash)
 
 	return logs, err
 }
-func getTxFromRPC(txScanner scanner.TxScanner, logScanner scanner.LogScanner, ethClient *ethclient.Client, mysqlHandler data.DataHandler, redisHandler data.DataHandler, txHash string) (model.TxResponse, error) {
-	hash := common.HexToHash(txHash)
-	tx, isPending, err := txScanner.TxDetailByHash(context.Background(), hash)
+func getTxFromRPC(ctx context.Context, txScanner scanner.TxScanner, logScanner scanner.LogScanner, ethClient *ethclient.Client, mysqlHandler data.DataHandler, redisHandler data.DataHandler, abiRegistry *abiregistry.Registry, profile chainprofile.Profile, txHash, encoding string) (model.TxResponse, error) {
+	hash := ethCommon.HexToHash(txHash)
+	tx, isPending, err := txScanner.TxDetailByHash(ctx, hash)
 	if err != nil {
 		return model.TxResponse{}, err
 	}
 
-	from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+	from, err := profile.TxSender(tx)
 	if err != nil {
 		return model.TxResponse{}, fmt.Errorf("getTxFromRPC : %w", err)
 	}
 	resp := model.TxResponse{
-		TxHash: tx.Hash().Hex(),
-		From:   from.Hex(),
-		To:     tx.To().Hex(),
-		Value:  tx.Value().String(),
-		Data:   string(tx.Data()),
+		TxHash:  tx.Hash().Hex(),
+		From:    from.Hex(),
+		To:      tx.To().Hex(),
+		Value:   tx.Value().String(),
+		Nonce:   tx.Nonce(),
+		Type:    tx.Type(),
+		Status:  c.TxStatusMined,
+		Data:    common.EncodeBytes(tx.Data(), encoding),
+		Decoded: abiRegistry.DecodeInput(ctx, tx.To().Hex(), tx.Data()),
+	}
+
+	// pending transactions have no receipt yet and are not stored
+	if isPending {
+		resp.Status = c.TxStatusPending
+		resp.Logs = []model.LogResponse{}
+		return resp, nil
 	}
 
-	receipt, err := ethClient.TransactionReceipt(context.Background(), hash)
+	receipt, err := ethClient.TransactionReceipt(ctx, hash)
 	if err != nil {
 		return model.TxResponse{}, fmt.Errorf("getTxFromRPC : %w", err)
 	}
@@ -71,21 +121,20 @@ func getTxFromRPC(txScanner scanner.TxScanner, logScanner scanner.LogScanner, et
 		Value:       tx.Value().Int64(),
 		Data:        tx.Data(),
 		Nonce:       tx.Nonce(),
+		Type:        tx.Type(),
 		BlockNumber: receipt.BlockNumber.Int64(),
 	}
-	go mysqlHandler.SaveTransactionRow(context.Background(), []*model.TransactionRow{&txRow})
-	go redisHandler.SaveTransactionRow(context.Background(), []*model.TransactionRow{&txRow})
-
-	if !isPending {
-		logs, err := logScanner.GetLogs(context.Background(), hash)
-		if err != nil {
-			return model.TxResponse{}, fmt.Errorf("getTxFromRPC : %w", err)
-		}
-		resp.Logs = convertTypeLogToResp(logs)
-		logRows := convertTypeLogToRow(logs)
-		go mysqlHandler.SaveLogRow(context.Background(), logRows)
-		go redisHandler.SaveLogRow(context.Background(), logRows)
+	go mysqlHandler.SaveTransactionRow(tracing.Detach(ctx), []*model.TransactionRow{&txRow})
+	go redisHandler.SaveTransactionRow(tracing.Detach(ctx), []*model.TransactionRow{&txRow})
+
+	logs, err := logScanner.GetLogs(ctx, hash)
+	if err != nil {
+		return model.TxResponse{}, fmt.Errorf("getTxFromRPC : %w", err)
 	}
+	resp.Logs = convertTypeLogToResp(ctx, abiRegistry, logs, encoding)
+	logRows := convertTypeLogToRow(logs)
+	go mysqlHandler.SaveLogRow(tracing.Detach(ctx), logRows)
+	go redisHandler.SaveLogRow(tracing.Detach(ctx), logRows)
 
 	return resp, nil
 }
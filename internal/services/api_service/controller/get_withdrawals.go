@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/internal/tracing"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *Controller) GetBlockWithdrawals(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+	id, err := resolveBlockId(ctx, c.ethClient, ginC.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidBlockId) {
+			ginC.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		ginC.JSON(502, gin.H{"error": err.Error()})
+		return
+	}
+	block, err := c.getBlockDetail(ctx, id, getEncoding(ginC))
+	if err != nil {
+		ginC.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := getWithdrawalsFromStore(ctx, c.mysqlHandler, block.BlockNum)
+	if err == nil && len(resp) != 0 {
+		ginC.JSON(200, resp)
+		return
+	}
+	resp, err = getWithdrawalsFromRPC(ctx, c.blockScanner, c.mysqlHandler, block.BlockNum)
+	if err != nil {
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	ginC.JSON(200, resp)
+}
+
+func (c *Controller) GetAddressWithdrawals(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+	address := ginC.Param("addr")
+	if !ethCommon.IsHexAddress(address) {
+		ginC.JSON(400, gin.H{"error": "invalid address"})
+		return
+	}
+	limit, err := strconv.Atoi(ginC.DefaultQuery("limit", strconv.Itoa(defaultListLimit)))
+	if err != nil || limit <= 0 {
+		ginC.JSON(400, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	withdrawalRows, err := c.mysqlHandler.GetWithdrawalRowsByAddress(ctx, ethCommon.HexToAddress(address).Hex(), limit)
+	if err != nil {
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	ginC.JSON(200, convertWithdrawalRowsToResp(withdrawalRows))
+}
+
+func getWithdrawalsFromStore(ctx context.Context, dataHandler data.DataHandler, blockNumber int64) ([]model.WithdrawalResponse, error) {
+	withdrawalRows, err := dataHandler.GetWithdrawalRowsByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return convertWithdrawalRowsToResp(withdrawalRows), nil
+}
+
+func getWithdrawalsFromRPC(ctx context.Context, blockScanner scanner.BlockScanner, mysqlHandler data.DataHandler, blockNumber int64) ([]model.WithdrawalResponse, error) {
+	block, err := blockScanner.BlockByNumber(ctx, big.NewInt(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawalRows := make([]model.WithdrawalRow, 0, len(block.Withdrawals()))
+	saveRows := make([]*model.WithdrawalRow, 0, len(block.Withdrawals()))
+	for _, withdrawal := range block.Withdrawals() {
+		withdrawalRow := model.WithdrawalRow{
+			Index:          withdrawal.Index,
+			BlockNumber:    blockNumber,
+			ValidatorIndex: withdrawal.Validator,
+			Address:        withdrawal.Address.Hex(),
+			Amount:         withdrawal.Amount,
+		}
+		withdrawalRows = append(withdrawalRows, withdrawalRow)
+		saveRows = append(saveRows, &withdrawalRow)
+	}
+	if len(saveRows) != 0 {
+		go mysqlHandler.SaveWithdrawalRows(tracing.Detach(ctx), saveRows)
+	}
+	return convertWithdrawalRowsToResp(withdrawalRows), nil
+}
+
+func convertWithdrawalRowsToResp(withdrawalRows []model.WithdrawalRow) []model.WithdrawalResponse {
+	resp := make([]model.WithdrawalResponse, 0, len(withdrawalRows))
+	for _, withdrawalRow := range withdrawalRows {
+		resp = append(resp, model.WithdrawalResponse{
+			Index:          withdrawalRow.Index,
+			BlockNum:       withdrawalRow.BlockNumber,
+			ValidatorIndex: withdrawalRow.ValidatorIndex,
+			Address:        withdrawalRow.Address,
+			AmountGwei:     withdrawalRow.Amount,
+		})
+	}
+	return resp
+}
@@ -1,23 +1,26 @@
 package controller
 
 import (
+	"Ethereum_Service/internal/chainprofile"
+	"Ethereum_Service/internal/convert"
 	"Ethereum_Service/internal/data"
 	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/internal/tracing"
 	"Ethereum_Service/pkg/model"
+	"sort"
 
 	"context"
 	"math/big"
 )
 
-func listBlocksFromStore(dataHandler data.DataHandler, latestBlockNumber, limit uint64) ([]model.BlockResponse, error) {
-	numbers := make([]int64, 0, limit)
-	for i := latestBlockNumber; i > latestBlockNumber-limit; i-- {
-		numbers = append(numbers, int64(i))
-	}
-	blockRows, err := dataHandler.GetBlockRowByBlockNumbers(context.Background(), numbers)
+func listBlocksFromStore(ctx context.Context, dataHandler data.DataHandler, numbers []int64) ([]model.BlockResponse, error) {
+	blockRows, err := dataHandler.GetBlockRowByBlockNumbers(ctx, numbers)
 	if err != nil {
 		return nil, err
 	}
+	sort.Slice(blockRows, func(i, j int) bool {
+		return blockRows[i].Number > blockRows[j].Number
+	})
 
 	resp := make([]model.BlockResponse, 0, len(blockRows))
 	for _, blockRow := range blockRows {
@@ -31,12 +34,11 @@ func listBlocksFromStore(dataHandler data.DataHandler, latestBlockNumber, limit
 	return resp, nil
 }
 
-func listBlocksFromRPC(mysqlHandler, redesHandler data.DataHandler, blockScanner scanner.BlockScanner, latestBlockNumber, limit uint64) ([]model.BlockResponse, error) {
-	resp := make([]model.BlockResponse, 0, limit)
+func listBlocksFromRPC(ctx context.Context, mysqlHandler, redesHandler data.DataHandler, blockScanner scanner.BlockScanner, profile chainprofile.Profile, numbers []int64) ([]model.BlockResponse, error) {
+	resp := make([]model.BlockResponse, 0, len(numbers))
 	blockRows := make([]*model.BlockRow, 0)
-	for i := latestBlockNumber; i > latestBlockNumber-limit; i-- {
-		number := big.NewInt(int64(i))
-		block, err := blockScanner.BlockByNumber(context.Background(), number)
+	for _, number := range numbers {
+		block, err := blockScanner.BlockByNumber(ctx, big.NewInt(number))
 		if err != nil {
 			return nil, err
 		}
@@ -46,23 +48,14 @@ func listBlocksFromRPC(mysqlHandler, redesHandler data.DataHandler, blockScanner
 			ParentHash: block.ParentHash().Hex(),
 			BlockTime:  block.Time(),
 		})
-		blockRows = append(blockRows, &model.BlockRow{
-			Hash:       block.Hash().Hex(),
-			Number:     (*block.Number()).Int64(),
-			GasLimit:   block.GasLimit(),
-			GasUsed:    block.GasUsed(),
-			Difficulty: (*block.Difficulty()).Int64(),
-			Time:       block.Time(),
-			Nonce:      block.Nonce(),
-			Root:       block.Root().Hex(),
-			ParentHash: block.ParentHash().Hex(),
-			TxHash:     block.TxHash().Hex(),
-			UncleHash:  block.UncleHash().Hex(),
-			Extra:      block.Extra(),
-		})
+		blockRow, err := convert.BlockToRow(block, profile)
+		if err != nil {
+			return nil, err
+		}
+		blockRows = append(blockRows, &blockRow)
 	}
-	go mysqlHandler.SaveBlockRows(context.Background(), blockRows)
-	go redesHandler.SaveBlockRows(context.Background(), blockRows)
+	go mysqlHandler.SaveBlockRows(tracing.Detach(ctx), blockRows)
+	go redesHandler.SaveBlockRows(tracing.Detach(ctx), blockRows)
 
 	return resp, nil
 }
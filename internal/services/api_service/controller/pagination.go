@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 10
+	maxListLimit     = 100
+)
+
+var (
+	ErrInvalidListQuery = errors.New("before and after can not be used together")
+)
+
+// blockListQuery holds the optional bounds of GET /blocks, a negative value means unset.
+type blockListQuery struct {
+	before int64
+	after  int64
+	from   int64
+	to     int64
+	limit  int64
+}
+
+// blockWindow is an inclusive range of block numbers served in descending order.
+type blockWindow struct {
+	start int64
+	end   int64
+	// next is the cursor of older blocks and prev the cursor of newer blocks,
+	// a negative value means there is no more page in that direction.
+	next int64
+	prev int64
+}
+
+func parseBlockListQuery(ginC *gin.Context) (blockListQuery, error) {
+	q := blockListQuery{before: -1, after: -1, from: -1, to: -1, limit: defaultListLimit}
+	params := []struct {
+		name  string
+		value *int64
+	}{
+		{"before", &q.before},
+		{"after", &q.after},
+		{"from", &q.from},
+		{"to", &q.to},
+		{"limit", &q.limit},
+	}
+	for _, param := range params {
+		raw, ok := ginC.GetQuery(param.name)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || value < 0 {
+			return blockListQuery{}, errors.New(param.name + " must be a non-negative integer")
+		}
+		*param.value = value
+	}
+
+	if q.before >= 0 && q.after >= 0 {
+		return blockListQuery{}, ErrInvalidListQuery
+	}
+	if q.limit == 0 {
+		q.limit = defaultListLimit
+	}
+	if q.limit > maxListLimit {
+		q.limit = maxListLimit
+	}
+	return q, nil
+}
+
+// resolveBlockWindow computes the page of block numbers to serve, bounded by
+// the indexed watermark of the database, ok is false if the page is empty.
+func resolveBlockWindow(q blockListQuery, watermark int64) (blockWindow, bool) {
+	lower, upper := int64(0), watermark
+	if q.from >= 0 && q.from > lower {
+		lower = q.from
+	}
+	if q.to >= 0 && q.to < upper {
+		upper = q.to
+	}
+
+	var w blockWindow
+	switch {
+	case q.after >= 0:
+		w.start = max64(lower, q.after+1)
+		w.end = min64(upper, w.start+q.limit-1)
+	default:
+		w.end = upper
+		if q.before >= 0 {
+			w.end = min64(upper, q.before-1)
+		}
+		w.start = max64(lower, w.end-q.limit+1)
+	}
+	if w.start > w.end {
+		return blockWindow{next: -1, prev: -1}, false
+	}
+
+	w.next, w.prev = -1, -1
+	if w.start > lower {
+		w.next = w.start
+	}
+	if w.end < upper {
+		w.prev = w.end
+	}
+	return w, true
+}
+
+// numbers returns the block numbers of the window in descending order.
+func (w blockWindow) numbers() []int64 {
+	numbers := make([]int64, 0, w.end-w.start+1)
+	for i := w.end; i >= w.start; i-- {
+		numbers = append(numbers, i)
+	}
+	return numbers
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
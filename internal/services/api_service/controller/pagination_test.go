@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveBlockWindow(t *testing.T) {
+	query := blockListQuery{before: -1, after: -1, from: -1, to: -1, limit: 10}
+
+	w, ok := resolveBlockWindow(query, 100)
+	assert.True(t, ok)
+	assert.Equal(t, int64(91), w.start)
+	assert.Equal(t, int64(100), w.end)
+	assert.Equal(t, int64(91), w.next)
+	assert.Equal(t, int64(-1), w.prev)
+	assert.Equal(t, []int64{100, 99, 98, 97, 96, 95, 94, 93, 92, 91}, w.numbers())
+
+	query.before = 91
+	w, ok = resolveBlockWindow(query, 100)
+	assert.True(t, ok)
+	assert.Equal(t, int64(81), w.start)
+	assert.Equal(t, int64(90), w.end)
+	assert.Equal(t, int64(90), w.prev)
+
+	query.before, query.after = -1, 95
+	w, ok = resolveBlockWindow(query, 100)
+	assert.True(t, ok)
+	assert.Equal(t, int64(96), w.start)
+	assert.Equal(t, int64(100), w.end)
+	assert.Equal(t, int64(-1), w.prev)
+
+	// a limit larger than the chain must not underflow
+	query = blockListQuery{before: -1, after: -1, from: -1, to: -1, limit: 100}
+	w, ok = resolveBlockWindow(query, 5)
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), w.start)
+	assert.Equal(t, int64(-1), w.next)
+
+	query.from, query.to = 10, 20
+	_, ok = resolveBlockWindow(query, 5)
+	assert.False(t, ok)
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/internal/tracing"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrInvalidReindex = errors.New("invalid reindex request")
+
+// Reindex publishes the jobs indexing a range of blocks again. The jobs of
+// reindex and reorg replace the stored rows of their blocks unless the
+// request sets another mode.
+func (c *Controller) Reindex(ginC *gin.Context) {
+	ctx := ginC.Request.Context()
+
+	var req model.ReindexRequest
+	if err := ginC.ShouldBindJSON(&req); err != nil {
+		ginC.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	watermark, err := c.mysqlHandler.GetLatestBlockNumber(ctx)
+	if err != nil {
+		ginC.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	jobs, err := reindexJobs(c.chainOption.ChainId, watermark, req)
+	if err != nil {
+		ginC.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	queue, err := c.getQueue()
+	if err != nil {
+		ginC.JSON(503, gin.H{"error": err.Error()})
+		return
+	}
+	resp := model.ReindexResponse{
+		Reason: jobs[0].Reason,
+		Mode:   jobs[0].WriteMode(),
+		JobIds: make([]string, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		if err := c.publishJob(ctx, queue, job); err != nil {
+			// the jobs published before stay queued
+			ginC.JSON(502, gin.H{"error": err.Error(), "job_ids": resp.JobIds})
+			return
+		}
+		resp.JobIds = append(resp.JobIds, job.JobId)
+	}
+	ginC.JSON(200, resp)
+}
+
+// reindexJobs returns the jobs of req, JOB_RANGE_SIZE blocks at most each.
+// The blocks have to be indexed already, up to the watermark, and a request
+// covers MAX_REINDEX_BLOCKS blocks at most.
+func reindexJobs(chainId, watermark int64, req model.ReindexRequest) ([]model.BlockJob, error) {
+	reason := req.Reason
+	switch reason {
+	case "":
+		reason = c.JobReasonReindex
+	case c.JobReasonReindex, c.JobReasonReorg, c.JobReasonGap:
+	default:
+		return nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidReindex, req.Reason)
+	}
+	switch req.Mode {
+	case "", c.WriteModeInsert, c.WriteModeUpsert, c.WriteModeReplace:
+	default:
+		return nil, fmt.Errorf("%w: unknown write mode %q", ErrInvalidReindex, req.Mode)
+	}
+	from, to := *req.From, *req.To
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("%w: invalid range %d-%d", ErrInvalidReindex, from, to)
+	}
+	if to > watermark {
+		return nil, fmt.Errorf("%w: block %d is above the watermark %d", ErrInvalidReindex, to, watermark)
+	}
+	if max := config.GetConfig().GetMaxReindexBlocks(); to-from >= max {
+		return nil, fmt.Errorf("%w: range %d-%d covers more than %d blocks", ErrInvalidReindex, from, to, max)
+	}
+
+	jobs := model.SplitBlockJobs(chainId, from, to, config.GetConfig().GetJobRangeSize(), reason)
+	for i := range jobs {
+		jobs[i].Mode = req.Mode
+	}
+	return jobs, nil
+}
+
+func (c *Controller) publishJob(ctx context.Context, queue mq.Queue, job model.BlockJob) error {
+	queueName := c.chainOption.JobQueue(job.Reason)
+	ctx, span := tracing.StartPublish(ctx, queue.System(), queueName)
+	span.SetAttributes(
+		attribute.String("job.id", job.JobId),
+		attribute.Int64("block.from", job.From),
+		attribute.Int64("block.to", job.To),
+		attribute.String("job.reason", job.Reason),
+		attribute.String("job.write_mode", job.WriteMode()),
+		attribute.String("chain", c.chain))
+	job.TraceContext = tracing.InjectHeaders(ctx)
+	body, err := job.Encode()
+	if err == nil {
+		err = mq.PublishConfirmed(ctx, queue, queueName, mq.Message{
+			Headers:     job.TraceContext,
+			Body:        body,
+			ContentType: model.BlockJobContentType,
+		})
+	}
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("publishJob : %w", err)
+	}
+	monitor.QueuePublished.WithLabelValues(queueName).Inc()
+	return nil
+}
+
+// getQueue returns the queue of the jobs, connecting to it on first use so
+// that the api service only needs the broker for the admin routes.
+func (c *Controller) getQueue() (mq.Queue, error) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	if c.queue == nil {
+		queue, err := mq.New(config.GetConfig().Queue, config.GetConfig().MQEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("getQueue : %w", err)
+		}
+		c.queue = queue
+	}
+	return c.queue, nil
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// watermarkStore is a database indexed up to watermark.
+type watermarkStore struct {
+	data.DataHandler
+	watermark int64
+}
+
+func (s *watermarkStore) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	return s.watermark, nil
+}
+
+func TestReindex(t *testing.T) {
+	queue := mq.NewMemory()
+	chain := config.ChainOption{Name: "bsc", ChainId: 56}
+	controller := &Controller{chain: chain.Name, chainOption: chain, queue: queue, mysqlHandler: &watermarkStore{watermark: 1000}}
+	maxReindexBlocks := config.GetConfig().MaxReindexBlocks
+	defer func() { config.GetConfig().MaxReindexBlocks = maxReindexBlocks }()
+	config.GetConfig().MaxReindexBlocks = 500
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/reindex", controller.Reindex)
+	post := func(body string) (int, model.ReindexResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reindex", strings.NewReader(body)))
+		var resp model.ReindexResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := post(`{"from":0,"to":249}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, c.JobReasonReindex, resp.Reason)
+	assert.Equal(t, c.WriteModeReplace, resp.Mode)
+	assert.Equal(t, []string{"56-0-99", "56-100-199", "56-200-249"}, resp.JobIds)
+
+	code, resp = post(`{"from":300,"to":300,"reason":"reorg","mode":"upsert"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, c.WriteModeUpsert, resp.Mode)
+
+	// reindex jobs go to the backfill lane with their write mode
+	assert.Equal(t, 4, queue.Len(chain.BlockNumberQueue()))
+	assert.Equal(t, 0, queue.Len(chain.BlockNumberHeadQueue()))
+	var jobs []model.BlockJob
+	stop := make(chan struct{})
+	queue.Consume(stop, chain.BlockNumberQueue(), "test", 1, func(msg mq.Delivery) {
+		job, err := model.DecodeBlockJob(msg.Body(), chain.ChainId)
+		assert.NoError(t, err)
+		jobs = append(jobs, job)
+		msg.Ack()
+		if len(jobs) == 4 {
+			close(stop)
+		}
+	})
+	assert.Equal(t, int64(200), jobs[2].From)
+	assert.Equal(t, int64(249), jobs[2].To)
+	assert.Equal(t, c.WriteModeReplace, jobs[2].WriteMode())
+	assert.Equal(t, c.JobReasonReorg, jobs[3].Reason)
+	assert.Equal(t, c.WriteModeUpsert, jobs[3].Mode)
+
+	for _, body := range []string{
+		`{"from":10}`,
+		`{"from":10,"to":9}`,
+		`{"from":-1,"to":9}`,
+		`{"from":1,"to":9,"reason":"head"}`,
+		`{"from":1,"to":9,"mode":"merge"}`,
+		// blocks not indexed yet
+		`{"from":900,"to":1001}`,
+		// more blocks than one request may cover
+		`{"from":0,"to":500}`,
+		`{"from":0,"to":9000000000000000000}`,
+	} {
+		code, _ := post(body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+	assert.Equal(t, 0, queue.Len(chain.BlockNumberQueue()))
+}
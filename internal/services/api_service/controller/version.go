@@ -0,0 +1,23 @@
+package controller
+
+import "github.com/gin-gonic/gin"
+
+const (
+	versionContextKey = "api_version"
+
+	APIVersionLegacy = "legacy"
+	APIVersionV1     = "v1"
+)
+
+// APIVersion marks which version of the API a route group serves, so handlers
+// can keep the legacy response shapes on the unversioned routes.
+func APIVersion(version string) gin.HandlerFunc {
+	return func(ginC *gin.Context) {
+		ginC.Set(versionContextKey, version)
+		ginC.Next()
+	}
+}
+
+func getAPIVersion(ginC *gin.Context) string {
+	return ginC.GetString(versionContextKey)
+}
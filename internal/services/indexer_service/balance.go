@@ -0,0 +1,185 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var gweiToWei = big.NewInt(1_000_000_000)
+
+// scannedTx is a transaction of the scanned block with its sender and receipt,
+// the receipt is nil for pending transactions.
+type scannedTx struct {
+	tx      *types.Transaction
+	from    common.Address
+	receipt *types.Receipt
+}
+
+// trackBalances returns the balance rows of the addresses touched by block,
+// none when balances are not tracked.
+func (s *ScanHandler) trackBalances(ctx context.Context, block *types.Block, txs []scannedTx) ([]model.BalanceRow, error) {
+	mode := config.GetConfig().Balance.Mode
+	if mode == "" {
+		return nil, nil
+	}
+
+	var transfers []scanner.InternalTransfer
+	var err error
+	if config.GetConfig().Balance.TraceInternal {
+		transfers, err = s.balanceScanner.InternalTransfers(ctx, block.Number())
+		if err != nil {
+			return nil, fmt.Errorf("trackBalances: %w", err)
+		}
+	}
+
+	switch mode {
+	case c.BalanceModeDerived:
+		return deriveBalanceChanges(block, txs, transfers), nil
+	case c.BalanceModeRPC:
+		balanceRows, err := snapshotBalances(ctx, s.balanceScanner, block, touchedAddresses(block, txs, transfers))
+		if err != nil {
+			return nil, fmt.Errorf("trackBalances: %w", err)
+		}
+		return balanceRows, nil
+	default:
+		return nil, fmt.Errorf("trackBalances: unknown balance mode %s", mode)
+	}
+}
+
+// deriveBalanceChanges sums the native balance change of every address touched
+// by the block from tx values, gas fees, withdrawals and internal transfers.
+// Block and uncle rewards of pre-merge chains and OP-stack deposits are not included.
+func deriveBalanceChanges(block *types.Block, txs []scannedTx, transfers []scanner.InternalTransfer) []model.BalanceRow {
+	deltas := make(map[common.Address]*big.Int)
+	nonces := make(map[common.Address]uint64)
+	add := func(address common.Address, amount *big.Int) {
+		if _, ok := deltas[address]; !ok {
+			deltas[address] = new(big.Int)
+		}
+		deltas[address].Add(deltas[address], amount)
+	}
+
+	for _, scanned := range txs {
+		receipt := scanned.receipt
+		if receipt == nil {
+			continue
+		}
+
+		gasPrice := receipt.EffectiveGasPrice
+		if gasPrice == nil {
+			gasPrice = scanned.tx.GasPrice()
+		}
+		gasUsed := new(big.Int).SetUint64(receipt.GasUsed)
+		fee := new(big.Int).Mul(gasUsed, gasPrice)
+		if receipt.BlobGasPrice != nil {
+			fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+		}
+		add(scanned.from, new(big.Int).Neg(fee))
+
+		// the base fee is burnt, the miner only receives the priority fee
+		tip := new(big.Int).Set(gasPrice)
+		if block.BaseFee() != nil {
+			tip.Sub(tip, block.BaseFee())
+		}
+		add(block.Coinbase(), tip.Mul(tip, gasUsed))
+
+		if receipt.Status == types.ReceiptStatusSuccessful && scanned.tx.Value().Sign() > 0 {
+			to := receipt.ContractAddress
+			if scanned.tx.To() != nil {
+				to = *scanned.tx.To()
+			}
+			add(scanned.from, new(big.Int).Neg(scanned.tx.Value()))
+			add(to, scanned.tx.Value())
+		}
+
+		if scanned.tx.Nonce()+1 > nonces[scanned.from] {
+			nonces[scanned.from] = scanned.tx.Nonce() + 1
+		}
+	}
+
+	for _, withdrawal := range block.Withdrawals() {
+		add(withdrawal.Address, new(big.Int).Mul(new(big.Int).SetUint64(withdrawal.Amount), gweiToWei))
+	}
+
+	for _, transfer := range transfers {
+		add(transfer.From, new(big.Int).Neg(transfer.Value))
+		add(transfer.To, transfer.Value)
+	}
+
+	balanceRows := make([]model.BalanceRow, 0, len(deltas))
+	for _, address := range sortedAddresses(deltas) {
+		balanceRow := model.BalanceRow{
+			Address:     address.Hex(),
+			BlockNumber: block.Number().Int64(),
+			Delta:       deltas[address].String(),
+		}
+		if nonce, ok := nonces[address]; ok {
+			balanceRow.Nonce = &nonce
+		}
+		balanceRows = append(balanceRows, balanceRow)
+	}
+	return balanceRows
+}
+
+// snapshotBalances reads the balance and nonce of the addresses at the block height.
+func snapshotBalances(ctx context.Context, balanceScanner scanner.BalanceScanner, block *types.Block, addresses []common.Address) ([]model.BalanceRow, error) {
+	balanceRows := make([]model.BalanceRow, 0, len(addresses))
+	for _, address := range addresses {
+		balance, err := balanceScanner.BalanceAt(ctx, address, block.Number())
+		if err != nil {
+			return nil, fmt.Errorf("snapshotBalances: %w", err)
+		}
+		nonce, err := balanceScanner.NonceAt(ctx, address, block.Number())
+		if err != nil {
+			return nil, fmt.Errorf("snapshotBalances: %w", err)
+		}
+		balanceRows = append(balanceRows, model.BalanceRow{
+			Address:     address.Hex(),
+			BlockNumber: block.Number().Int64(),
+			Balance:     balance.String(),
+			Nonce:       &nonce,
+		})
+	}
+	return balanceRows, nil
+}
+
+func touchedAddresses(block *types.Block, txs []scannedTx, transfers []scanner.InternalTransfer) []common.Address {
+	touched := map[common.Address]*big.Int{block.Coinbase(): nil}
+	for _, scanned := range txs {
+		touched[scanned.from] = nil
+		if scanned.tx.To() != nil {
+			touched[*scanned.tx.To()] = nil
+		}
+		if scanned.receipt != nil && scanned.receipt.ContractAddress != (common.Address{}) {
+			touched[scanned.receipt.ContractAddress] = nil
+		}
+	}
+	for _, withdrawal := range block.Withdrawals() {
+		touched[withdrawal.Address] = nil
+	}
+	for _, transfer := range transfers {
+		touched[transfer.From] = nil
+		touched[transfer.To] = nil
+	}
+	return sortedAddresses(touched)
+}
+
+func sortedAddresses(m map[common.Address]*big.Int) []common.Address {
+	addresses := make([]common.Address, 0, len(m))
+	for address := range m {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Hex() < addresses[j].Hex()
+	})
+	return addresses
+}
@@ -0,0 +1,55 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/pkg/model"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveBalanceChanges(t *testing.T) {
+	miner := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	alice := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	bob := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	carol := common.HexToAddress("0x0000000000000000000000000000000000000004")
+	validator := common.HexToAddress("0x0000000000000000000000000000000000000005")
+
+	header := &types.Header{Number: big.NewInt(100), Coinbase: miner, BaseFee: big.NewInt(10)}
+	block := types.NewBlockWithHeader(header).WithWithdrawals([]*types.Withdrawal{
+		{Index: 1, Validator: 7, Address: validator, Amount: 2},
+	})
+	txs := []scannedTx{
+		{
+			tx:      types.NewTx(&types.LegacyTx{Nonce: 4, To: &bob, Value: big.NewInt(100), Gas: 21000, GasPrice: big.NewInt(12)}),
+			from:    alice,
+			receipt: &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 21000, EffectiveGasPrice: big.NewInt(12)},
+		},
+		{
+			// a reverted transaction pays the fee but moves no value
+			tx:      types.NewTx(&types.LegacyTx{Nonce: 5, To: &carol, Value: big.NewInt(1000), Gas: 30000, GasPrice: big.NewInt(12)}),
+			from:    alice,
+			receipt: &types.Receipt{Status: types.ReceiptStatusFailed, GasUsed: 30000, EffectiveGasPrice: big.NewInt(12)},
+		},
+	}
+	transfers := []scanner.InternalTransfer{{From: bob, To: carol, Value: big.NewInt(30)}}
+
+	rows := make(map[string]model.BalanceRow)
+	for _, balanceRow := range deriveBalanceChanges(block, txs, transfers) {
+		assert.Equal(t, int64(100), balanceRow.BlockNumber)
+		assert.Empty(t, balanceRow.Balance)
+		rows[balanceRow.Address] = balanceRow
+	}
+	assert.Len(t, rows, 5)
+	assert.Equal(t, "-612100", rows[alice.Hex()].Delta)
+	assert.Equal(t, uint64(6), *rows[alice.Hex()].Nonce)
+	assert.Equal(t, "70", rows[bob.Hex()].Delta)
+	assert.Nil(t, rows[bob.Hex()].Nonce)
+	assert.Equal(t, "30", rows[carol.Hex()].Delta)
+	// the miner only gets the priority fee, the base fee is burnt
+	assert.Equal(t, "102000", rows[miner.Hex()].Delta)
+	assert.Equal(t, "2000000000", rows[validator.Hex()].Delta)
+}
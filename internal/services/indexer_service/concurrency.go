@@ -0,0 +1,129 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/config"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultAdjustInterval = 10 * time.Second
+	defaultTargetLatency  = 2 * time.Second
+	defaultMaxErrorRate   = 0.05
+)
+
+// aimd sizes the backfill workers of a chain from the RPC calls they make:
+// one more worker after an interval of fast and successful calls, half as
+// many after an interval with throttled or timed out calls, too many errors
+// or a high latency.
+type aimd struct {
+	min           int
+	max           int
+	targetLatency time.Duration
+	maxErrorRate  float64
+
+	mu        sync.Mutex
+	calls     int
+	errors    int
+	congested int
+	latency   time.Duration
+}
+
+func newAIMD(opt config.ConcurrencyOption, initial int) *aimd {
+	a := &aimd{
+		min:           opt.MinWorkers,
+		max:           opt.MaxWorkers,
+		targetLatency: opt.TargetLatency,
+		maxErrorRate:  opt.MaxErrorRate,
+	}
+	if a.min <= 0 {
+		a.min = 1
+	}
+	if a.max < a.min {
+		a.max = initial
+		if a.max < a.min {
+			a.max = a.min
+		}
+	}
+	if a.targetLatency <= 0 {
+		a.targetLatency = defaultTargetLatency
+	}
+	if a.maxErrorRate <= 0 {
+		a.maxErrorRate = defaultMaxErrorRate
+	}
+	return a
+}
+
+// clamp keeps n within the limits of the controller.
+func (a *aimd) clamp(n int) int {
+	if n < a.min {
+		return a.min
+	}
+	if n > a.max {
+		return a.max
+	}
+	return n
+}
+
+// observe records an RPC call, it is the RPCObserver of the endpoint pool.
+func (a *aimd) observe(method string, latency time.Duration, err error) {
+	// calls cancelled by the shutdown say nothing about the endpoint
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	a.latency += latency
+	if err != nil {
+		a.errors++
+		if isCongestion(err) {
+			a.congested++
+		}
+	}
+}
+
+// next returns the number of workers following current from the calls
+// observed since the previous call, an interval without calls keeps current.
+func (a *aimd) next(current int) int {
+	a.mu.Lock()
+	calls, errs, congested, latency := a.calls, a.errors, a.congested, a.latency
+	a.calls, a.errors, a.congested, a.latency = 0, 0, 0, 0
+	a.mu.Unlock()
+
+	if calls == 0 {
+		return a.clamp(current)
+	}
+	if congested > 0 ||
+		float64(errs)/float64(calls) > a.maxErrorRate ||
+		latency/time.Duration(calls) > a.targetLatency {
+		return a.clamp(current / 2)
+	}
+	return a.clamp(current + 1)
+}
+
+// isCongestion reports whether err means the endpoint is overloaded: a 429
+// or 503 answer, or a call that timed out.
+func isCongestion(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode == http.StatusServiceUnavailable
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// some providers answer a JSON-RPC error when rate limiting
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "too many requests") || strings.Contains(msg, "rate limit")
+}
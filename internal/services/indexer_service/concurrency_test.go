@@ -0,0 +1,46 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/config"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMD(t *testing.T) {
+	a := newAIMD(config.ConcurrencyOption{
+		MinWorkers:    2,
+		MaxWorkers:    10,
+		TargetLatency: time.Second,
+		MaxErrorRate:  0.1,
+	}, 8)
+
+	// an idle interval keeps the workers
+	assert.Equal(t, 8, a.next(8))
+
+	a.observe("eth_getBlockByNumber", 100*time.Millisecond, nil)
+	assert.Equal(t, 9, a.next(8))
+	a.observe("eth_getBlockByNumber", 100*time.Millisecond, nil)
+	assert.Equal(t, 10, a.next(10))
+
+	a.observe("eth_getBlockByNumber", 100*time.Millisecond, rpc.HTTPError{StatusCode: 429})
+	for i := 0; i < 99; i++ {
+		a.observe("eth_getTransactionReceipt", 100*time.Millisecond, nil)
+	}
+	assert.Equal(t, 5, a.next(10))
+
+	a.observe("eth_getBlockByNumber", 3*time.Second, nil)
+	assert.Equal(t, 2, a.next(5))
+
+	// too many errors, the workers stay at the minimum
+	a.observe("eth_getBlockByNumber", 100*time.Millisecond, errors.New("header not found"))
+	assert.Equal(t, 2, a.next(2))
+
+	// the calls cancelled by the shutdown are ignored
+	a.observe("eth_getBlockByNumber", 100*time.Millisecond, context.Canceled)
+	assert.Equal(t, 2, a.next(2))
+}
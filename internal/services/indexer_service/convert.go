@@ -1,32 +1,27 @@
 package indexer_service
 
 import (
+	"Ethereum_Service/internal/chainprofile"
 	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/common"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
-func convertBlockToRow(block *types.Block) model.BlockRow {
-	return model.BlockRow{
-		Hash:       block.Hash().Hex(),
-		Number:     (*block.Number()).Int64(),
-		GasLimit:   block.GasLimit(),
-		GasUsed:    block.GasUsed(),
-		Difficulty: (*block.Difficulty()).Int64(),
-		Time:       block.Time(),
-		Nonce:      block.Nonce(),
-		Root:       block.Root().Hex(),
-		ParentHash: block.ParentHash().Hex(),
-		TxHash:     block.TxHash().Hex(),
-		UncleHash:  block.UncleHash().Hex(),
-		Extra:      block.Extra(),
+func convertWithdrawalToRow(withdrawal *types.Withdrawal, blockNumber big.Int) model.WithdrawalRow {
+	return model.WithdrawalRow{
+		Index:          withdrawal.Index,
+		BlockNumber:    blockNumber.Int64(),
+		ValidatorIndex: withdrawal.Validator,
+		Address:        withdrawal.Address.Hex(),
+		Amount:         withdrawal.Amount,
 	}
 }
 
-func convertTxToRow(tx *types.Transaction, blockNumber big.Int) (model.TransactionRow, error) {
-	from, err := types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+func convertTxToRow(tx *types.Transaction, blockNumber big.Int, profile chainprofile.Profile) (model.TransactionRow, error) {
+	from, err := profile.TxSender(tx)
 	if err != nil {
 		return model.TransactionRow{}, fmt.Errorf("convertTxToRow : %w", err)
 	}
@@ -36,7 +31,9 @@ func convertTxToRow(tx *types.Transaction, blockNumber big.Int) (model.Transacti
 		Nonce:       tx.Nonce(),
 		From:        from.Hex(),
 		Value:       (*tx.Value()).Int64(),
+		ValueWei:    tx.Value(),
 		Data:        tx.Data(),
+		Type:        tx.Type(),
 	}
 	if tx.To() != nil {
 		txRow.To = tx.To().Hex()
@@ -44,10 +41,31 @@ func convertTxToRow(tx *types.Transaction, blockNumber big.Int) (model.Transacti
 	return txRow, nil
 }
 
+func convertDepositTxToRow(deposit chainprofile.DepositTx, blockNumber big.Int) model.TransactionRow {
+	txRow := model.TransactionRow{
+		Hash:        deposit.Hash.Hex(),
+		BlockNumber: blockNumber.Int64(),
+		Nonce:       uint64(deposit.Nonce),
+		From:        deposit.From.Hex(),
+		Data:        deposit.Input,
+		Type:        chainprofile.DepositTxType,
+	}
+	if deposit.Value != nil {
+		txRow.Value = deposit.Value.ToInt().Int64()
+		txRow.ValueWei = deposit.Value.ToInt()
+	}
+	if deposit.To != nil {
+		txRow.To = deposit.To.Hex()
+	}
+	return txRow
+}
+
 func convertLogToRow(log *types.Log, txHash string) model.LogRow {
 	return model.LogRow{
-		TxHash: txHash,
-		Index:  log.Index,
-		Data:   log.Data,
+		TxHash:  txHash,
+		Index:   log.Index,
+		Address: log.Address.Hex(),
+		Topics:  common.JoinTopics(log.Topics),
+		Data:    log.Data,
 	}
 }
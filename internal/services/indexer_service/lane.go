@@ -0,0 +1,131 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/pkg/utils/logger"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBackfillShare = 0.8
+	defaultPrefetch      = 1
+
+	laneHead     = "head"
+	laneBackfill = "backfill"
+)
+
+// lane is the workers consuming one queue of jobs, every worker has its own
+// stop channel so the lane can shrink without stopping the others. Workers of
+// a lane with a fallbackName take its jobs while queueName is empty.
+type lane struct {
+	name         string
+	queueName    string
+	fallbackName string
+
+	mu    sync.Mutex
+	stops []chan struct{}
+}
+
+func (l *lane) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.stops)
+}
+
+// startScanners splits the workers between the lanes by BACKFILL_SHARE,
+// each lane keeps at least one worker. The share is reserved for backfill,
+// the head workers help with the backfill while no head job is queued. The backfill lane is resized by the
+// AIMD controller when CONCURRENCY.ADAPTIVE is set.
+func (s *Service) startScanners(workerCount int) {
+	opt := config.GetConfig().Concurrency
+	headWorkers, backfillWorkers := splitWorkers(workerCount, config.GetConfig().BackfillShare)
+
+	if opt.Adaptive {
+		s.concurrency = newAIMD(opt, backfillWorkers)
+		backfillWorkers = s.concurrency.clamp(backfillWorkers)
+		s.pool.AddObserver(s.concurrency.observe)
+	}
+	logger.GetLogger().Sugar().Infof("chain %s: %d head workers, %d backfill workers", s.chain.Name, headWorkers, backfillWorkers)
+	s.resize(s.headLane, headWorkers)
+	s.resize(s.backfillLane, backfillWorkers)
+
+	if s.concurrency != nil {
+		s.workers.Add(1)
+		go func() {
+			defer s.workers.Done()
+			s.adjustConcurrency(opt.Interval)
+		}()
+	}
+}
+
+// adjustConcurrency resizes the backfill lane every interval until the
+// workers stop.
+func (s *Service) adjustConcurrency(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultAdjustInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.scanHandler.stopping:
+			return
+		case <-t.C:
+		}
+		current := s.backfillLane.size()
+		if next := s.concurrency.next(current); next != current {
+			logger.GetLogger().Sugar().Infof("chain %s: backfill workers %d -> %d", s.chain.Name, current, next)
+			s.resize(s.backfillLane, next)
+		}
+	}
+}
+
+// resize starts or stops workers of l until it has n, stopped workers finish
+// the job they are indexing.
+func (s *Service) resize(l *lane, n int) {
+	prefetch := config.GetConfig().Concurrency.Prefetch
+	if prefetch <= 0 {
+		prefetch = defaultPrefetch
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.stops) < n {
+		stop := make(chan struct{})
+		l.stops = append(l.stops, stop)
+		s.workers.Add(1)
+		go func() {
+			defer s.workers.Done()
+			s.scanHandler.Scan(s.shutDownCtx, s.getQueue(), l.queueName, l.fallbackName, prefetch, stop)
+		}()
+	}
+	for len(l.stops) > n {
+		last := len(l.stops) - 1
+		close(l.stops[last])
+		l.stops = l.stops[:last]
+	}
+	monitor.IndexerWorkers.WithLabelValues(s.chain.Name, l.name).Set(float64(len(l.stops)))
+}
+
+// workerCount returns the number of workers of both lanes.
+func (s *Service) workerCount() int {
+	return s.headLane.size() + s.backfillLane.size()
+}
+
+func splitWorkers(workerCount int, backfillShare float64) (head, backfill int) {
+	if backfillShare <= 0 || backfillShare >= 1 {
+		backfillShare = defaultBackfillShare
+	}
+	backfill = int(math.Round(float64(workerCount) * backfillShare))
+	if backfill < 1 {
+		backfill = 1
+	}
+	head = workerCount - backfill
+	if head < 1 {
+		head = 1
+	}
+	return head, backfill
+}
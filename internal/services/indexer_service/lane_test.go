@@ -0,0 +1,56 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/internal/mq"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWorkers(t *testing.T) {
+	head, backfill := splitWorkers(100, 0.8)
+	assert.Equal(t, 20, head)
+	assert.Equal(t, 80, backfill)
+
+	head, backfill = splitWorkers(1, 0)
+	assert.Equal(t, 1, head)
+	assert.Equal(t, 1, backfill)
+}
+
+func TestConsumePreferring(t *testing.T) {
+	queue := mq.NewMemory()
+	ctx := context.Background()
+	publish := func(queueName string, bodies ...string) {
+		for _, body := range bodies {
+			_, err := queue.Publish(ctx, queueName, mq.Message{Body: []byte(body)})
+			assert.NoError(t, err)
+		}
+	}
+	publish("backfill", "b1", "b2", "b3")
+	publish("head", "h1", "h2", "h3")
+
+	stop := make(chan struct{})
+	var got []string
+	consumePreferring(queue, stop, "head", "backfill", 1, func(queueName string, msg mq.Delivery) {
+		// indexing takes a while, both consumers are waiting with a message
+		time.Sleep(10 * time.Millisecond)
+		got = append(got, string(msg.Body()))
+		msg.Ack()
+		if len(got) == 5 {
+			close(stop)
+		}
+	})
+
+	// a backfill job may be taken before the head consumer started, the head
+	// jobs come first afterwards
+	want := []string{"h1", "h2", "h3", "b1", "b2"}
+	if got[0] == "b1" {
+		want = []string{"b1", "h1", "h2", "h3", "b2"}
+	}
+	assert.Equal(t, want, got)
+	// the job held by the backfill consumer at stop goes back to the queue
+	assert.Equal(t, 0, queue.Len("head"))
+	assert.Equal(t, 1, queue.Len("backfill"))
+}
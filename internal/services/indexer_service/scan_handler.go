@@ -3,219 +3,635 @@ package indexer_service
 import (
 	"Ethereum_Service/c"
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/chainprofile"
 	"Ethereum_Service/internal/consumer"
+	"Ethereum_Service/internal/convert"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/mq"
 	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/internal/tracing"
 	"Ethereum_Service/pkg/model"
 	"Ethereum_Service/pkg/utils/logger"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
-	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	ethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ScanHandler struct {
-	blockScanner scanner.BlockScanner
-	txScanner    scanner.TxScanner
-	logScanner   scanner.LogScanner
+	chain   config.ChainOption
+	profile chainprofile.Profile
+	// store writes the blocks of the jobs which overwrite rows, the other
+	// rows are buffered by the consumers
+	store data.DataHandler
+	// cache is the redis cache of the api, nil without REDIS
+	cache data.DataHandler
+	// indexedHead is the highest block indexed by the workers of this chain
+	indexedHead *atomic.Uint64
+	// inFlight counts the blocks being indexed by the workers
+	inFlight *atomic.Int64
 
-	blockConsumer consumer.Consumer
-	txConsumer    consumer.Consumer
-	logConsumer   consumer.Consumer
+	blockScanner   scanner.BlockScanner
+	txScanner      scanner.TxScanner
+	logScanner     scanner.LogScanner
+	balanceScanner scanner.BalanceScanner
+
+	blockConsumer      consumer.Consumer
+	txConsumer         consumer.Consumer
+	logConsumer        consumer.Consumer
+	withdrawalConsumer consumer.Consumer
+	balanceConsumer    consumer.Consumer
+	// clickHouseConsumer streams the blocks to ClickHouse when a mode is set
+	clickHouseConsumer consumer.Consumer
+
+	// stopping is closed when the workers have to stop consuming, blocks
+	// finished afterwards are completed once their rows are flushed
+	stopping  chan struct{}
+	stopOnce  sync.Once
+	pendingMu sync.Mutex
+	pending   []pendingDone
 }
 
-func NewScanHandler(rcpEndpoint string) ScanHandler {
+// pendingDone is a job indexed while shutting down, its completion is
+// published after the consumers flushed.
+type pendingDone struct {
+	ctx context.Context
+	job model.BlockJob
+	msg mq.Delivery
+}
 
-	blockScanner := scanner.NewDefaultBlockScanner(rcpEndpoint)
-	txScanner := scanner.NewDefaultTxScanner(rcpEndpoint)
-	logScanner := scanner.NewDefaultLogScanner(rcpEndpoint)
+func NewScanHandler(chain config.ChainOption, pool *scanner.EndpointPool, profile chainprofile.Profile, store data.DataHandler) *ScanHandler {
+
+	blockScanner := scanner.NewDefaultBlockScanner(pool, profile)
+	txScanner := scanner.NewDefaultTxScanner(pool)
+	logScanner := scanner.NewDefaultLogScanner(pool)
 
 	blockConsume := consumer.NewConsumer(&consumer.ConsumerConf{
+		ChainId:         chain.ChainId,
+		Chain:           chain.Name,
 		Type:            c.BlockConsumerType,
 		StoreBufferSize: config.GetConfig().StoreBufferSize,
 		StoreInterval:   storeInterval,
 	})
 
 	txConsume := consumer.NewConsumer(&consumer.ConsumerConf{
+		ChainId:         chain.ChainId,
+		Chain:           chain.Name,
 		Type:            c.TxConsumerType,
 		StoreBufferSize: config.GetConfig().StoreBufferSize,
 		StoreInterval:   storeInterval,
 	})
 	logConsume := consumer.NewConsumer(&consumer.ConsumerConf{
+		ChainId:         chain.ChainId,
+		Chain:           chain.Name,
 		Type:            c.LogConsumerType,
 		StoreBufferSize: config.GetConfig().StoreBufferSize,
 		StoreInterval:   storeInterval,
 	})
 
+	withdrawalConsume := consumer.NewConsumer(&consumer.ConsumerConf{
+		ChainId:         chain.ChainId,
+		Chain:           chain.Name,
+		Type:            c.WithdrawalConsumerType,
+		StoreBufferSize: config.GetConfig().StoreBufferSize,
+		StoreInterval:   storeInterval,
+	})
+
 	go blockConsume.Run()
 	go txConsume.Run()
 	go logConsume.Run()
+	go withdrawalConsume.Run()
 
-	return ScanHandler{
+	// balances are only tracked when a balance mode is configured
+	var balanceScanner scanner.BalanceScanner
+	var balanceConsume consumer.Consumer
+	if config.GetConfig().Balance.Mode != "" {
+		balanceScanner = scanner.NewDefaultBalanceScanner(pool)
+		balanceConsume = consumer.NewConsumer(&consumer.ConsumerConf{
+			ChainId:         chain.ChainId,
+			Chain:           chain.Name,
+			Type:            c.BalanceConsumerType,
+			StoreBufferSize: config.GetConfig().StoreBufferSize,
+			StoreInterval:   storeInterval,
+		})
+		go balanceConsume.Run()
+	}
 
-		blockScanner: blockScanner,
-		txScanner:    txScanner,
-		logScanner:   logScanner,
+	var cache data.DataHandler
+	if config.GetConfig().Redis.Host != "" {
+		cache = data.NewRedisDataHandler(chain.ChainId)
+	}
+
+	var clickHouseConsume consumer.Consumer
+	if config.GetConfig().ClickHouse.Mode != "" {
+		clickHouseConsume = consumer.NewConsumer(&consumer.ConsumerConf{
+			ChainId:         chain.ChainId,
+			Chain:           chain.Name,
+			Type:            c.ClickHouseConsumerType,
+			StoreBufferSize: config.GetConfig().ClickHouse.BatchSize,
+			StoreInterval:   config.GetConfig().ClickHouse.FlushInterval,
+		})
+		go clickHouseConsume.Run()
+	}
+
+	return &ScanHandler{
+		chain:       chain,
+		profile:     profile,
+		store:       store,
+		cache:       cache,
+		indexedHead: &atomic.Uint64{},
+		inFlight:    &atomic.Int64{},
 
-		blockConsumer: blockConsume,
-		txConsumer:    txConsume,
-		logConsumer:   logConsume,
+		blockScanner:   blockScanner,
+		txScanner:      txScanner,
+		logScanner:     logScanner,
+		balanceScanner: balanceScanner,
+
+		blockConsumer:      blockConsume,
+		txConsumer:         txConsume,
+		logConsumer:        logConsume,
+		withdrawalConsumer: withdrawalConsume,
+		balanceConsumer:    balanceConsume,
+		clickHouseConsumer: clickHouseConsume,
+
+		stopping: make(chan struct{}),
 	}
 }
-func (s *ScanHandler) Scan(ctx context.Context, ethClient *ethclient.Client, mqConn *amqp.Connection) {
-	ch, err := mqConn.Channel()
-	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to open a channel: %v", err)
+
+// Scan indexes the jobs of the lane queueName until stop is closed, Stop is
+// called or the queue is closed. With a fallbackName the jobs of queueName
+// come first and the jobs of fallbackName are taken while queueName is empty.
+func (s *ScanHandler) Scan(ctx context.Context, queue mq.Queue, queueName, fallbackName string, prefetch int, stop <-chan struct{}) {
+	stopped := make(chan struct{})
+	defer close(stopped)
+	workerStop := make(chan struct{})
+	go func() {
+		defer close(workerStop)
+		select {
+		case <-stop:
+		case <-s.stopping:
+		case <-stopped:
+		}
+	}()
+
+	handle := func(name string, msg mq.Delivery) {
+		monitor.QueueConsumed.WithLabelValues(name).Inc()
+		s.scanJob(ctx, queue, name, msg)
+	}
+	if fallbackName == "" {
+		queue.Consume(workerStop, queueName, indexerConsumerTag, prefetch, func(msg mq.Delivery) {
+			handle(queueName, msg)
+		})
 		return
 	}
-	defer ch.Close()
+	consumePreferring(queue, workerStop, queueName, fallbackName, prefetch, handle)
+}
 
-	err = ch.Qos(1, 0, false)
-	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to set prefetch count: %v", err)
+// preferredWait is how long a worker which handled a message of the preferred
+// queue waits for the next one before taking a message of the fallback queue,
+// the broker needs the acknowledgement to deliver the next message.
+const preferredWait = 50 * time.Millisecond
+
+// delivery is a message of one of the queues of consumePreferring, done is
+// closed once the message is handled so that its consumer fetches the next.
+type delivery struct {
+	queueName string
+	msg       mq.Delivery
+	done      chan struct{}
+}
+
+// consumePreferring consumes preferred and fallback until stop is closed and
+// handles one message at a time, a message of fallback is only handled when
+// preferred has none. The message a consumer holds at stop is requeued.
+func consumePreferring(queue mq.Queue, stop <-chan struct{}, preferred, fallback string, prefetch int, handle func(queueName string, msg mq.Delivery)) {
+	preferredCh := make(chan delivery)
+	fallbackCh := make(chan delivery)
+	var consumers sync.WaitGroup
+	for queueName, ch := range map[string]chan delivery{preferred: preferredCh, fallback: fallbackCh} {
+		queueName, ch := queueName, ch
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			queue.Consume(stop, queueName, indexerConsumerTag, prefetch, func(msg mq.Delivery) {
+				d := delivery{queueName: queueName, msg: msg, done: make(chan struct{})}
+				select {
+				case ch <- d:
+					<-d.done
+				case <-stop:
+					msg.Nack(true)
+				}
+			})
+		}()
+	}
+	defer consumers.Wait()
+
+	// next returns the next message to handle, false once stop is closed
+	next := func(afterPreferred bool) (delivery, bool) {
+		select {
+		case d := <-preferredCh:
+			return d, true
+		default:
+		}
+		if afterPreferred {
+			select {
+			case d := <-preferredCh:
+				return d, true
+			case <-time.After(preferredWait):
+			case <-stop:
+				return delivery{}, false
+			}
+		}
+		select {
+		case d := <-preferredCh:
+			return d, true
+		case d := <-fallbackCh:
+			return d, true
+		case <-stop:
+			return delivery{}, false
+		}
+	}
+
+	afterPreferred := false
+	for {
+		d, ok := next(afterPreferred)
+		if !ok {
+			return
+		}
+		handle(d.queueName, d.msg)
+		close(d.done)
+		afterPreferred = d.queueName == preferred
+	}
+}
+
+// Stop makes the workers stop consuming, jobs being indexed are finished.
+func (s *ScanHandler) Stop() {
+	s.stopOnce.Do(func() { close(s.stopping) })
+}
+
+func (s *ScanHandler) isStopping() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// abandon handles a job which failed at block failed. A job interrupted by
+// the shutdown deadline goes back to the queue as a whole, otherwise the
+// blocks before failed are completed and the rest of the range is published
+// again until MAX_RETRY_TIME attempts were made.
+func (s *ScanHandler) abandon(ctx context.Context, queue mq.Queue, msg mq.Delivery, job model.BlockJob, failed int64) {
+	if ctx.Err() != nil {
+		msg.Nack(true)
 		return
 	}
+	if failed > job.From {
+		if err := s.publishDone(ctx, queue, job.Range(job.From, failed-1)); err != nil {
+			logger.GetLogger().Sugar().Errorf("Failed to publish a message: %v", err)
+			msg.Nack(true)
+			return
+		}
+	}
 
-	queue, err := ch.QueueDeclare(
-		"blockNumber_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to declare a queue: %v", err)
+	retry := job.Range(failed, job.To)
+	retry.Attempt++
+	if retry.Attempt >= config.GetConfig().MaxRetryTime {
+		logger.GetLogger().Sugar().Errorf("job %s dropped after %d attempts, blocks %d-%d not indexed", job.JobId, retry.Attempt, retry.From, retry.To)
+		msg.Ack()
+		return
+	}
+	if err := s.publishJob(ctx, queue, retry); err != nil {
+		logger.GetLogger().Sugar().Errorf("Failed to publish a message: %v", err)
+		msg.Nack(true)
 		return
 	}
+	msg.Ack()
+}
 
-	msgs, err := ch.Consume(
-		queue.Name,
-		"indexer_service",
-		false,
-		false,
-		false,
-		false,
-		nil,
-	)
+// scanJob indexes the blocks of the job of msg in the trace of the producer
+// that published it.
+func (s *ScanHandler) scanJob(ctx context.Context, queue mq.Queue, queueName string, msg mq.Delivery) {
+	job, err := model.DecodeBlockJob(msg.Body(), s.chain.ChainId)
 	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to declare a queue: %v", err)
+		logger.GetLogger().Sugar().Errorf("scan job error: %s", err.Error())
+		msg.Nack(false)
 		return
 	}
 
-	for msg := range msgs {
-		blockNumber, err := strconv.ParseUint(string(msg.Body), 10, 64)
-		blockNumberBig := big.NewInt(int64(blockNumber))
-		block, err := ethClient.BlockByNumber(ctx, blockNumberBig)
+	headers := msg.Headers()
+	if len(job.TraceContext) != 0 {
+		headers = job.TraceContext
+	}
+	ctx, span := tracing.StartConsume(ctx, queue.System(), queueName, headers)
+	defer func() { tracing.End(span, err) }()
+	span.SetAttributes(
+		attribute.String("job.id", job.JobId),
+		attribute.Int64("block.from", job.From),
+		attribute.Int64("block.to", job.To),
+		attribute.Int("job.attempt", job.Attempt),
+		attribute.String("job.reason", job.Reason),
+		attribute.String("job.write_mode", job.WriteMode()),
+		attribute.String("chain", s.chain.Name))
 
-		if err != nil {
-			logger.GetLogger().Sugar().Errorf("scan block %d error: %s", blockNumber, err.Error())
-			msg.Ack(false)
-			continue
+	for number := job.From; number <= job.To; number++ {
+		if err = s.scanBlock(ctx, number, job.WriteMode()); err != nil {
+			logger.GetLogger().Sugar().Errorf("scan block %d of job %s error: %s", number, job.JobId, err.Error())
+			s.abandon(ctx, queue, msg, job, number)
+			return
 		}
+	}
 
-		err = s.getBlockInfo(ctx, block)
-		if err != nil {
-			logger.GetLogger().Sugar().Errorf("scan block %d error: %s", blockNumber, err.Error())
-			msg.Ack(false)
-			continue
+	if s.isStopping() {
+		s.pendingMu.Lock()
+		s.pending = append(s.pending, pendingDone{ctx: ctx, job: job, msg: msg})
+		s.pendingMu.Unlock()
+		return
+	}
+	s.scanDone(ctx, queue, job, msg)
+}
+
+// scanBlock indexes one block of a job, its rows are written in mode.
+func (s *ScanHandler) scanBlock(ctx context.Context, number int64, mode string) error {
+	s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+
+	block, deposits, err := s.blockScanner.BlockWithDeposits(ctx, big.NewInt(number))
+	if err != nil {
+		return fmt.Errorf("scanBlock : %w", err)
+	}
+	blockData, err := s.getBlockInfo(ctx, block, deposits)
+	if err != nil {
+		return fmt.Errorf("scanBlock : %w", err)
+	}
+	if err := s.saveBlock(ctx, mode, blockData); err != nil {
+		return fmt.Errorf("scanBlock : %w", err)
+	}
+	s.reportIndexed(block, deposits, len(blockData.Logs))
+	return nil
+}
+
+// saveBlock passes the rows of an insert to the consumers, the rows of the
+// other modes are written at once so that the block is never half replaced.
+// The block goes to ClickHouse once written to the database, or instead of it
+// in only mode where the database keeps the balances alone.
+func (s *ScanHandler) saveBlock(ctx context.Context, mode string, blockData *model.BlockData) error {
+	if config.GetConfig().ClickHouse.Mode != c.ClickHouseModeOnly {
+		if err := s.saveBlockRows(ctx, mode, blockData); err != nil {
+			return err
 		}
+	} else if err := s.saveBalances(ctx, mode, blockData); err != nil {
+		return err
+	}
+	// the api would keep serving the cached rows of a rewritten block
+	if s.cache != nil {
+		if err := s.cache.SaveBlockData(ctx, mode, blockData); err != nil {
+			return err
+		}
+	}
+	if s.clickHouseConsumer != nil {
+		s.clickHouseConsumer.GetChan().(chan consumer.Traced[model.BlockWrite]) <- consumer.Traced[model.BlockWrite]{
+			Row:  model.BlockWrite{Mode: mode, Block: blockData},
+			Span: trace.SpanContextFromContext(ctx),
+		}
+	}
+	return nil
+}
 
-		s.scanDone(mqConn, blockNumberBig, &msg)
+func (s *ScanHandler) saveBlockRows(ctx context.Context, mode string, blockData *model.BlockData) error {
+	if mode != c.WriteModeInsert {
+		return s.store.SaveBlockData(ctx, mode, blockData)
+	}
+	span := trace.SpanContextFromContext(ctx)
+	s.blockConsumer.GetChan().(chan consumer.Traced[model.BlockRow]) <- consumer.Traced[model.BlockRow]{Row: blockData.Block, Span: span}
+	for _, withdrawalRow := range blockData.Withdrawals {
+		s.withdrawalConsumer.GetChan().(chan consumer.Traced[model.WithdrawalRow]) <- consumer.Traced[model.WithdrawalRow]{Row: withdrawalRow, Span: span}
+	}
+	for _, txRow := range blockData.Txs {
+		s.txConsumer.GetChan().(chan consumer.Traced[model.TransactionRow]) <- consumer.Traced[model.TransactionRow]{Row: txRow, Span: span}
 	}
+	for _, logRow := range blockData.Logs {
+		s.logConsumer.GetChan().(chan consumer.Traced[model.LogRow]) <- consumer.Traced[model.LogRow]{Row: logRow, Span: span}
+	}
+	for _, balanceRow := range blockData.Balances {
+		s.balanceConsumer.GetChan().(chan consumer.Traced[model.BalanceRow]) <- consumer.Traced[model.BalanceRow]{Row: balanceRow, Span: span}
+	}
+	return nil
 }
 
-func (s *ScanHandler) getBlockInfo(ctx context.Context, block *types.Block) error {
+func (s *ScanHandler) saveBalances(ctx context.Context, mode string, blockData *model.BlockData) error {
+	if s.balanceConsumer == nil {
+		return nil
+	}
+	if mode != c.WriteModeInsert {
+		return s.store.SaveBlockBalances(ctx, mode, blockData.Block.Number, blockData.Balances)
+	}
+	span := trace.SpanContextFromContext(ctx)
+	for _, balanceRow := range blockData.Balances {
+		s.balanceConsumer.GetChan().(chan consumer.Traced[model.BalanceRow]) <- consumer.Traced[model.BalanceRow]{Row: balanceRow, Span: span}
+	}
+	return nil
+}
 
-	blockRow := convertBlockToRow(block)
-	// sned to chan
-	s.blockConsumer.GetChan().(chan model.BlockRow) <- blockRow
+// PublishPending publishes the completions of the jobs indexed while
+// stopping, the consumers have to be flushed before.
+func (s *ScanHandler) PublishPending(queue mq.Queue) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	for _, done := range s.pending {
+		s.scanDone(done.ctx, queue, done.job, done.msg)
+	}
+	s.pending = nil
+}
+
+// getBlockInfo returns the rows of block with the balances of the addresses
+// it touched.
+func (s *ScanHandler) getBlockInfo(ctx context.Context, block *types.Block, deposits []chainprofile.DepositTx) (*model.BlockData, error) {
+
+	blockRow, err := convert.BlockToRow(block, s.profile)
+	if err != nil {
+		logger.LoadExtra(map[string]interface{}{
+			"err": err.Error(),
+		}).Error("convert block to row error")
+		return nil, fmt.Errorf("scanBlockInfo: %s", err.Error())
+	}
+	blockData := &model.BlockData{Block: blockRow}
+
+	for _, withdrawal := range block.Withdrawals() {
+		blockData.Withdrawals = append(blockData.Withdrawals, convertWithdrawalToRow(withdrawal, *block.Number()))
+	}
+
+	// deposit transactions come first in OP-stack blocks
+	for _, deposit := range deposits {
+		txRow := convertDepositTxToRow(deposit, *block.Number())
+		receipt, err := s.getReceipt(ctx, deposit.Hash, &txRow)
+		if err != nil {
+			logger.LoadExtra(map[string]interface{}{
+				"err": err.Error(),
+			}).Error("get logs error")
+			return nil, fmt.Errorf("scanBlockInfo: %s", err.Error())
+		}
+		blockData.Txs = append(blockData.Txs, txRow)
+
+		for _, log := range receipt.Logs {
+			blockData.Logs = append(blockData.Logs, convertLogToRow(log, deposit.Hash.Hex()))
+		}
+	}
 
+	scannedTxs := make([]scannedTx, 0, len(block.Transactions()))
 	for _, tx := range block.Transactions() {
-		txRow, err := convertTxToRow(tx, *block.Number())
+		txRow, err := convertTxToRow(tx, *block.Number(), s.profile)
 		if err != nil {
 			logger.LoadExtra(map[string]interface{}{
 				"err": err.Error(),
 			}).Error("convert tx to row error")
-			return fmt.Errorf("scanBlockInfo: %s", err.Error())
+			return nil, fmt.Errorf("scanBlockInfo: %s", err.Error())
 		}
 
-		s.txConsumer.GetChan().(chan model.TransactionRow) <- txRow
-
 		_, isPending, err := s.txScanner.TxDetailByHash(ctx, tx.Hash())
 		if err != nil && !strings.Contains(err.Error(), "LogsByTxHash : not found") {
 			logger.LoadExtra(map[string]interface{}{
 				"err": err.Error(),
 			}).Error("get logs error")
-			return fmt.Errorf("scanBlockInfo: %s", err.Error())
+			return nil, fmt.Errorf("scanBlockInfo: %s", err.Error())
 		}
 		if isPending {
+			blockData.Txs = append(blockData.Txs, txRow)
+			scannedTxs = append(scannedTxs, scannedTx{tx: tx, from: ethCommon.HexToAddress(txRow.From)})
 			continue
 		}
-		logs, err := s.logScanner.GetLogs(ctx, tx.Hash())
+		receipt, err := s.getReceipt(ctx, tx.Hash(), &txRow)
 		if err != nil {
 			logger.LoadExtra(map[string]interface{}{
 				"err": err.Error(),
 			}).Error("get logs error")
-			return fmt.Errorf("scanBlockInfo: %s", err.Error())
+			return nil, fmt.Errorf("scanBlockInfo: %s", err.Error())
 		}
+		blockData.Txs = append(blockData.Txs, txRow)
+		scannedTxs = append(scannedTxs, scannedTx{tx: tx, from: ethCommon.HexToAddress(txRow.From), receipt: receipt})
 
-		for _, log := range logs {
-			logRow := convertLogToRow(log, tx.Hash().Hex())
-			s.logConsumer.GetChan().(chan model.LogRow) <- logRow
+		for _, log := range receipt.Logs {
+			blockData.Logs = append(blockData.Logs, convertLogToRow(log, tx.Hash().Hex()))
 		}
 	}
-	return nil
-}
 
-func (s *ScanHandler) scanDone(conn *amqp.Connection, blockNumber *big.Int, msg *amqp.Delivery) {
-	ch, err := conn.Channel()
+	blockData.Balances, err = s.trackBalances(ctx, block, scannedTxs)
 	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to open a channel: %v", err)
-		return
+		logger.LoadExtra(map[string]interface{}{
+			"err": err.Error(),
+		}).Error("track balances error")
+		return nil, fmt.Errorf("scanBlockInfo: %s", err.Error())
 	}
-	defer ch.Close()
+	return blockData, nil
+}
 
-	queue, err := ch.QueueDeclare(
-		"blockNumber_done_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+// getReceipt returns the receipt of the transaction and fills the chain specific
+// receipt fields of its row.
+func (s *ScanHandler) getReceipt(ctx context.Context, txHash ethCommon.Hash, txRow *model.TransactionRow) (*types.Receipt, error) {
+	rawReceipt, err := s.logScanner.GetRawReceipt(ctx, txHash)
 	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to declare a queue: %v", err)
-		return
+		return nil, fmt.Errorf("getReceipt: %w", err)
+	}
+	var receipt *types.Receipt
+	if err := json.Unmarshal(rawReceipt, &receipt); err != nil {
+		return nil, fmt.Errorf("getReceipt: %w", err)
+	}
+	if err := s.profile.FillReceiptFields(txRow, rawReceipt); err != nil {
+		return nil, fmt.Errorf("getReceipt: %w", err)
 	}
+	return receipt, nil
+}
 
-	err = ch.Publish(
-		"",
-		queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(blockNumber.String()),
-		},
-	)
-	if err != nil {
+// reportIndexed counts the rows of an indexed block and moves the indexed head.
+func (s *ScanHandler) reportIndexed(block *types.Block, deposits []chainprofile.DepositTx, logs int) {
+	monitor.IndexedBlocks.WithLabelValues(s.chain.Name).Inc()
+	monitor.IndexedTxs.WithLabelValues(s.chain.Name).Add(float64(len(block.Transactions()) + len(deposits)))
+	monitor.IndexedLogs.WithLabelValues(s.chain.Name).Add(float64(logs))
+
+	number := block.NumberU64()
+	for {
+		head := s.indexedHead.Load()
+		if number <= head {
+			return
+		}
+		if s.indexedHead.CompareAndSwap(head, number) {
+			monitor.IndexedHead.WithLabelValues(s.chain.Name).Set(float64(number))
+			return
+		}
+	}
+}
+
+// scanDone acknowledges the job of msg once the broker confirmed its
+// completion, the job goes back to the queue when the completion is not
+// published.
+func (s *ScanHandler) scanDone(ctx context.Context, queue mq.Queue, job model.BlockJob, msg mq.Delivery) {
+	if err := s.publishDone(ctx, queue, job); err != nil {
 		logger.GetLogger().Sugar().Errorf("Failed to publish a message: %v", err)
+		msg.Nack(true)
 		return
 	}
+	msg.Ack()
+	logger.GetLogger().Sugar().Infof("Published a message: job %s blocks %d-%d", job.JobId, job.From, job.To)
+}
 
-	msg.Ack(true)
-	logger.GetLogger().Sugar().Infof("Published a message: %s", blockNumber.String())
+// publishDone publishes the completion of the blocks of job.
+func (s *ScanHandler) publishDone(ctx context.Context, queue mq.Queue, job model.BlockJob) error {
+	return s.publish(ctx, queue, s.chain.BlockNumberDoneQueue(), job)
 }
 
-func (s *ScanHandler) Shutdown() {
+// publishJob publishes job to the lane of its reason.
+func (s *ScanHandler) publishJob(ctx context.Context, queue mq.Queue, job model.BlockJob) error {
+	return s.publish(ctx, queue, s.chain.JobQueue(job.Reason), job)
+}
+
+func (s *ScanHandler) publish(ctx context.Context, queue mq.Queue, queueName string, job model.BlockJob) error {
+	ctx, span := tracing.StartPublish(ctx, queue.System(), queueName)
+	job.TraceContext = tracing.InjectHeaders(ctx)
+	body, err := job.Encode()
+	if err == nil {
+		err = mq.PublishConfirmed(ctx, queue, queueName, mq.Message{
+			Headers:     job.TraceContext,
+			Body:        body,
+			ContentType: model.BlockJobContentType,
+		})
+	}
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("publish : %w", err)
+	}
+	monitor.QueuePublished.WithLabelValues(queueName).Inc()
+	return nil
+}
+
+// FlushConsumers stores the rows left in the consumers and waits for them.
+func (s *ScanHandler) FlushConsumers() {
 	s.blockConsumer.Shutdown()
 	s.txConsumer.Shutdown()
 	s.logConsumer.Shutdown()
+	s.withdrawalConsumer.Shutdown()
+	if s.balanceConsumer != nil {
+		s.balanceConsumer.Shutdown()
+	}
+	if s.clickHouseConsumer != nil {
+		s.clickHouseConsumer.Shutdown()
+	}
+}
+
+func (s *ScanHandler) Shutdown() {
+	if s.balanceScanner != nil {
+		s.balanceScanner.Shutdown()
+	}
 	s.blockScanner.Shutdown()
 	s.txScanner.Shutdown()
+	s.logScanner.Shutdown()
 }
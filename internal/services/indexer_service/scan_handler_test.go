@@ -0,0 +1,42 @@
+package indexer_service
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedDelivery struct {
+	acked   bool
+	requeue *bool
+}
+
+func (d *recordedDelivery) Body() []byte               { return nil }
+func (d *recordedDelivery) Headers() map[string]string { return nil }
+func (d *recordedDelivery) Ack() error                 { d.acked = true; return nil }
+func (d *recordedDelivery) Nack(requeue bool) error    { d.requeue = &requeue; return nil }
+
+func TestScanDone(t *testing.T) {
+	s := &ScanHandler{chain: config.ChainOption{Name: "mainnet"}}
+	job := model.NewBlockJob(0, 100, 199, c.JobReasonBackfill)
+
+	queue := mq.NewMemory()
+	msg := &recordedDelivery{}
+	s.scanDone(context.Background(), queue, job, msg)
+	assert.True(t, msg.acked)
+	assert.Equal(t, 1, queue.Len(s.chain.BlockNumberDoneQueue()))
+
+	// the job is indexed again when its completion cannot be published
+	queue.Close()
+	msg = &recordedDelivery{}
+	s.scanDone(context.Background(), queue, job, msg)
+	assert.False(t, msg.acked)
+	if assert.NotNil(t, msg.requeue) {
+		assert.True(t, *msg.requeue)
+	}
+}
@@ -2,55 +2,85 @@ package indexer_service
 
 import (
 	"Ethereum_Service/config"
+	"Ethereum_Service/internal/chainprofile"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/internal/scanner"
 	"Ethereum_Service/pkg/utils/logger"
 	"context"
 	"fmt"
-	"math/big"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/streadway/amqp"
 )
 
 const (
 	storeInterval = 10 * time.Second
+
+	indexerConsumerTag = "indexer_service"
+
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 type Service struct {
-	ethClient *ethclient.Client
-
-	scanHandler ScanHandler
-	mqConn      *amqp.Connection
-	rcpEndpoint string
+	ethClient    *ethclient.Client
+	mysqlHandler data.DataHandler
+	pool         *scanner.EndpointPool
+
+	headLane     *lane
+	backfillLane *lane
+	// concurrency sizes the backfill lane when CONCURRENCY.ADAPTIVE is set
+	concurrency *aimd
+
+	scanHandler *ScanHandler
+	// queue is set by Start, the health checks and Shutdown read it with getQueue
+	queue   mq.Queue
+	queueMu sync.Mutex
+	chain   config.ChainOption
+	workers sync.WaitGroup
 
 	shutDownCtx  context.Context
 	cancel       context.CancelFunc
 	shutdownOnce sync.Once
 }
 
-func NewService(rcpEndpoint string) (*Service, error) {
-	ethClient, err := ethclient.Dial(rcpEndpoint)
+func NewService(chain config.ChainOption) (*Service, error) {
+	pool := scanner.NewEndpointPool(chain.RCPEndpoints)
+	ethClient, err := ethclient.Dial(pool.Next())
 	if err != nil {
 		return nil, fmt.Errorf("newDefaultEthHandler : %s", err.Error())
 	}
-	scanHandler := NewScanHandler(rcpEndpoint)
-	s := Service{
-		ethClient:   ethClient,
-		scanHandler: scanHandler,
-		rcpEndpoint: rcpEndpoint,
+	profile, err := chainprofile.New(chain.Profile, chain.ChainId)
+	if err != nil {
+		return nil, fmt.Errorf("NewService : %s", err.Error())
+	}
+	mysqlHandler, err := data.NewDatabaseHandler(&config.GetConfig().Databases, chain.ChainId)
+	if err != nil {
+		return nil, fmt.Errorf("NewService : %s", err.Error())
 	}
+	scanHandler := NewScanHandler(chain, pool, profile, mysqlHandler)
+	s := &Service{
+		ethClient:    ethClient,
+		mysqlHandler: mysqlHandler,
+		pool:         pool,
+		scanHandler:  scanHandler,
+		chain:        chain,
+		headLane:     &lane{name: laneHead, queueName: chain.BlockNumberHeadQueue(), fallbackName: chain.BlockNumberQueue()},
+		backfillLane: &lane{name: laneBackfill, queueName: chain.BlockNumberQueue()},
+	}
+	s.shutDownCtx, s.cancel = context.WithCancel(context.Background())
 
-	return &s, nil
+	return s, nil
 }
 
 func (s *Service) Start(workerCount int) {
-	logger.GetLogger().Sugar().Infof("start indexer service with %d workers", workerCount)
-	s.shutDownCtx, s.cancel = context.WithCancel(context.Background())
+	logger.GetLogger().Sugar().Infof("start indexer service of chain %s with %d workers", s.chain.Name, workerCount)
 
-	err := s.createMqConn()
+	err := s.createQueue()
 	if err != nil {
-		logger.GetLogger().Sugar().Fatalf("Failed to create RabbitMQ connection: %v", err)
+		logger.GetLogger().Sugar().Fatalf("Failed to connect to the queue: %v", err)
 		return
 	}
 
@@ -58,65 +88,77 @@ func (s *Service) Start(workerCount int) {
 	s.startScanners(workerCount)
 }
 
-func (s *Service) createMqConn() error {
-	conn, err := amqp.Dial(config.GetConfig().MQEndpoint)
+func (s *Service) createQueue() error {
+	queue, err := mq.New(config.GetConfig().Queue, config.GetConfig().MQEndpoint)
 	if err != nil {
 		return err
 	}
 
-	s.mqConn = conn
+	s.queueMu.Lock()
+	s.queue = queue
+	s.queueMu.Unlock()
 	return nil
 }
 
-func (s *Service) startScanners(workerCount int) {
-	for i := 0; i < workerCount; i++ {
-		go s.scanHandler.Scan(s.shutDownCtx, s.ethClient, s.mqConn)
-	}
+// getQueue returns the queue once Start created it, nil before.
+func (s *Service) getQueue() mq.Queue {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+	return s.queue
 }
 
-func (s *Service) publishBlockDone(blockNumber *big.Int, msg *amqp.Delivery) {
-	ch, err := s.mqConn.Channel()
-	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to open a channel: %v", err)
-		return
-	}
-	defer ch.Close()
-
-	queue, err := ch.QueueDeclare(
-		"blockNumber_done_queue",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-
-	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to declare a queue: %v", err)
-	}
-	err = ch.Publish(
-		"",
-		queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(blockNumber.String()),
-		},
-	)
-	if err != nil {
-		logger.GetLogger().Sugar().Errorf("Failed to publish a message: %v", err)
-		return
-	}
-	msg.Ack(true)
-	logger.GetLogger().Sugar().Infof("Published a message: %s", blockNumber.String())
-
+// RegisterHealth adds the dependencies and the progress of the indexer to health.
+func (s *Service) RegisterHealth(health *monitor.Health) {
+	health.AddCheck(s.chain.Name+"/mysql", s.mysqlHandler.Ping)
+	health.AddCheck(s.chain.Name+"/queue", monitor.QueueCheck(s.getQueue))
+	health.AddCheck(s.chain.Name+"/rpc", monitor.RPCCheck(s.ethClient))
+	health.AddStatus(func(ctx context.Context) monitor.ChainStatus {
+		status := monitor.ChainStatus{
+			Chain:    s.chain.Name,
+			Head:     int64(s.scanHandler.indexedHead.Load()),
+			InFlight: s.scanHandler.inFlight.Load(),
+			Workers:  s.workerCount(),
+		}
+		watermark, err := s.mysqlHandler.GetLatestBlockNumber(ctx)
+		if err == nil {
+			status.Watermark = watermark
+		}
+		return status
+	})
 }
 
+// Shutdown stops consuming and lets the blocks being indexed finish within
+// SHUTDOWN_TIMEOUT, blocks interrupted by the deadline are returned to the
+// queue. The consumers are flushed before the completions of the finished
+// blocks are published, the connections are closed last.
 func (s *Service) Shutdown() {
 	s.shutdownOnce.Do(func() {
-		s.mqConn.Close()
+		timeout := config.GetConfig().ShutdownTimeout
+		if timeout == 0 {
+			timeout = defaultShutdownTimeout
+		}
+
+		s.scanHandler.Stop()
+		done := make(chan struct{})
+		go func() {
+			s.workers.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			logger.GetLogger().Sugar().Warnf("indexer of chain %s: blocks still in flight after %s, interrupting", s.chain.Name, timeout)
+			s.cancel()
+			<-done
+		}
+
+		s.scanHandler.FlushConsumers()
+		if queue := s.getQueue(); queue != nil {
+			s.scanHandler.PublishPending(queue)
+			queue.Close()
+		}
 		s.scanHandler.Shutdown()
+		s.ethClient.Close()
 		s.cancel()
 	})
 }
@@ -0,0 +1,353 @@
+package mempool
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/pkg/model"
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultDropTimeout  = 30 * time.Minute
+	sweepBatchSize      = 500
+	// sweepLeaseName is the lease of the sweepers of a chain, every indexer
+	// replica runs a watcher but only one of them sweeps
+	sweepLeaseName = "mempool-sweep"
+	// maxCatchUpBlocks bounds how many blocks are checked for mined transactions after a restart
+	maxCatchUpBlocks = 100
+)
+
+// Watcher stores pending transactions of the node mempool and marks them
+// mined or dropped once they land in a block or disappear.
+type Watcher struct {
+	chain        config.ChainOption
+	ethClient    *ethclient.Client
+	mysqlHandler data.DataHandler
+	option       config.MempoolOption
+
+	lastBlockNumber uint64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWatcher(chain config.ChainOption, option config.MempoolOption) (*Watcher, error) {
+	if option.Mode != c.MempoolModeSubscribe && option.Mode != c.MempoolModePoll {
+		return nil, fmt.Errorf("NewWatcher : unknown mempool mode %s", option.Mode)
+	}
+	if option.Mode == c.MempoolModeSubscribe && chain.WsEndpoint == "" {
+		return nil, fmt.Errorf("NewWatcher : WS_ENDPOINT of chain %s is required in subscribe mode", chain.Name)
+	}
+	if option.PollInterval <= 0 {
+		option.PollInterval = defaultPollInterval
+	}
+	if option.DropTimeout <= 0 {
+		option.DropTimeout = defaultDropTimeout
+	}
+
+	ethClient, err := ethclient.Dial(scanner.NewEndpointPool(chain.RCPEndpoints).Next())
+	if err != nil {
+		return nil, fmt.Errorf("NewWatcher : %s", err.Error())
+	}
+	mysqlHandler, err := data.NewDatabaseHandler(&config.GetConfig().Databases, chain.ChainId)
+	if err != nil {
+		return nil, fmt.Errorf("NewWatcher : %s", err.Error())
+	}
+
+	return &Watcher{
+		chain:        chain,
+		ethClient:    ethClient,
+		mysqlHandler: mysqlHandler,
+		option:       option,
+	}, nil
+}
+
+func (w *Watcher) Start() {
+	logger.GetLogger().Sugar().Infof("start mempool watcher of chain %s in %s mode", w.chain.Name, w.option.Mode)
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.run(ctx, w.watchBlocks)
+	w.run(ctx, w.sweep)
+	switch w.option.Mode {
+	case c.MempoolModeSubscribe:
+		w.run(ctx, w.subscribe)
+	case c.MempoolModePoll:
+		w.run(ctx, w.poll)
+	}
+}
+
+func (w *Watcher) run(ctx context.Context, f func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		f(ctx)
+	}()
+}
+
+// subscribe listens to newPendingTransactions and re-subscribes when the websocket drops.
+func (w *Watcher) subscribe(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := w.subscribeOnce(ctx)
+		if err != nil {
+			logger.GetLogger().Sugar().Errorf("mempool subscribe : %s", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (w *Watcher) subscribeOnce(ctx context.Context) error {
+	client, err := rpc.DialContext(ctx, w.chain.WsEndpoint)
+	if err != nil {
+		return fmt.Errorf("subscribeOnce : %w", err)
+	}
+	defer client.Close()
+
+	hashes := make(chan common.Hash, 1024)
+	sub, err := client.EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		return fmt.Errorf("subscribeOnce : %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return fmt.Errorf("subscribeOnce : %w", err)
+		case hash := <-hashes:
+			tx, isPending, err := w.ethClient.TransactionByHash(ctx, hash)
+			if err != nil || !isPending {
+				continue
+			}
+			row, err := convertTxToPendingRow(tx, time.Now())
+			if err != nil {
+				logger.GetLogger().Sugar().Errorf("mempool subscribe : %s", err.Error())
+				continue
+			}
+			if err := w.mysqlHandler.SavePendingTxRows(ctx, []*model.PendingTxRow{&row}); err != nil {
+				logger.GetLogger().Sugar().Errorf("mempool subscribe : %s", err.Error())
+			}
+		}
+	}
+}
+
+type txpoolContent struct {
+	Pending map[common.Address]map[string]*types.Transaction `json:"pending"`
+}
+
+// poll reads txpool_content periodically, transactions missing from the next
+// poll are checked to be mined or dropped.
+func (w *Watcher) poll(ctx context.Context) {
+	seen := make(map[common.Hash]struct{})
+	t := time.NewTicker(w.option.PollInterval)
+	defer t.Stop()
+	for {
+		var content txpoolContent
+		err := w.ethClient.Client().CallContext(ctx, &content, "txpool_content")
+		if err != nil {
+			logger.GetLogger().Sugar().Errorf("mempool poll : %s", err.Error())
+		} else {
+			now := time.Now()
+			current := make(map[common.Hash]struct{})
+			rows := make([]*model.PendingTxRow, 0)
+			for _, txs := range content.Pending {
+				for _, tx := range txs {
+					current[tx.Hash()] = struct{}{}
+					if _, ok := seen[tx.Hash()]; ok {
+						continue
+					}
+					row, err := convertTxToPendingRow(tx, now)
+					if err != nil {
+						logger.GetLogger().Sugar().Errorf("mempool poll : %s", err.Error())
+						continue
+					}
+					rows = append(rows, &row)
+				}
+			}
+			if len(rows) != 0 {
+				if err := w.mysqlHandler.SavePendingTxRows(ctx, rows); err != nil {
+					logger.GetLogger().Sugar().Errorf("mempool poll : %s", err.Error())
+				}
+			}
+
+			disappeared := make([]string, 0)
+			for hash := range seen {
+				if _, ok := current[hash]; !ok {
+					disappeared = append(disappeared, hash.Hex())
+				}
+			}
+			w.resolve(ctx, disappeared)
+			seen = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// watchBlocks marks the transactions of every new block as mined.
+func (w *Watcher) watchBlocks(ctx context.Context) {
+	t := time.NewTicker(w.option.PollInterval)
+	defer t.Stop()
+	for {
+		head, err := w.ethClient.BlockNumber(ctx)
+		if err != nil {
+			logger.GetLogger().Sugar().Errorf("mempool watchBlocks : %s", err.Error())
+		} else {
+			if w.lastBlockNumber == 0 || head-w.lastBlockNumber > maxCatchUpBlocks {
+				w.lastBlockNumber = head - 1
+			}
+			for number := w.lastBlockNumber + 1; number <= head; number++ {
+				if err := w.markBlockMined(ctx, number); err != nil {
+					logger.GetLogger().Sugar().Errorf("mempool watchBlocks : %s", err.Error())
+					break
+				}
+				w.lastBlockNumber = number
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (w *Watcher) markBlockMined(ctx context.Context, number uint64) error {
+	block, err := w.ethClient.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return fmt.Errorf("markBlockMined : %w", err)
+	}
+	hashes := make([]string, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		hashes = append(hashes, tx.Hash().Hex())
+	}
+	if err := w.mysqlHandler.UpdatePendingTxStatus(ctx, hashes, c.TxStatusMined); err != nil {
+		return fmt.Errorf("markBlockMined : %w", err)
+	}
+	return nil
+}
+
+// sweep resolves transactions which stayed pending longer than the drop
+// timeout, on the replica holding the sweep lease.
+func (w *Watcher) sweep(ctx context.Context) {
+	interval := w.option.DropTimeout / 2
+	holder := data.LeaseHolder()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		// the lease outlives the interval so that the holder renews it on its next tick
+		ok, err := w.mysqlHandler.AcquireLease(ctx, sweepLeaseName, holder, 2*interval)
+		if err != nil {
+			logger.GetLogger().Sugar().Errorf("mempool sweep : %s", err.Error())
+			continue
+		}
+		if ok {
+			w.sweepOnce(ctx, time.Now().Add(-w.option.DropTimeout))
+		}
+	}
+}
+
+// sweepOnce pages through the transactions pending since before seenBefore,
+// rows the node still has pending are skipped by the cursor.
+func (w *Watcher) sweepOnce(ctx context.Context, seenBefore time.Time) {
+	var after *model.PendingTxRow
+	for ctx.Err() == nil {
+		rows, err := w.mysqlHandler.GetPendingTxRowsSeenBefore(ctx, seenBefore, after, sweepBatchSize)
+		if err != nil {
+			logger.GetLogger().Sugar().Errorf("mempool sweep : %s", err.Error())
+			return
+		}
+		hashes := make([]string, 0, len(rows))
+		for _, row := range rows {
+			hashes = append(hashes, row.Hash)
+		}
+		w.resolve(ctx, hashes)
+		if len(rows) < sweepBatchSize {
+			return
+		}
+		after = &rows[len(rows)-1]
+	}
+}
+
+// resolve asks the node about transactions which left the mempool.
+func (w *Watcher) resolve(ctx context.Context, hashes []string) {
+	mined := make([]string, 0)
+	dropped := make([]string, 0)
+	for _, hash := range hashes {
+		_, isPending, err := w.ethClient.TransactionByHash(ctx, common.HexToHash(hash))
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			dropped = append(dropped, hash)
+		case err != nil:
+			logger.GetLogger().Sugar().Errorf("mempool resolve : %s", err.Error())
+		case !isPending:
+			mined = append(mined, hash)
+		}
+	}
+	if err := w.mysqlHandler.UpdatePendingTxStatus(ctx, mined, c.TxStatusMined); err != nil {
+		logger.GetLogger().Sugar().Errorf("mempool resolve : %s", err.Error())
+	}
+	if err := w.mysqlHandler.UpdatePendingTxStatus(ctx, dropped, c.TxStatusDropped); err != nil {
+		logger.GetLogger().Sugar().Errorf("mempool resolve : %s", err.Error())
+	}
+}
+
+func (w *Watcher) Shutdown() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+	w.ethClient.Close()
+}
+
+func convertTxToPendingRow(tx *types.Transaction, seen time.Time) (model.PendingTxRow, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return model.PendingTxRow{}, fmt.Errorf("convertTxToPendingRow : %w", err)
+	}
+	row := model.PendingTxRow{
+		Hash:      tx.Hash().Hex(),
+		From:      from.Hex(),
+		Value:     tx.Value().String(),
+		Nonce:     tx.Nonce(),
+		Data:      tx.Data(),
+		Status:    c.TxStatusPending,
+		FirstSeen: seen,
+		UpdatedAt: seen,
+	}
+	if tx.To() != nil {
+		row.To = tx.To().Hex()
+	}
+	return row, nil
+}
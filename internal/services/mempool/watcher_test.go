@@ -0,0 +1,92 @@
+package mempool
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/internal/data"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// ethService answers eth_getTransactionByHash like a node, mined
+// transactions have a block number, unknown ones are null.
+type ethService struct {
+	pending map[common.Hash]*types.Transaction
+	mined   map[common.Hash]*types.Transaction
+	failing map[common.Hash]struct{}
+}
+
+func (s *ethService) GetTransactionByHash(hash common.Hash) (map[string]interface{}, error) {
+	if _, ok := s.failing[hash]; ok {
+		return nil, errors.New("node unavailable")
+	}
+	tx, isPending := s.pending[hash]
+	if !isPending {
+		var ok bool
+		if tx, ok = s.mined[hash]; !ok {
+			return nil, nil
+		}
+	}
+	raw, err := tx.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if !isPending {
+		fields["blockNumber"] = "0x1"
+	}
+	return fields, nil
+}
+
+// statusStore records the status updates of the watcher.
+type statusStore struct {
+	data.DataHandler
+	status map[string]string
+}
+
+func (s *statusStore) UpdatePendingTxStatus(ctx context.Context, hashes []string, status string) error {
+	for _, hash := range hashes {
+		s.status[hash] = status
+	}
+	return nil
+}
+
+func TestResolve(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	newTx := func(nonce uint64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: nonce, Gas: 21000, GasPrice: big.NewInt(1)}), signer, key)
+		assert.NoError(t, err)
+		return tx
+	}
+	pending, mined, dropped, failing := newTx(0), newTx(1), newTx(2), newTx(3)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	assert.NoError(t, server.RegisterName("eth", &ethService{
+		pending: map[common.Hash]*types.Transaction{pending.Hash(): pending},
+		mined:   map[common.Hash]*types.Transaction{mined.Hash(): mined},
+		failing: map[common.Hash]struct{}{failing.Hash(): {}},
+	}))
+	store := &statusStore{status: make(map[string]string)}
+	w := &Watcher{ethClient: ethclient.NewClient(rpc.DialInProc(server)), mysqlHandler: store}
+
+	w.resolve(context.Background(), []string{pending.Hash().Hex(), mined.Hash().Hex(), dropped.Hash().Hex(), failing.Hash().Hex()})
+	assert.Equal(t, map[string]string{
+		mined.Hash().Hex():   c.TxStatusMined,
+		dropped.Hash().Hex(): c.TxStatusDropped,
+	}, store.status)
+}
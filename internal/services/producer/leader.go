@@ -0,0 +1,104 @@
+package producer
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/pkg/utils/logger"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// leaseName is the lease of the producers of a chain
+	leaseName = "producer"
+
+	defaultLeaseTTL = 10 * time.Second
+)
+
+// campaign tries to take the lease of the chain until Shutdown and leads
+// while holding it. A standby takes over within LEASE_TTL once the leader
+// stopped renewing the lease.
+func (p *Producer) campaign() {
+	ttl := config.GetConfig().LeaderElection.LeaseTTL
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+	holder := data.LeaseHolder()
+	logger.GetLogger().Sugar().Infof("producer of chain %s campaigning as %s", p.chain.Name, holder)
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		ok, err := p.mysqlHandler.AcquireLease(p.ctx, leaseName, holder, ttl)
+		if err != nil && !p.isStopping() {
+			logger.GetLogger().Sugar().Errorf("campaign : %s", err.Error())
+		}
+		if ok {
+			logger.GetLogger().Sugar().Infof("producer of chain %s is the leader", p.chain.Name)
+			monitor.ProducerLeader.WithLabelValues(p.chain.Name).Set(1)
+			p.leadWhileHeld(holder, ttl, start.Add(ttl))
+			monitor.ProducerLeader.WithLabelValues(p.chain.Name).Set(0)
+			logger.GetLogger().Sugar().Infof("producer of chain %s stepped down", p.chain.Name)
+		}
+
+		select {
+		case <-p.ctx.Done():
+			// let a standby take over without waiting for the lease to expire
+			err := p.mysqlHandler.ReleaseLease(context.Background(), leaseName, holder)
+			if err != nil {
+				logger.GetLogger().Sugar().Errorf("campaign : %s", err.Error())
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leadWhileHeld leads until Shutdown or until the lease is lost, the lease
+// is renewed every ttl/3. The leader steps down when another producer holds
+// the lease or when it could not be renewed before the last third of its
+// validity, so that it stops publishing before a standby takes over.
+func (p *Producer) leadWhileHeld(holder string, ttl time.Duration, expires time.Time) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	var leading sync.WaitGroup
+	leading.Add(1)
+	go func() {
+		defer leading.Done()
+		p.lead(ctx)
+	}()
+	defer func() {
+		cancel()
+		leading.Wait()
+	}()
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		ok, err := p.mysqlHandler.AcquireLease(ctx, leaseName, holder, ttl)
+		switch {
+		case err == nil && ok:
+			expires = start.Add(ttl)
+		case err == nil:
+			logger.GetLogger().Sugar().Warnf("producer of chain %s lost the lease", p.chain.Name)
+			return
+		case ctx.Err() != nil:
+			return
+		default:
+			logger.GetLogger().Sugar().Errorf("leadWhileHeld : %s", err.Error())
+			if time.Until(expires) < ttl/3 {
+				logger.GetLogger().Sugar().Warnf("producer of chain %s could not renew the lease", p.chain.Name)
+				return
+			}
+		}
+	}
+}
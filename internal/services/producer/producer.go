@@ -1,100 +1,256 @@
 package producer
 
 import (
+	"Ethereum_Service/c"
 	"Ethereum_Service/config"
 	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/internal/scanner"
+	"Ethereum_Service/internal/tracing"
+	"Ethereum_Service/pkg/model"
 	"Ethereum_Service/pkg/utils/logger"
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"strconv"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type Producer struct {
-	ethClient           *ethclient.Client
-	mysqlHandler        data.DataHandler
-	mqConn              *amqp.Connection
-	latestBlockNumber   uint64
-	dbLatestBlockNumber int64
+	chain        config.ChainOption
+	ethClient    *ethclient.Client
+	mysqlHandler data.DataHandler
+	// queue is set by Start, the health checks read it with getQueue
+	queue   mq.Queue
+	queueMu sync.Mutex
+
+	// the progress is read by the health status while the producer runs
+	latestBlockNumber   atomic.Uint64
+	dbLatestBlockNumber atomic.Int64
+	enqueuedBlockNumber atomic.Int64
+
+	// ctx is cancelled by Shutdown, workers tracks the goroutines to wait for
+	ctx          context.Context
+	cancel       context.CancelFunc
+	workers      sync.WaitGroup
+	shutdownOnce sync.Once
 }
 
 const (
-	BlockNumberQueueName       = "blockNumber_queue"
-	BlockNumberDoneQueueName   = "blockNumber_done_queue"
 	ProducerServiceConsumerTag = "producer_service"
+
+	// publishWindow is the number of jobs published ahead of the oldest job
+	// the broker has not confirmed yet
+	publishWindow  = 256
+	republishDelay = time.Second
+
+	// headPollInterval is how often a producer caught up with the head
+	// looks for new blocks
+	headPollInterval = time.Second
 )
 
+// pendingPush is a job published but not confirmed by the broker yet.
+type pendingPush struct {
+	job     model.BlockJob
+	confirm mq.Confirmation
+}
+
 var (
 	ErrMaxRetryExceeded = errors.New("max retry attempts exceeded")
 )
 
-func NewProducer(rcpEndpoint string) (*Producer, error) {
-	ethClient, err := ethclient.Dial(rcpEndpoint)
+func NewProducer(chain config.ChainOption) (*Producer, error) {
+	ethClient, err := ethclient.Dial(scanner.NewEndpointPool(chain.RCPEndpoints).Next())
 	if err != nil {
 		return nil, fmt.Errorf("NewProducer : %s", err.Error())
 	}
 
-	mysqlHandler, err := data.NewMysqlHandler(&config.GetConfig().Databases)
+	mysqlHandler, err := data.NewDatabaseHandler(&config.GetConfig().Databases, chain.ChainId)
 	if err != nil {
 		return nil, fmt.Errorf("NewProducer : %s", err.Error())
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Producer{
+		chain:        chain,
 		ethClient:    ethClient,
 		mysqlHandler: mysqlHandler,
+		ctx:          ctx,
+		cancel:       cancel,
 	}, nil
 }
 
 func (p *Producer) Start() {
-	p.createEthClient()
+	p.workers.Add(1)
+	defer p.workers.Done()
+	if p.isStopping() {
+		return
+	}
+
+	p.createQueue()
 	p.getLatestBlockNumber()
-	go p.continueUpdateBlockNumber()
-	go p.receiveACK()
-	p.startLoop()
+	p.workers.Add(1)
+	go func() {
+		defer p.workers.Done()
+		p.continueUpdateBlockNumber()
+	}()
+
+	if !config.GetConfig().LeaderElection.Enable {
+		p.lead(p.ctx)
+		return
+	}
+	p.campaign()
 }
 
-func (p *Producer) startLoop() {
+// lead publishes the jobs and moves the watermark until ctx is done.
+func (p *Producer) lead(ctx context.Context) {
+	var acks sync.WaitGroup
+	acks.Add(1)
+	go func() {
+		defer acks.Done()
+		p.receiveACK(ctx)
+	}()
+	p.startLoop(ctx)
+	acks.Wait()
+}
+
+func (p *Producer) startLoop(ctx context.Context) {
 	index, err := p.mysqlHandler.GetLatestBlockNumber(context.Background())
-	p.dbLatestBlockNumber = index
+	p.dbLatestBlockNumber.Store(index)
 	if err != nil {
 		logger.GetLogger().Sugar().Errorf("startLoop: failed to get latest block number from MySQL: %s", err.Error())
 		return
 	}
+	p.reportProgress()
 
-	for index <= int64(p.latestBlockNumber) {
-		blockNumber := strconv.FormatInt(index, 10)
-		err := p.pushMsg(blockNumber)
-		if err != nil {
-			logger.GetLogger().Sugar().Errorf("Start : %s", err.Error())
+	// the enqueued marker only moves over jobs confirmed by the broker
+	window := make([]pendingPush, 0, publishWindow)
+	for ctx.Err() == nil {
+		if index > int64(p.latestBlockNumber.Load()) {
+			// caught up with the head, the next blocks are head jobs
+			for len(window) != 0 {
+				if !p.confirmOldest(ctx, &window) {
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(headPollInterval):
+			}
+			continue
+		}
+		if len(window) == publishWindow {
+			if !p.confirmOldest(ctx, &window) {
+				return
+			}
+		}
+		job := p.nextJob(index)
+		confirm, ok := p.publishJob(ctx, job)
+		if !ok {
+			return
+		}
+		window = append(window, pendingPush{job: job, confirm: confirm})
+		index = job.To + 1
+	}
+	for len(window) != 0 {
+		if !p.confirmOldest(ctx, &window) {
+			return
 		}
-		index++
 	}
 }
 
-func (p *Producer) createEthClient() {
-	var err error
-	p.mqConn, err = amqp.Dial(config.GetConfig().MQEndpoint)
+// nextJob returns the job of the blocks from index, ranges are at most
+// JOB_RANGE_SIZE blocks and jobs within one range of the head are head jobs.
+func (p *Producer) nextJob(index int64) model.BlockJob {
+	rangeSize := config.GetConfig().GetJobRangeSize()
+	head := int64(p.latestBlockNumber.Load())
+	to := index + rangeSize - 1
+	if to > head {
+		to = head
+	}
+	reason := c.JobReasonBackfill
+	if head-to < rangeSize {
+		reason = c.JobReasonHead
+	}
+	return model.NewBlockJob(p.chain.ChainId, index, to, reason)
+}
+
+// confirmOldest waits for the broker to confirm the oldest job of window,
+// publishing it again when it was rejected, and advances the enqueued marker.
+// It returns false once ctx is done.
+func (p *Producer) confirmOldest(ctx context.Context, window *[]pendingPush) bool {
+	push := (*window)[0]
+	for {
+		err := push.confirm.Wait(ctx)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		logger.GetLogger().Sugar().Warnf("job %s not confirmed, publishing again: %s", push.job.JobId, err.Error())
+		monitor.QueueRepublished.WithLabelValues(p.chain.JobQueue(push.job.Reason)).Inc()
+		var ok bool
+		push.confirm, ok = p.publishJob(ctx, push.job)
+		if !ok {
+			return false
+		}
+	}
+	*window = (*window)[1:]
+	p.enqueuedBlockNumber.Store(push.job.To)
+	monitor.EnqueuedHead.WithLabelValues(p.chain.Name).Set(float64(push.job.To))
+	return true
+}
+
+// publishJob publishes job until the broker accepts the message for
+// confirmation, it returns false once ctx is done.
+func (p *Producer) publishJob(ctx context.Context, job model.BlockJob) (mq.Confirmation, bool) {
+	for {
+		confirm, err := p.pushMsg(ctx, job)
+		if err == nil {
+			return confirm, true
+		}
+		logger.GetLogger().Sugar().Errorf("publishJob : %s", err.Error())
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(republishDelay):
+		}
+	}
+}
+
+func (p *Producer) createQueue() {
+	queue, err := mq.New(config.GetConfig().Queue, config.GetConfig().MQEndpoint)
 	if err != nil {
 		panic(err)
 	}
+	p.queueMu.Lock()
+	p.queue = queue
+	p.queueMu.Unlock()
+}
+
+// getQueue returns the queue once Start created it, nil before.
+func (p *Producer) getQueue() mq.Queue {
+	p.queueMu.Lock()
+	defer p.queueMu.Unlock()
+	return p.queue
 }
 
 func (p *Producer) getLatestBlockNumber() {
 
 	var err error
 	for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
-		p.latestBlockNumber, err = p.ethClient.BlockNumber(context.Background())
-		if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
-			p.createEthClient()
-		}
+		var head uint64
+		head, err = p.ethClient.BlockNumber(context.Background())
 		if err == nil {
+			p.latestBlockNumber.Store(head)
 			break
 		}
 		<-time.NewTimer(time.Second * 1).C
@@ -102,18 +258,17 @@ func (p *Producer) getLatestBlockNumber() {
 	if err != nil {
 		panic(err)
 	}
-
+	p.reportProgress()
 }
 func (p *Producer) continueUpdateBlockNumber() {
 	t := time.NewTicker(time.Second * 5)
 	for {
 		var err error
 		for i := 0; i < config.GetConfig().MaxRetryTime; i++ {
-			p.latestBlockNumber, err = p.ethClient.BlockNumber(context.Background())
-			if err != nil && strings.Contains(err.Error(), "connection reset by peer") {
-				p.createEthClient()
-			}
+			var head uint64
+			head, err = p.ethClient.BlockNumber(context.Background())
 			if err == nil {
+				p.latestBlockNumber.Store(head)
 				break
 			}
 			<-time.NewTimer(time.Second * 1).C
@@ -121,90 +276,142 @@ func (p *Producer) continueUpdateBlockNumber() {
 		if err != nil {
 			panic(err)
 		}
-		<-t.C
+		p.reportProgress()
+		select {
+		case <-p.ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
 	}
 }
 
-func (p *Producer) pushMsg(blockNumber string) error {
-	ch, err := p.mqConn.Channel()
-	if err != nil {
-		return fmt.Errorf("Start : %s", err.Error())
-	}
-	defer ch.Close()
-
-	queue, err := ch.QueueDeclare(
-		BlockNumberQueueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+func (p *Producer) pushMsg(ctx context.Context, job model.BlockJob) (mq.Confirmation, error) {
+	queueName := p.chain.JobQueue(job.Reason)
+
+	// the trace of a job starts when it is enqueued
+	ctx, span := tracing.StartPublish(ctx, p.queue.System(), queueName)
+	span.SetAttributes(
+		attribute.String("job.id", job.JobId),
+		attribute.Int64("block.from", job.From),
+		attribute.Int64("block.to", job.To),
+		attribute.String("job.reason", job.Reason),
+		attribute.String("chain", p.chain.Name))
+	job.TraceContext = tracing.InjectHeaders(ctx)
+	body, err := job.Encode()
 	if err != nil {
-		return fmt.Errorf("pushMsg: failed to declare queue: %s", err.Error())
-	}
-
-	err = ch.Publish(
-		"",
-		queue.Name,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(blockNumber),
-		},
-	)
+		tracing.End(span, err)
+		return nil, fmt.Errorf("pushMsg : %w", err)
+	}
+	confirm, err := p.queue.Publish(ctx, queueName, mq.Message{
+		Headers:     job.TraceContext,
+		Body:        body,
+		ContentType: model.BlockJobContentType,
+	})
+	tracing.End(span, err)
 	if err != nil {
-		return fmt.Errorf("pushMsg: failed to publish message: %s", err.Error())
+		return nil, fmt.Errorf("pushMsg : %w", err)
 	}
-	return nil
+	monitor.QueuePublished.WithLabelValues(queueName).Inc()
+	return confirm, nil
 }
 
-func (p *Producer) receiveACK() {
+// receiveACK moves the watermark with the completions of the indexer until
+// ctx is done, completions not handled yet are redelivered to the next leader.
+func (p *Producer) receiveACK(ctx context.Context) {
+	queueName := p.chain.BlockNumberDoneQueue()
+	p.queue.Consume(ctx.Done(), queueName, ProducerServiceConsumerTag, 0, func(msg mq.Delivery) {
+		monitor.QueueConsumed.WithLabelValues(queueName).Inc()
+		p.handleACK(ctx, msg)
+	})
+}
 
-	var msgs <-chan amqp.Delivery
-	for {
-		ch, err := p.mqConn.Channel()
-		if err != nil {
-			log.Fatalf("Failed to open a channel: %v", err)
-		}
-		msgs, err = ch.Consume(
-			"blockNumber_done_queue",
-			"producer_service",
-			false,
-			false,
-			false,
-			false,
-			nil,
-		)
-		if err != nil && strings.Contains(err.Error(), "no queue") {
-			continue
-		}
-		if err == nil {
-			break
-		}
+// handleACK moves the watermark to the last block of the completed range of
+// msg, in the trace of the job. A producer which stopped leading leaves the
+// watermark to the next leader.
+func (p *Producer) handleACK(leader context.Context, msg mq.Delivery) {
+	if leader.Err() != nil {
+		msg.Nack(true)
+		return
 	}
+	ctx, span := tracing.StartConsume(context.Background(), p.queue.System(), p.chain.BlockNumberDoneQueue(), msg.Headers())
+	var err error
+	defer func() { tracing.End(span, err) }()
 
-	for msg := range msgs {
-		logger.GetLogger().Sugar().Infof("receive : %s", msg.Body)
-		num, err := strconv.ParseInt(string(msg.Body), 10, 64)
-		if err != nil {
-			logger.GetLogger().Sugar().Errorf("receiveACK : %s", err.Error())
-			continue
-		}
+	logger.GetLogger().Sugar().Infof("receive : %s", msg.Body())
+	job, err := model.DecodeBlockJob(msg.Body(), p.chain.ChainId)
+	if err != nil {
+		logger.GetLogger().Sugar().Errorf("receiveACK : %s", err.Error())
+		msg.Nack(false)
+		return
+	}
+	num := job.To
+	span.SetAttributes(attribute.String("job.id", job.JobId), attribute.Int64("block.number", num), attribute.String("chain", p.chain.Name))
 
-		if num < p.dbLatestBlockNumber {
-			msg.Ack(true)
-			continue
-		}
+	// ranges indexed again from the admin api are behind or apart from the
+	// progress of the producer
+	switch job.Reason {
+	case c.JobReasonReindex, c.JobReasonGap, c.JobReasonReorg:
+		msg.Ack()
+		return
+	}
+	if num < p.dbLatestBlockNumber.Load() {
+		msg.Ack()
+		return
+	}
 
-		err = p.mysqlHandler.UpdateLatestBlockNumber(context.Background(), num)
-		if err != nil {
-			logger.GetLogger().Sugar().Errorf("receiveACK : %s", err.Error())
+	err = p.mysqlHandler.UpdateLatestBlockNumber(ctx, num)
+	if err != nil {
+		logger.GetLogger().Sugar().Errorf("receiveACK : %s", err.Error())
+	}
+
+	p.dbLatestBlockNumber.Store(num)
+	p.reportProgress()
+	msg.Ack()
+	logger.GetLogger().Sugar().Infof("receiveACK : %d", num)
+}
+
+// RegisterHealth adds the dependencies and the progress of the producer to health.
+func (p *Producer) RegisterHealth(health *monitor.Health) {
+	health.AddCheck(p.chain.Name+"/mysql", p.mysqlHandler.Ping)
+	health.AddCheck(p.chain.Name+"/queue", monitor.QueueCheck(p.getQueue))
+	health.AddCheck(p.chain.Name+"/rpc", monitor.RPCCheck(p.ethClient))
+	health.AddStatus(func(ctx context.Context) monitor.ChainStatus {
+		status := monitor.ChainStatus{
+			Chain:     p.chain.Name,
+			Head:      int64(p.latestBlockNumber.Load()),
+			Watermark: p.dbLatestBlockNumber.Load(),
 		}
+		// blocks enqueued but not acknowledged yet
+		if enqueued := p.enqueuedBlockNumber.Load(); enqueued > status.Watermark {
+			status.InFlight = enqueued - status.Watermark
+		}
+		return status
+	})
+}
 
-		p.dbLatestBlockNumber = num
-		msg.Ack(true)
-		logger.GetLogger().Sugar().Infof("receiveACK : %d", num)
-	}
+// reportProgress exports the chain head, the watermark and the lag between them.
+func (p *Producer) reportProgress() {
+	head := float64(p.latestBlockNumber.Load())
+	watermark := float64(p.dbLatestBlockNumber.Load())
+	monitor.ChainHead.WithLabelValues(p.chain.Name).Set(head)
+	monitor.Watermark.WithLabelValues(p.chain.Name).Set(watermark)
+	monitor.IndexerLag.WithLabelValues(p.chain.Name).Set(head - watermark)
+}
+
+func (p *Producer) isStopping() bool {
+	return p.ctx.Err() != nil
+}
+
+// Shutdown stops enqueuing blocks and consuming completions, waits for the
+// completion being handled to be stored and closes the connections.
+func (p *Producer) Shutdown() {
+	p.shutdownOnce.Do(func() {
+		p.cancel()
+		p.workers.Wait()
+		if queue := p.getQueue(); queue != nil {
+			queue.Close()
+		}
+		p.ethClient.Close()
+	})
 }
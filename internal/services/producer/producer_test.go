@@ -0,0 +1,193 @@
+package producer
+
+import (
+	"Ethereum_Service/c"
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/data"
+	"Ethereum_Service/internal/monitor"
+	"Ethereum_Service/internal/mq"
+	"Ethereum_Service/pkg/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthStatus(t *testing.T) {
+	p := &Producer{chain: config.ChainOption{Name: "mainnet"}, mysqlHandler: struct{ data.DataHandler }{}}
+	health := monitor.NewHealth("producer")
+	p.RegisterHealth(health)
+
+	status := func() monitor.ChainStatus {
+		w := httptest.NewRecorder()
+		health.ServeStatus(w, httptest.NewRequest("GET", "/status", nil))
+		var resp monitor.Status
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Len(t, resp.Chains, 1)
+		return resp.Chains[0]
+	}
+
+	// the status is served while the producer moves its progress
+	var progress sync.WaitGroup
+	progress.Add(1)
+	go func() {
+		defer progress.Done()
+		for i := int64(1); i <= 100; i++ {
+			p.latestBlockNumber.Store(uint64(i + 10))
+			p.enqueuedBlockNumber.Store(i + 5)
+			p.dbLatestBlockNumber.Store(i)
+			p.reportProgress()
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		status()
+	}
+	progress.Wait()
+
+	assert.Equal(t, monitor.ChainStatus{Chain: "mainnet", Head: 110, Watermark: 100, InFlight: 5}, status())
+}
+
+// watermarkStore records the watermarks written by the producer.
+type watermarkStore struct {
+	data.DataHandler
+	watermarks []int64
+}
+
+func (s *watermarkStore) UpdateLatestBlockNumber(ctx context.Context, number int64) error {
+	s.watermarks = append(s.watermarks, number)
+	return nil
+}
+
+type ackDelivery struct {
+	body  []byte
+	acked bool
+}
+
+func (d *ackDelivery) Body() []byte               { return d.body }
+func (d *ackDelivery) Headers() map[string]string { return nil }
+func (d *ackDelivery) Ack() error                 { d.acked = true; return nil }
+func (d *ackDelivery) Nack(requeue bool) error    { return nil }
+
+func TestHandleACK(t *testing.T) {
+	store := &watermarkStore{}
+	p := newLeaseProducer(&leaseStore{})
+	defer p.cancel()
+	p.mysqlHandler = store
+	p.dbLatestBlockNumber.Store(100)
+
+	ack := func(job model.BlockJob) {
+		body, err := job.Encode()
+		assert.NoError(t, err)
+		msg := &ackDelivery{body: body}
+		p.handleACK(p.ctx, msg)
+		assert.True(t, msg.acked)
+	}
+
+	// the blocks indexed again do not move the watermark, even ahead of it
+	ack(model.NewBlockJob(0, 500, 599, c.JobReasonReindex))
+	ack(model.NewBlockJob(0, 500, 599, c.JobReasonReorg))
+	ack(model.NewBlockJob(0, 10, 19, c.JobReasonGap))
+	ack(model.NewBlockJob(0, 101, 200, c.JobReasonBackfill))
+	assert.Equal(t, []int64{200}, store.watermarks)
+	assert.Equal(t, int64(200), p.dbLatestBlockNumber.Load())
+}
+
+type leaseResult struct {
+	ok  bool
+	err error
+}
+
+// leaseStore answers AcquireLease with results in order, the last one repeats.
+// The producer leading without a watermark stops its loop at once.
+type leaseStore struct {
+	data.DataHandler
+
+	mu       sync.Mutex
+	results  []leaseResult
+	acquired int
+	released []string
+}
+
+func (s *leaseStore) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := s.results[len(s.results)-1]
+	if s.acquired < len(s.results) {
+		result = s.results[s.acquired]
+	}
+	s.acquired++
+	return result.ok, result.err
+}
+
+func (s *leaseStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released = append(s.released, holder)
+	return nil
+}
+
+func (s *leaseStore) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	return 0, errors.New("database unavailable")
+}
+
+func newLeaseProducer(store *leaseStore) *Producer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Producer{
+		chain:        config.ChainOption{Name: "mainnet"},
+		mysqlHandler: store,
+		queue:        mq.NewMemory(),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+func TestLeadWhileHeldStepsDown(t *testing.T) {
+	ttl := 300 * time.Millisecond
+	for name, results := range map[string][]leaseResult{
+		"renewal fails": {{err: errors.New("database unavailable")}},
+		"lease lost":    {{ok: true}, {ok: false}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := newLeaseProducer(&leaseStore{results: results})
+			defer p.cancel()
+
+			// the leader stops before its lease expires and a standby takes over
+			start := time.Now()
+			p.leadWhileHeld("a", ttl, start.Add(ttl))
+			assert.Less(t, time.Since(start), ttl)
+		})
+	}
+}
+
+func TestCampaign(t *testing.T) {
+	leaderElection := config.GetConfig().LeaderElection
+	defer func() { config.GetConfig().LeaderElection = leaderElection }()
+	config.GetConfig().LeaderElection.LeaseTTL = 300 * time.Millisecond
+
+	store := &leaseStore{results: []leaseResult{{ok: true}, {ok: false}}}
+	p := newLeaseProducer(store)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.campaign()
+	}()
+
+	// the producer steps down once the lease is lost and keeps campaigning
+	assert.Eventually(t, func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return store.acquired >= 4
+	}, 2*time.Second, 10*time.Millisecond)
+	p.cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("campaign did not stop")
+	}
+	assert.Len(t, store.released, 1)
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InjectHeaders returns the message headers carrying the trace context of ctx.
+func InjectHeaders(ctx context.Context) map[string]string {
+	headers := map[string]string{}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+// ExtractHeaders returns ctx with the trace context carried by the message headers.
+func ExtractHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
+
+// StartPublish starts the span of a message published to queue of the
+// messaging system.
+func StartPublish(ctx context.Context, system, queue string) (context.Context, trace.Span) {
+	return Start(ctx, queue+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem(system),
+			semconv.MessagingDestinationName(queue),
+		))
+}
+
+// StartConsume starts the span processing a message of queue, as a child of
+// the span that published it.
+func StartConsume(ctx context.Context, system, queue string, headers map[string]string) (context.Context, trace.Span) {
+	return Start(ExtractHeaders(ctx, headers), queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystem(system),
+			semconv.MessagingDestinationName(queue),
+		))
+}
@@ -0,0 +1,31 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestHeaderPropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	headers := InjectHeaders(trace.ContextWithSpanContext(context.Background(), parent))
+	assert.Contains(t, headers, "traceparent")
+
+	got := trace.SpanContextFromContext(ExtractHeaders(context.Background(), headers))
+	assert.Equal(t, parent.TraceID(), got.TraceID())
+	assert.Equal(t, parent.SpanID(), got.SpanID())
+	assert.True(t, got.IsRemote())
+
+	// messages published before tracing was enabled carry no headers
+	assert.False(t, trace.SpanContextFromContext(ExtractHeaders(context.Background(), nil)).IsValid())
+}
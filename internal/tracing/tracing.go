@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"Ethereum_Service/config"
+	"Ethereum_Service/internal/monitor"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "Ethereum_Service"
+
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// Init installs the tracer provider of the service and the W3C trace context
+// propagator. Without an exporter spans are not recorded, but trace context
+// received from other services is still passed on. The returned function
+// flushes the spans left and has to be called before the service exits.
+func Init(serviceName string, opt config.TracingOption) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if opt.Exporter == "" {
+		return func() {}, nil
+	}
+
+	exporter, closer, err := newExporter(opt)
+	if err != nil {
+		return nil, fmt.Errorf("Init : %w", err)
+	}
+
+	ratio := opt.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+	}, nil
+}
+
+func newExporter(opt config.TracingOption) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opt.Exporter {
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(opt.Endpoint)}
+		if opt.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return exporter, nil, err
+	case ExporterFile:
+		f, err := os.OpenFile(opt.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, f, nil
+	}
+	return nil, nil, fmt.Errorf("unknown exporter %q", opt.Exporter)
+}
+
+// Start starts a span with the tracer of the service.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// StartRPC starts the span of a call to an RPC endpoint, only the host of the
+// endpoint is recorded.
+func StartRPC(ctx context.Context, method, endpoint string) (context.Context, trace.Span) {
+	return Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCMethod(method),
+			semconv.ServerAddress(monitor.EndpointLabel(endpoint)),
+		))
+}
+
+// End records err on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Detach returns a context that continues the trace of ctx but is not canceled
+// with it, for work that outlives a request.
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
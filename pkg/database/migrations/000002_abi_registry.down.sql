@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `abi`;
+
+ALTER TABLE `log`
+  DROP COLUMN `address`,
+  DROP COLUMN `topics`;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `abi` (
+  `address` varchar(42) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `abi` LONGTEXT NOT NULL,
+  PRIMARY KEY (`address`)
+);
+
+ALTER TABLE `log`
+  ADD COLUMN `address` varchar(42) NOT NULL DEFAULT '',
+  ADD COLUMN `topics` varchar(300) NOT NULL DEFAULT '';
@@ -0,0 +1,7 @@
+ALTER TABLE `block`
+  DROP INDEX `idx_block_hash`,
+  DROP COLUMN `miner`,
+  DROP COLUMN `base_fee`,
+  DROP COLUMN `withdrawals_root`,
+  DROP COLUMN `blob_gas_used`,
+  DROP COLUMN `excess_blob_gas`;
@@ -0,0 +1,7 @@
+ALTER TABLE `block`
+  ADD COLUMN `miner` varchar(42) NOT NULL DEFAULT '',
+  ADD COLUMN `base_fee` varchar(78) NOT NULL DEFAULT '',
+  ADD COLUMN `withdrawals_root` varchar(66) NOT NULL DEFAULT '',
+  ADD COLUMN `blob_gas_used` bigint(20) unsigned NULL,
+  ADD COLUMN `excess_blob_gas` bigint(20) unsigned NULL,
+  ADD INDEX `idx_block_hash` (`hash`);
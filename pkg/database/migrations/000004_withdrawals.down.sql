@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `withdrawal`;
+
+ALTER TABLE `block`
+  DROP COLUMN `parent_beacon_root`;
@@ -0,0 +1,13 @@
+ALTER TABLE `block`
+  ADD COLUMN `parent_beacon_root` varchar(66) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS `withdrawal` (
+  `index` bigint(20) unsigned NOT NULL,
+  `block_number` bigint NOT NULL,
+  `validator_index` bigint(20) unsigned NOT NULL,
+  `address` varchar(42) NOT NULL,
+  `amount` bigint(20) unsigned NOT NULL,
+  PRIMARY KEY (`index`),
+  INDEX `idx_withdrawal_block_number` (`block_number`),
+  INDEX `idx_withdrawal_address` (`address`)
+);
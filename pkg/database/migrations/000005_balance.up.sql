@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `balance` (
+  `address` varchar(42) NOT NULL,
+  `block_number` bigint NOT NULL,
+  `delta` varchar(80) NOT NULL DEFAULT '',
+  `balance` varchar(78) NOT NULL DEFAULT '',
+  `nonce` bigint(20) unsigned NULL,
+  PRIMARY KEY (`address`, `block_number`)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `pending_tx` (
+  `hash` varchar(66) NOT NULL,
+  `from` varchar(42) NOT NULL,
+  `to` varchar(42) NOT NULL,
+  `value` varchar(78) NOT NULL,
+  `nonce` bigint(20) unsigned NOT NULL,
+  `data` LONGBLOB NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `first_seen` datetime(3) NOT NULL,
+  `updated_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`hash`),
+  INDEX `idx_pending_tx_status_first_seen` (`status`, `first_seen`)
+);
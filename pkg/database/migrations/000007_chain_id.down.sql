@@ -0,0 +1,57 @@
+-- rows of other chains than chain id 0 do not fit the single chain keys
+DELETE FROM `pending_tx` WHERE `chain_id` != 0;
+DELETE FROM `balance` WHERE `chain_id` != 0;
+DELETE FROM `withdrawal` WHERE `chain_id` != 0;
+DELETE FROM `abi` WHERE `chain_id` != 0;
+DELETE FROM `latest_block_number` WHERE `chain_id` != 0;
+DELETE FROM `log` WHERE `chain_id` != 0;
+DELETE FROM `tx` WHERE `chain_id` != 0;
+DELETE FROM `block` WHERE `chain_id` != 0;
+
+ALTER TABLE `pending_tx`
+  DROP INDEX `idx_pending_tx_status_first_seen`,
+  ADD INDEX `idx_pending_tx_status_first_seen` (`status`, `first_seen`),
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`hash`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `balance`
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`address`, `block_number`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `withdrawal`
+  DROP INDEX `idx_withdrawal_address`,
+  ADD INDEX `idx_withdrawal_address` (`address`),
+  DROP INDEX `idx_withdrawal_block_number`,
+  ADD INDEX `idx_withdrawal_block_number` (`block_number`),
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`index`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `abi`
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`address`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `latest_block_number`
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`id`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `log`
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`tx_hash`, `index`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `tx`
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`hash`),
+  DROP COLUMN `chain_id`;
+
+ALTER TABLE `block`
+  DROP INDEX `idx_block_hash`,
+  ADD INDEX `idx_block_hash` (`hash`),
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`number`),
+  DROP COLUMN `chain_id`;
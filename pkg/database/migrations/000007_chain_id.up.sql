@@ -0,0 +1,47 @@
+ALTER TABLE `block`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `number`),
+  DROP INDEX `idx_block_hash`,
+  ADD INDEX `idx_block_hash` (`chain_id`, `hash`);
+
+ALTER TABLE `tx`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `hash`);
+
+ALTER TABLE `log`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `tx_hash`, `index`);
+
+ALTER TABLE `latest_block_number`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`);
+
+ALTER TABLE `abi`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `address`);
+
+ALTER TABLE `withdrawal`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `index`),
+  DROP INDEX `idx_withdrawal_block_number`,
+  ADD INDEX `idx_withdrawal_block_number` (`chain_id`, `block_number`),
+  DROP INDEX `idx_withdrawal_address`,
+  ADD INDEX `idx_withdrawal_address` (`chain_id`, `address`);
+
+ALTER TABLE `balance`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `address`, `block_number`);
+
+ALTER TABLE `pending_tx`
+  ADD COLUMN `chain_id` bigint NOT NULL DEFAULT 0 FIRST,
+  DROP PRIMARY KEY,
+  ADD PRIMARY KEY (`chain_id`, `hash`),
+  DROP INDEX `idx_pending_tx_status_first_seen`,
+  ADD INDEX `idx_pending_tx_status_first_seen` (`chain_id`, `status`, `first_seen`);
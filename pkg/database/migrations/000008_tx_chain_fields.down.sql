@@ -0,0 +1,5 @@
+ALTER TABLE `tx`
+  DROP COLUMN `type`,
+  DROP COLUMN `l1_fee`,
+  DROP COLUMN `l1_gas_price`,
+  DROP COLUMN `l1_gas_used`;
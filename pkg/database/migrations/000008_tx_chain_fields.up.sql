@@ -0,0 +1,5 @@
+ALTER TABLE `tx`
+  ADD COLUMN `type` tinyint unsigned NOT NULL DEFAULT 0,
+  ADD COLUMN `l1_fee` varchar(78) NOT NULL DEFAULT '',
+  ADD COLUMN `l1_gas_price` varchar(78) NOT NULL DEFAULT '',
+  ADD COLUMN `l1_gas_used` varchar(78) NOT NULL DEFAULT '';
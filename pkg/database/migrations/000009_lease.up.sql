@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS `lease` (
+  `chain_id` bigint NOT NULL DEFAULT 0,
+  `name` varchar(64) NOT NULL,
+  `holder` varchar(255) NOT NULL DEFAULT '',
+  `expires_at` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`chain_id`, `name`)
+);
@@ -0,0 +1,110 @@
+CREATE TABLE IF NOT EXISTS block (
+  chain_id bigint NOT NULL DEFAULT 0,
+  hash varchar(255) NOT NULL,
+  number bigint NOT NULL,
+  gas_limit numeric(20) NOT NULL,
+  gas_used numeric(20) NOT NULL,
+  difficulty bigint NOT NULL,
+  time numeric(20) NOT NULL,
+  nonce numeric(20) NOT NULL,
+  root varchar(255) NOT NULL,
+  parent_hash varchar(255) NOT NULL,
+  tx_hash varchar(255) NOT NULL,
+  uncle_hash varchar(255) NOT NULL,
+  extra bytea NOT NULL,
+  miner varchar(42) NOT NULL DEFAULT '',
+  base_fee varchar(78) NOT NULL DEFAULT '',
+  withdrawals_root varchar(66) NOT NULL DEFAULT '',
+  blob_gas_used numeric(20) NULL,
+  excess_blob_gas numeric(20) NULL,
+  parent_beacon_root varchar(66) NOT NULL DEFAULT '',
+  PRIMARY KEY (chain_id, number)
+);
+CREATE INDEX IF NOT EXISTS idx_block_hash ON block (chain_id, hash);
+
+CREATE TABLE IF NOT EXISTS tx (
+  chain_id bigint NOT NULL DEFAULT 0,
+  hash varchar(66) NOT NULL,
+  block_number bigint NOT NULL,
+  nonce numeric(20) NOT NULL,
+  "to" varchar(42) NOT NULL,
+  "from" varchar(42) NOT NULL,
+  value bigint NOT NULL,
+  data bytea NOT NULL,
+  type smallint NOT NULL DEFAULT 0,
+  l1_fee varchar(78) NOT NULL DEFAULT '',
+  l1_gas_price varchar(78) NOT NULL DEFAULT '',
+  l1_gas_used varchar(78) NOT NULL DEFAULT '',
+  PRIMARY KEY (chain_id, hash)
+);
+CREATE INDEX IF NOT EXISTS idx_tx_block_number ON tx (chain_id, block_number);
+
+CREATE TABLE IF NOT EXISTS log (
+  chain_id bigint NOT NULL DEFAULT 0,
+  tx_hash varchar(255) NOT NULL,
+  index bigint NOT NULL,
+  data bytea NOT NULL,
+  address varchar(42) NOT NULL DEFAULT '',
+  topics varchar(300) NOT NULL DEFAULT '',
+  PRIMARY KEY (chain_id, tx_hash, index)
+);
+
+CREATE TABLE IF NOT EXISTS latest_block_number (
+  chain_id bigint NOT NULL DEFAULT 0,
+  id bigint NOT NULL DEFAULT 0,
+  block_number bigint NOT NULL,
+  PRIMARY KEY (chain_id)
+);
+
+CREATE TABLE IF NOT EXISTS abi (
+  chain_id bigint NOT NULL DEFAULT 0,
+  address varchar(42) NOT NULL,
+  name varchar(255) NOT NULL,
+  abi text NOT NULL,
+  PRIMARY KEY (chain_id, address)
+);
+
+CREATE TABLE IF NOT EXISTS withdrawal (
+  chain_id bigint NOT NULL DEFAULT 0,
+  index numeric(20) NOT NULL,
+  block_number bigint NOT NULL,
+  validator_index numeric(20) NOT NULL,
+  address varchar(42) NOT NULL,
+  amount numeric(20) NOT NULL,
+  PRIMARY KEY (chain_id, index)
+);
+CREATE INDEX IF NOT EXISTS idx_withdrawal_block_number ON withdrawal (chain_id, block_number);
+CREATE INDEX IF NOT EXISTS idx_withdrawal_address ON withdrawal (chain_id, address);
+
+CREATE TABLE IF NOT EXISTS balance (
+  chain_id bigint NOT NULL DEFAULT 0,
+  address varchar(42) NOT NULL,
+  block_number bigint NOT NULL,
+  delta varchar(80) NOT NULL DEFAULT '',
+  balance varchar(78) NOT NULL DEFAULT '',
+  nonce numeric(20) NULL,
+  PRIMARY KEY (chain_id, address, block_number)
+);
+
+CREATE TABLE IF NOT EXISTS pending_tx (
+  chain_id bigint NOT NULL DEFAULT 0,
+  hash varchar(66) NOT NULL,
+  "from" varchar(42) NOT NULL,
+  "to" varchar(42) NOT NULL,
+  value varchar(78) NOT NULL,
+  nonce numeric(20) NOT NULL,
+  data bytea NOT NULL,
+  status varchar(16) NOT NULL,
+  first_seen timestamptz NOT NULL,
+  updated_at timestamptz NOT NULL,
+  PRIMARY KEY (chain_id, hash)
+);
+CREATE INDEX IF NOT EXISTS idx_pending_tx_status_first_seen ON pending_tx (chain_id, status, first_seen);
+
+CREATE TABLE IF NOT EXISTS lease (
+  chain_id bigint NOT NULL DEFAULT 0,
+  name varchar(64) NOT NULL,
+  holder varchar(255) NOT NULL DEFAULT '',
+  expires_at bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (chain_id, name)
+);
@@ -1,26 +1,120 @@
 package model
 
+import "encoding/json"
+
 type TxResponse struct {
-	TxHash string        `json:"tx_hash"`
-	From   string        `json:"from"`
-	To     string        `json:"to"`
-	Data   string        `json:"data"`
-	Value  string        `json:"value"`
-	Nonce  uint64        `json:"nonce"`
-	Logs   []LogResponse `json:"logs"`
+	TxHash  string        `json:"tx_hash"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Data    string        `json:"data"`
+	Value   string        `json:"value"`
+	Nonce   uint64        `json:"nonce"`
+	Type    uint8         `json:"type"`
+	Status  string        `json:"status"`
+	Decoded *DecodedCall  `json:"decoded,omitempty"`
+	Logs    []LogResponse `json:"logs"`
+
+	L1Fee      string `json:"l1_fee,omitempty"`
+	L1GasPrice string `json:"l1_gas_price,omitempty"`
+	L1GasUsed  string `json:"l1_gas_used,omitempty"`
 }
 
 type LogResponse struct {
-	Index uint   `json:"index"`
-	Data  string `json:"data"`
+	Index   uint          `json:"index"`
+	Address string        `json:"address"`
+	Topics  []string      `json:"topics"`
+	Data    string        `json:"data"`
+	Decoded *DecodedEvent `json:"decoded,omitempty"`
+}
+
+type DecodedCall struct {
+	Method    string                 `json:"method"`
+	Signature string                 `json:"signature"`
+	Args      map[string]interface{} `json:"args"`
+}
+
+type DecodedEvent struct {
+	Event     string                 `json:"event"`
+	Signature string                 `json:"signature"`
+	Args      map[string]interface{} `json:"args"`
+}
+
+type AbiRequest struct {
+	Name string          `json:"name"`
+	Abi  json.RawMessage `json:"abi" binding:"required"`
+}
+
+// ReindexRequest asks for blocks From to To to be indexed again, Reason is
+// reindex when empty and Mode the write mode of the reason when empty.
+type ReindexRequest struct {
+	From   *int64 `json:"from" binding:"required"`
+	To     *int64 `json:"to" binding:"required"`
+	Reason string `json:"reason"`
+	Mode   string `json:"mode"`
+}
+
+type ReindexResponse struct {
+	Reason string   `json:"reason"`
+	Mode   string   `json:"mode"`
+	JobIds []string `json:"job_ids"`
+}
+
+type AbiResponse struct {
+	Address string          `json:"address"`
+	Name    string          `json:"name"`
+	Abi     json.RawMessage `json:"abi"`
 }
 
 type BlockResponseWithTx struct {
-	BlockNum     int64    `json:"block_num"`
-	BlockHash    string   `json:"block_hash"`
-	BlockTime    uint64   `json:"block_time"`
-	ParentHash   string   `json:"parent_hash"`
-	Transactions []string `json:"transactions"`
+	BlockNum           int64        `json:"block_num"`
+	BlockHash          string       `json:"block_hash"`
+	BlockTime          uint64       `json:"block_time"`
+	ParentHash         string       `json:"parent_hash"`
+	Miner              string       `json:"miner"`
+	GasLimit           uint64       `json:"gas_limit"`
+	GasUsed            uint64       `json:"gas_used"`
+	Difficulty         int64        `json:"difficulty"`
+	Nonce              uint64       `json:"nonce"`
+	StateRoot          string       `json:"state_root"`
+	TransactionsRoot   string       `json:"transactions_root"`
+	UncleHash          string       `json:"uncle_hash"`
+	Extra              string       `json:"extra"`
+	BaseFee            string       `json:"base_fee,omitempty"`
+	WithdrawalsRoot    string       `json:"withdrawals_root,omitempty"`
+	BlobGasUsed        *uint64      `json:"blob_gas_used,omitempty"`
+	ExcessBlobGas      *uint64      `json:"excess_blob_gas,omitempty"`
+	ParentBeaconRoot   string       `json:"parent_beacon_root,omitempty"`
+	Transactions       []string     `json:"transactions"`
+	TransactionObjects []TxResponse `json:"transaction_objects,omitempty"`
+}
+
+type WithdrawalResponse struct {
+	Index          uint64 `json:"index"`
+	BlockNum       int64  `json:"block_num"`
+	ValidatorIndex uint64 `json:"validator_index"`
+	Address        string `json:"address"`
+	AmountGwei     uint64 `json:"amount_gwei"`
+}
+
+type BalanceResponse struct {
+	Address  string  `json:"address"`
+	BlockNum int64   `json:"block_num"`
+	Balance  string  `json:"balance"`
+	Nonce    *uint64 `json:"nonce,omitempty"`
+	Source   string  `json:"source"`
+}
+
+type BalanceChangeResponse struct {
+	BlockNum int64   `json:"block_num"`
+	Delta    string  `json:"delta,omitempty"`
+	Balance  string  `json:"balance,omitempty"`
+	Nonce    *uint64 `json:"nonce,omitempty"`
+}
+
+type BlockListResponse struct {
+	Data []BlockResponse `json:"data"`
+	Next string          `json:"next,omitempty"`
+	Prev string          `json:"prev,omitempty"`
 }
 
 type BlockResponse struct {
@@ -2,8 +2,6 @@ package model
 
 import (
 	"math/big"
-
-	"github.com/streadway/amqp"
 )
 
 type TransactionWithLogs struct {
@@ -22,13 +20,20 @@ type Log struct {
 	Data  string `json:"data"`
 }
 
+// Delivery is the queue message a job was received with, it is acknowledged
+// once the job is done.
+type Delivery interface {
+	Ack() error
+	Nack(requeue bool) error
+}
+
 type Job struct {
 	BlockNumber *big.Int
 	DoneChan    chan JobResult
-	Msg         *amqp.Delivery
+	Msg         Delivery
 }
 
 type JobResult struct {
 	BlockNumber *big.Int
-	Msg         *amqp.Delivery
+	Msg         Delivery
 }
@@ -1,21 +1,35 @@
 package model
 
+import (
+	"math/big"
+	"time"
+)
+
 type BlockRow struct {
-	Hash       string
-	Number     int64
-	GasLimit   uint64
-	GasUsed    uint64
-	Difficulty int64
-	Time       uint64
-	Nonce      uint64
-	Root       string
-	ParentHash string
-	TxHash     string
-	UncleHash  string
-	Extra      []byte
+	ChainId         int64
+	Hash            string
+	Number          int64
+	GasLimit        uint64
+	GasUsed         uint64
+	Difficulty      int64
+	Time            uint64
+	Nonce           uint64
+	Root            string
+	ParentHash      string
+	TxHash          string
+	UncleHash       string
+	Extra           []byte
+	Miner           string
+	BaseFee         string
+	WithdrawalsRoot string
+	BlobGasUsed     *uint64
+	ExcessBlobGas   *uint64
+
+	ParentBeaconRoot string
 }
 
 type TransactionRow struct {
+	ChainId     int64
 	Hash        string
 	BlockNumber int64
 	Nonce       uint64
@@ -23,15 +37,94 @@ type TransactionRow struct {
 	From        string
 	Value       int64
 	Data        []byte
+	Type        uint8
+	// ValueWei is the exact value, Value only holds values below 2^63 wei.
+	// It is written to ClickHouse and not stored in the database.
+	ValueWei *big.Int `gorm:"-" json:"-"`
+
+	// L1 data fee of OP-stack chains
+	L1Fee      string
+	L1GasPrice string
+	L1GasUsed  string
 }
 
 type LogRow struct {
-	TxHash string
-	Index  uint
-	Data   []byte
+	ChainId int64
+	TxHash  string
+	Index   uint
+	Address string
+	Topics  string
+	Data    []byte
+}
+
+// WithdrawalRow is a beacon chain withdrawal, amount is in gwei.
+type WithdrawalRow struct {
+	ChainId        int64
+	Index          uint64
+	BlockNumber    int64
+	ValidatorIndex uint64
+	Address        string
+	Amount         uint64
+}
+
+// BlockData is a block with the rows indexed from it, Balances is empty when
+// balances are not tracked.
+type BlockData struct {
+	Block       BlockRow
+	Txs         []TransactionRow
+	Logs        []LogRow
+	Withdrawals []WithdrawalRow
+	Balances    []BalanceRow
+}
+
+// BlockWrite is a block to write in Mode, one of the c.WriteMode values.
+type BlockWrite struct {
+	Mode  string
+	Block *BlockData
+}
+
+// BalanceRow is the native balance of an address at a block, Delta is filled
+// in derived mode, Balance and Nonce are filled in rpc mode.
+type BalanceRow struct {
+	ChainId     int64
+	Address     string
+	BlockNumber int64
+	Delta       string
+	Balance     string
+	Nonce       *uint64
+}
+
+type PendingTxRow struct {
+	ChainId   int64
+	Hash      string
+	From      string
+	To        string
+	Value     string
+	Nonce     uint64
+	Data      []byte
+	Status    string
+	FirstSeen time.Time
+	UpdatedAt time.Time
+}
+
+type AbiRow struct {
+	ChainId int64
+	Address string
+	Name    string
+	Abi     string
 }
 
 type LatestBlockNumber struct {
+	ChainId     int64
 	Id          int64
 	BlockNumber int64
 }
+
+// LeaseRow is a lease held by one replica of a service until ExpiresAt, in
+// unix milliseconds.
+type LeaseRow struct {
+	ChainId   int64
+	Name      string
+	Holder    string
+	ExpiresAt int64
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"Ethereum_Service/c"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// BlockJobVersion is the version of the block job schema written by this
+// build, jobs of a higher version are rejected.
+const BlockJobVersion = 1
+
+// BlockJobContentType is the content type of encoded block jobs.
+const BlockJobContentType = "application/json"
+
+var ErrUnsupportedJobVersion = errors.New("unsupported block job version")
+
+// BlockJob is a range of blocks to index, From and To included. The queue of
+// completed blocks carries the same schema with the range that was indexed.
+type BlockJob struct {
+	Version  int    `json:"version"`
+	JobId    string `json:"job_id"`
+	ChainId  int64  `json:"chain_id"`
+	From     int64  `json:"from"`
+	To       int64  `json:"to"`
+	Attempt  int    `json:"attempt"`
+	Priority uint8  `json:"priority"`
+	Reason   string `json:"reason"`
+	// Mode is the write mode of the rows, the default of Reason when empty
+	Mode string `json:"mode,omitempty"`
+	// TraceContext is the trace context of the span that published the job
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// NewBlockJob returns the first attempt of indexing blocks from to to.
+func NewBlockJob(chainId, from, to int64, reason string) BlockJob {
+	priority := uint8(c.JobPriorityBackfill)
+	if reason == c.JobReasonHead {
+		priority = c.JobPriorityHead
+	}
+	return BlockJob{
+		Version:  BlockJobVersion,
+		JobId:    fmt.Sprintf("%d-%d-%d", chainId, from, to),
+		ChainId:  chainId,
+		From:     from,
+		To:       to,
+		Priority: priority,
+		Reason:   reason,
+	}
+}
+
+// SplitBlockJobs returns the jobs indexing blocks from to to, size blocks at
+// most each.
+func SplitBlockJobs(chainId, from, to, size int64, reason string) []BlockJob {
+	jobs := make([]BlockJob, 0, (to-from)/size+1)
+	for start := from; start <= to; start += size {
+		end := start + size - 1
+		if end > to {
+			end = to
+		}
+		jobs = append(jobs, NewBlockJob(chainId, start, end, reason))
+	}
+	return jobs
+}
+
+// Size returns the number of blocks of the job.
+func (j BlockJob) Size() int64 {
+	return j.To - j.From + 1
+}
+
+// Range returns a copy of the job covering blocks from to to only.
+func (j BlockJob) Range(from, to int64) BlockJob {
+	j.From, j.To = from, to
+	j.TraceContext = nil
+	return j
+}
+
+// WriteMode returns how the rows of the job are written: reindexes and
+// reorganizations replace the blocks, other jobs keep the rows already stored.
+func (j BlockJob) WriteMode() string {
+	if j.Mode != "" {
+		return j.Mode
+	}
+	switch j.Reason {
+	case c.JobReasonReindex, c.JobReasonReorg:
+		return c.WriteModeReplace
+	}
+	return c.WriteModeInsert
+}
+
+func (j BlockJob) Encode() ([]byte, error) {
+	body, err := json.Marshal(j)
+	if err != nil {
+		return nil, fmt.Errorf("Encode : %w", err)
+	}
+	return body, nil
+}
+
+// DecodeBlockJob decodes a job, the bare decimal block numbers published
+// before the job schema are read as a job of a single block of chainId.
+func DecodeBlockJob(body []byte, chainId int64) (BlockJob, error) {
+	if number, err := strconv.ParseInt(string(body), 10, 64); err == nil {
+		job := NewBlockJob(chainId, number, number, c.JobReasonHead)
+		job.Version = 0
+		return job, nil
+	}
+
+	var job BlockJob
+	if err := json.Unmarshal(body, &job); err != nil {
+		return BlockJob{}, fmt.Errorf("DecodeBlockJob : %w", err)
+	}
+	if job.Version > BlockJobVersion {
+		return BlockJob{}, fmt.Errorf("DecodeBlockJob : %w %d", ErrUnsupportedJobVersion, job.Version)
+	}
+	if job.From > job.To {
+		return BlockJob{}, fmt.Errorf("DecodeBlockJob : invalid range %d-%d", job.From, job.To)
+	}
+	switch job.Mode {
+	case "", c.WriteModeInsert, c.WriteModeUpsert, c.WriteModeReplace:
+	default:
+		return BlockJob{}, fmt.Errorf("DecodeBlockJob : unknown write mode %q", job.Mode)
+	}
+	return job, nil
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"Ethereum_Service/c"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBlockJob(t *testing.T) {
+	job := NewBlockJob(1, 100, 199, c.JobReasonBackfill)
+	job.TraceContext = map[string]string{"traceparent": "00-1-2-01"}
+	body, err := job.Encode()
+	assert.NoError(t, err)
+
+	got, err := DecodeBlockJob(body, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, job, got)
+	assert.Equal(t, int64(100), got.Size())
+	assert.Equal(t, uint8(c.JobPriorityBackfill), got.Priority)
+
+	// decimal heights published before the job schema are single block jobs
+	legacy, err := DecodeBlockJob([]byte("42"), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), legacy.ChainId)
+	assert.Equal(t, int64(42), legacy.From)
+	assert.Equal(t, int64(42), legacy.To)
+
+	_, err = DecodeBlockJob([]byte(`{"version":2,"from":1,"to":1}`), 1)
+	assert.ErrorIs(t, err, ErrUnsupportedJobVersion)
+	_, err = DecodeBlockJob([]byte(`{"version":1,"from":2,"to":1}`), 1)
+	assert.Error(t, err)
+}
+
+func TestBlockJobWriteMode(t *testing.T) {
+	assert.Equal(t, c.WriteModeInsert, NewBlockJob(1, 1, 1, c.JobReasonHead).WriteMode())
+	assert.Equal(t, c.WriteModeReplace, NewBlockJob(1, 1, 1, c.JobReasonReindex).WriteMode())
+
+	job := NewBlockJob(1, 1, 1, c.JobReasonReorg)
+	job.Mode = c.WriteModeUpsert
+	assert.Equal(t, c.WriteModeUpsert, job.WriteMode())
+
+	_, err := DecodeBlockJob([]byte(`{"version":1,"from":1,"to":1,"mode":"merge"}`), 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"encoding/base64"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	// EncodingRaw keeps the legacy behaviour of returning the bytes as a string
+	EncodingRaw    = "raw"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+// IsValidEncoding reports whether encoding can be requested by API clients.
+func IsValidEncoding(encoding string) bool {
+	switch encoding {
+	case EncodingRaw, EncodingHex, EncodingBase64:
+		return true
+	}
+	return false
+}
+
+// EncodeBytes encodes bytes fields of API responses, hex is used by default.
+func EncodeBytes(data []byte, encoding string) string {
+	switch encoding {
+	case EncodingRaw:
+		return string(data)
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(data)
+	default:
+		return hexutil.Encode(data)
+	}
+}
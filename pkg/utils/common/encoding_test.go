@@ -0,0 +1,19 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeBytes(t *testing.T) {
+	data := []byte{0xa9, 0x05, 0x9c, 0xbb}
+	assert.Equal(t, "0xa9059cbb", EncodeBytes(data, EncodingHex))
+	assert.Equal(t, "qQWcuw==", EncodeBytes(data, EncodingBase64))
+	assert.Equal(t, string(data), EncodeBytes(data, EncodingRaw))
+	assert.Equal(t, "0xa9059cbb", EncodeBytes(data, ""))
+	assert.Equal(t, "0x", EncodeBytes(nil, EncodingHex))
+
+	assert.True(t, IsValidEncoding(EncodingBase64))
+	assert.False(t, IsValidEncoding("utf8"))
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"Ethereum_Service/config"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"Ethereum_Service/pkg/utils/logger"
+
+	"github.com/golang-migrate/migrate/v4"
+	migration_pgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresMigrationDir is the directory of the PostgreSQL migrations within
+// MIGRATION_FILE_PATH, the MySQL migrations do not run on PostgreSQL.
+const postgresMigrationDir = "postgres"
+
+func OpenPostgresDatabase(option *config.DatabaseOption) (db *sql.DB, err error) {
+
+	if db, err = sql.Open("pgx", GetPostgresConnectionString(option)); err != nil {
+		return nil, fmt.Errorf("openPostgresDatabase: %v", err)
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("openPostgresDatabase: %v", err)
+	}
+
+	// Set connection pool
+	if option.PoolSize > 0 {
+		db.SetMaxIdleConns(option.PoolSize)
+		db.SetMaxOpenConns(option.PoolSize)
+	}
+
+	return
+}
+
+// GetPostgresConnectionString returns the URL of the database, TIMEOUT is the
+// connect timeout and TIMEZONE the session time zone.
+func GetPostgresConnectionString(option *config.DatabaseOption) string {
+	params := url.Values{}
+	if option.SSLMode != "" {
+		params.Set("sslmode", option.SSLMode)
+	}
+	if option.Timeout > 0 {
+		params.Set("connect_timeout", strconv.Itoa(int(option.Timeout.Seconds())))
+	}
+	if option.Timezone != "" {
+		params.Set("timezone", option.Timezone)
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(option.Username, option.Password),
+		Host:     net.JoinHostPort(option.Host, strconv.Itoa(int(option.Port))),
+		Path:     "/" + option.DBName,
+		RawQuery: params.Encode(),
+	}
+	return u.String()
+}
+
+func PostgresMigration(instance *sql.DB) error {
+	driver, err := migration_pgx.WithInstance(instance, &migration_pgx.Config{})
+	if err != nil {
+		return fmt.Errorf("Migrate: %v", err)
+	}
+	m, err := migrate.NewWithDatabaseInstance(
+		fmt.Sprintf("file://%s", filepath.Join(config.GetConfig().MigrationFilePath, postgresMigrationDir)),
+		config.GetConfig().Databases.DBName,
+		driver)
+	if err != nil {
+		return fmt.Errorf("Migrate: %v", err)
+	}
+
+	err = m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("Migrate: %v", err)
+	}
+
+	logger.Info("Migrate: Migrate successfully")
+	return nil
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"Ethereum_Service/config"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPostgresConnectionString(t *testing.T) {
+	option := &config.DatabaseOption{
+		Host:     "db.internal",
+		Port:     5433,
+		Username: "indexer",
+		Password: "p@ss:w/rd?#",
+		DBName:   "ethereum",
+		SSLMode:  "require",
+		Timeout:  5 * time.Second,
+		Timezone: "Asia/Taipei",
+	}
+	dsn := GetPostgresConnectionString(option)
+
+	// the password is escaped and read back by the driver as it was set
+	conf, err := pgx.ParseConfig(dsn)
+	assert.NoError(t, err)
+	assert.Equal(t, "db.internal", conf.Host)
+	assert.Equal(t, uint16(5433), conf.Port)
+	assert.Equal(t, "indexer", conf.User)
+	assert.Equal(t, "p@ss:w/rd?#", conf.Password)
+	assert.Equal(t, "ethereum", conf.Database)
+	assert.Equal(t, 5*time.Second, conf.ConnectTimeout)
+	assert.Equal(t, "Asia/Taipei", conf.RuntimeParams["timezone"])
+	assert.NotNil(t, conf.TLSConfig)
+
+	// the optional parameters are left to the driver defaults
+	dsn = GetPostgresConnectionString(&config.DatabaseOption{Host: "::1", Port: 5432, Username: "indexer", DBName: "ethereum"})
+	assert.Equal(t, "postgres://indexer:@[::1]:5432/ethereum", dsn)
+	conf, err = pgx.ParseConfig(dsn)
+	assert.NoError(t, err)
+	assert.Equal(t, "::1", conf.Host)
+	assert.NotContains(t, conf.RuntimeParams, "timezone")
+}
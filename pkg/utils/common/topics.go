@@ -0,0 +1,31 @@
+package common
+
+import (
+	"strings"
+
+	ethCommon "github.com/ethereum/go-ethereum/common"
+)
+
+const topicSeparator = ","
+
+// JoinTopics joins log topics into the form stored in the log table.
+func JoinTopics(topics []ethCommon.Hash) string {
+	hexTopics := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		hexTopics = append(hexTopics, topic.Hex())
+	}
+	return strings.Join(hexTopics, topicSeparator)
+}
+
+// SplitTopics splits the stored topics back into hashes.
+func SplitTopics(topics string) []ethCommon.Hash {
+	if topics == "" {
+		return []ethCommon.Hash{}
+	}
+	parts := strings.Split(topics, topicSeparator)
+	hashes := make([]ethCommon.Hash, 0, len(parts))
+	for _, part := range parts {
+		hashes = append(hashes, ethCommon.HexToHash(part))
+	}
+	return hashes
+}